@@ -1,12 +1,24 @@
 package main
 
 import (
+	"flag"
 	"fmt"
+	"os"
 
 	"github.com/errordeveloper/kubeplay/repl"
 )
 
 func main() {
+	flag.Parse()
+
+	if flag.NArg() > 0 {
+		if err := repl.RunScript(flag.Arg(0)); err != nil {
+			fmt.Printf("+++ Error: %v\n", err)
+			os.Exit(1)
+		}
+		return
+	}
+
 	repl, err := repl.NewRepl()
 	if err != nil {
 		panic(fmt.Errorf("repl.NewRepl: %v", err))
@@ -3,6 +3,7 @@ package repl
 import (
 	"fmt"
 	"io"
+	"io/ioutil"
 	"os"
 	"strings"
 
@@ -50,8 +51,13 @@ func (r *Repl) Loop() error {
 	context := mruby.NewCompileContext(r.rubykube.Mrb())
 	context.CaptureErrors(true)
 
+	r.rubykube.Lock()
+	defer r.rubykube.Unlock()
+
 	for {
+		r.rubykube.Unlock()
 		tmp, err := r.readline.Readline()
+		r.rubykube.Lock()
 		if err == io.EOF {
 			return nil
 		}
@@ -88,16 +94,62 @@ func (r *Repl) Loop() error {
 			continue
 		}
 
-		_, stackKeep, err = r.rubykube.RunCode(parser.GenerateCode(), stackKeep)
+		if err := r.rubykube.AddHistory(line); err != nil {
+			fmt.Printf("+++ Error: could not save history – %v\n", err)
+		}
+
+		r.rubykube.SetSource("", line)
+
+		var value *mruby.MrbValue
+		value, stackKeep, err = r.rubykube.RunCode(parser.GenerateCode(), stackKeep)
+		code := line
 		line = ""
 		r.rubykube.NormalPrompt()
 		if err != nil {
-			fmt.Printf("+++ Error: %v\n", err)
+			fmt.Printf("+++ Error: %s\n", rubykube.FormatError(err))
 			continue
 		}
 
-		//if val.String() != "" {
-		//	fmt.Println(val)
-		//}
+		if err := r.rubykube.RecordEvaluated(code); err != nil {
+			fmt.Printf("+++ Error: could not record the session – %v\n", err)
+		}
+
+		// print the value like irb does, except for nil, which most of the verbs return
+		if value.Type() != mruby.TypeNil {
+			inspected, err := r.rubykube.Inspect(value)
+			if err != nil {
+				fmt.Printf("+++ Error: %s\n", rubykube.FormatError(err))
+			} else {
+				fmt.Printf("=> %s\n", inspected)
+			}
+		}
+	}
+}
+
+// RunScript evaluates a file non-interactively, e.g. a session recorded with `-record`.
+func RunScript(path string) error {
+	script, err := ioutil.ReadFile(path)
+	if err != nil {
+		return err
+	}
+
+	rk, err := rubykube.NewRubyKube([]string{}, nil)
+	if err != nil {
+		return err
+	}
+
+	runErr := rk.RunFile(path, string(script))
+
+	failed, err := rk.ReportTests(os.Stdout)
+	if err != nil {
+		return err
+	}
+
+	if runErr != nil {
+		return fmt.Errorf("%s: %s", path, rubykube.FormatError(runErr))
+	}
+	if failed > 0 {
+		return fmt.Errorf("%s: %d test(s) failed", path, failed)
 	}
+	return nil
 }
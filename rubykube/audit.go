@@ -0,0 +1,378 @@
+package rubykube
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"os/user"
+	"path"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	mruby "github.com/mitchellh/go-mruby"
+)
+
+var auditLogFile = flag.String("audit-log", filepath.Join(kubeplayDir, "audit.jsonl"), "append every change made to the cluster to `file`, set to empty string to disable the audit log")
+
+// auditObject refers to the object a request was made for, it's parsed from the request path
+type auditObject struct {
+	APIVersion  string `json:"apiVersion"`
+	Resource    string `json:"resource"`
+	Namespace   string `json:"namespace,omitempty"`
+	Name        string `json:"name,omitempty"`
+	Subresource string `json:"subresource,omitempty"`
+}
+
+func (o auditObject) String() string {
+	s := o.Resource
+	if o.Subresource != "" {
+		s += "/" + o.Subresource
+	}
+	if o.Namespace != "" {
+		return s + " " + o.Namespace + "/" + o.Name
+	}
+	return s + " " + o.Name
+}
+
+type auditResult struct {
+	Code    int    `json:"code,omitempty"`
+	Status  string `json:"status"`
+	Message string `json:"message,omitempty"`
+}
+
+// auditEntry is a single change, entries are stored in the audit log as JSON, one per line
+type auditEntry struct {
+	Time      time.Time   `json:"time"`
+	Context   string      `json:"context,omitempty"`
+	User      string      `json:"user,omitempty"`
+	As        string      `json:"as,omitempty"`
+	LocalUser string      `json:"localUser,omitempty"`
+	Verb      string      `json:"verb"`
+	Object    auditObject `json:"object"`
+	Location  string      `json:"location,omitempty"`
+	Source    string      `json:"source,omitempty"`
+	Result    auditResult `json:"result"`
+}
+
+// auditLog appends an entry for every request that changes the cluster, requests are made from
+// worker goroutines, so it has to be safe for concurrent use
+type auditLog struct {
+	mutex     sync.Mutex
+	path      string
+	file      *os.File
+	state     *CurrentState
+	localUser string
+	// source is the code being evaluated, changes are attributed to it, location is
+	// file:line of the code when it's loaded from a file
+	location, source string
+}
+
+func newAuditLog(path string, state *CurrentState) (*auditLog, error) {
+	if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+		return nil, fmt.Errorf("cannot open audit log %q – %v", path, err)
+	}
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0600)
+	if err != nil {
+		return nil, fmt.Errorf("cannot open audit log %q – %v", path, err)
+	}
+
+	a := &auditLog{path: path, file: f, state: state}
+	if u, err := user.Current(); err == nil {
+		a.localUser = u.Username
+	}
+	return a, nil
+}
+
+func (a *auditLog) setSource(location, source string) {
+	a.mutex.Lock()
+	defer a.mutex.Unlock()
+	a.location, a.source = location, source
+}
+
+func (a *auditLog) write(verb string, object auditObject, result auditResult) error {
+	a.mutex.Lock()
+	defer a.mutex.Unlock()
+
+	data, err := json.Marshal(auditEntry{
+		Time:      time.Now().UTC(),
+		Context:   a.state.Context,
+		User:      a.state.User,
+		As:        a.state.As,
+		LocalUser: a.localUser,
+		Verb:      verb,
+		Object:    object,
+		Location:  a.location,
+		Source:    a.source,
+		Result:    result,
+	})
+	if err != nil {
+		return err
+	}
+	_, err = a.file.Write(append(data, '\n'))
+	return err
+}
+
+// wrap is used as `WrapTransport` of the client config, so that every request made by the
+// clientset goes through the audit log, including exec and port-forward upgrades
+func (a *auditLog) wrap(rt http.RoundTripper) http.RoundTripper {
+	return &auditTransport{log: a, next: rt}
+}
+
+type auditTransport struct {
+	log  *auditLog
+	next http.RoundTripper
+}
+
+// parseAPIPath parses `/api/<version>/...` and `/apis/<group>/<version>/...` paths
+func parseAPIPath(p string) (auditObject, bool) {
+	o := auditObject{}
+	parts := strings.Split(strings.Trim(p, "/"), "/")
+
+	switch {
+	case len(parts) >= 3 && parts[0] == "api":
+		o.APIVersion, parts = parts[1], parts[2:]
+	case len(parts) >= 4 && parts[0] == "apis":
+		o.APIVersion, parts = parts[1]+"/"+parts[2], parts[3:]
+	default:
+		return o, false
+	}
+
+	// `namespaces/<name>` refers to the namespace itself
+	if len(parts) >= 3 && parts[0] == "namespaces" {
+		o.Namespace, parts = parts[1], parts[2:]
+	}
+
+	o.Resource = parts[0]
+	if len(parts) >= 2 {
+		o.Name = parts[1]
+	}
+	if len(parts) >= 3 {
+		o.Subresource = parts[2]
+	}
+	return o, true
+}
+
+// requestVerb returns the verb of a request that changes the cluster, or an empty string for anything else;
+// access reviews are made with POST, but these don't change anything
+func requestVerb(method string, o auditObject) string {
+	switch o.Subresource {
+	case "exec", "attach", "portforward", "proxy":
+		return o.Subresource
+	case "eviction":
+		return "evict"
+	case "scale":
+		if method == http.MethodPut || method == http.MethodPatch {
+			return "scale"
+		}
+	}
+
+	if strings.HasPrefix(o.APIVersion, "authorization.k8s.io/") || strings.HasPrefix(o.APIVersion, "authentication.k8s.io/") {
+		return ""
+	}
+
+	switch method {
+	case http.MethodPost:
+		return "create"
+	case http.MethodPut:
+		return "update"
+	case http.MethodPatch:
+		return "patch"
+	case http.MethodDelete:
+		if o.Name == "" {
+			return "deletecollection"
+		}
+		return "delete"
+	}
+	return ""
+}
+
+// responseResult reads status of the response, and the name of the object, as it's not known
+// before a create request with `generateName` returns; the body is read and replaced, so the
+// client can still decode it
+func responseResult(resp *http.Response) (auditResult, string) {
+	result := auditResult{Code: resp.StatusCode, Status: "Success"}
+	if resp.StatusCode >= 400 {
+		result.Status = "Failure"
+	}
+
+	if resp.StatusCode == http.StatusSwitchingProtocols || resp.Body == nil ||
+		!strings.HasPrefix(resp.Header.Get("Content-Type"), "application/json") {
+		return result, ""
+	}
+
+	data, err := ioutil.ReadAll(resp.Body)
+	resp.Body.Close()
+	resp.Body = ioutil.NopCloser(bytes.NewReader(data))
+	if err != nil {
+		result.Message = fmt.Sprintf("could not read response – %v", err)
+		return result, ""
+	}
+
+	body := struct {
+		Kind     string `json:"kind"`
+		Message  string `json:"message"`
+		Metadata struct {
+			Name string `json:"name"`
+		} `json:"metadata"`
+	}{}
+	if err := json.Unmarshal(data, &body); err != nil {
+		return result, ""
+	}
+	if body.Kind == "Status" {
+		result.Message = body.Message
+	}
+	return result, body.Metadata.Name
+}
+
+func (t *auditTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	object, ok := parseAPIPath(req.URL.Path)
+	if !ok {
+		return t.next.RoundTrip(req)
+	}
+	verb := requestVerb(req.Method, object)
+	if verb == "" {
+		return t.next.RoundTrip(req)
+	}
+
+	resp, err := t.next.RoundTrip(req)
+
+	result := auditResult{Status: "Failure"}
+	if err != nil {
+		result.Message = err.Error()
+	} else {
+		var name string
+		if result, name = responseResult(resp); object.Name == "" {
+			object.Name = name
+		}
+	}
+
+	// the change has been made by now, so failing to record it is only reported
+	if err := t.log.write(verb, object, result); err != nil {
+		fmt.Printf("+++ Error: could not write audit log – %v\n", err)
+	}
+	return resp, err
+}
+
+// SetSource sets the code that changes made to the cluster are attributed to in the audit log,
+// location is file:line of the code, or empty when it's entered in the REPL
+func (rk *RubyKube) SetSource(location, source string) {
+	if rk.audit != nil {
+		rk.audit.setSource(location, source)
+	}
+}
+
+// auditFilter holds arguments of `audit` verb
+type auditFilter struct {
+	text    string
+	verb    string
+	context string
+	user    string
+	since   time.Duration
+}
+
+func (f *auditFilter) match(e auditEntry) bool {
+	if f.text != "" && !strings.Contains(e.Object.String()+"\n"+e.Location+"\n"+e.Source, f.text) {
+		return false
+	}
+	if f.verb != "" && e.Verb != f.verb {
+		return false
+	}
+	if f.context != "" {
+		if ok, _ := path.Match(f.context, e.Context); !ok {
+			return false
+		}
+	}
+	if f.user != "" && e.User != f.user && e.LocalUser != f.user && e.As != f.user {
+		return false
+	}
+	if f.since > 0 && time.Since(e.Time) > f.since {
+		return false
+	}
+	return true
+}
+
+func parseAuditFilter(args []*mruby.MrbValue) (*auditFilter, error) {
+	f := &auditFilter{}
+	for _, arg := range args {
+		switch arg.Type() {
+		case mruby.TypeString:
+			f.text = arg.String()
+		case mruby.TypeHash:
+			if err := iterateHash(arg, func(key, value *mruby.MrbValue) error {
+				switch k := key.String(); k {
+				case "verb":
+					f.verb = value.String()
+				case "context":
+					f.context = value.String()
+					if _, err := path.Match(f.context, ""); err != nil {
+						return newArgumentError("invalid context glob %q – %v", f.context, err)
+					}
+				case "user":
+					f.user = value.String()
+				case "since":
+					d, err := durationValue(k, value)
+					if err != nil {
+						return err
+					}
+					f.since = d
+				default:
+					return newArgumentError("unknown parameter %q – not one of [verb context user since]", k)
+				}
+				return nil
+			}); err != nil {
+				return nil, err
+			}
+		default:
+			return nil, newArgumentError("Arguments must be a string to search for and/or a hash")
+		}
+	}
+	return f, nil
+}
+
+// audit implements `audit` verb, which returns entries of the audit log as an array of hashes, e.g. `audit "nginx"`
+// or `audit verb: "delete", since: "24h"`; the file is read every time, as other sessions append to it too
+func audit(rk *RubyKube, args []*mruby.MrbValue, m *mruby.Mrb, self *mruby.MrbValue) (mruby.Value, mruby.Value) {
+	if rk.audit == nil {
+		return nil, createException(m, "Audit log is disabled")
+	}
+
+	filter, err := parseAuditFilter(args)
+	if err != nil {
+		return nil, createError(m, err)
+	}
+
+	f, err := os.Open(rk.audit.path)
+	if err != nil {
+		return nil, createError(m, err)
+	}
+	defer f.Close()
+
+	entries := []auditEntry{}
+	scanner := bufio.NewScanner(f)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		entry := auditEntry{}
+		if err := json.Unmarshal(scanner.Bytes(), &entry); err != nil {
+			continue
+		}
+		if filter.match(entry) {
+			entries = append(entries, entry)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, createError(m, err)
+	}
+
+	value, err := nativeRubyValueOf(m, entries)
+	if err != nil {
+		return nil, createError(m, err)
+	}
+	return value, nil
+}
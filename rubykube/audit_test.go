@@ -0,0 +1,81 @@
+package rubykube
+
+import (
+	"bufio"
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestAuditLogWrite(t *testing.T) {
+	dir, err := ioutil.TempDir("", "kubeplay-audit")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	log, err := newAuditLog(filepath.Join(dir, "audit.jsonl"), &CurrentState{Context: "prod-eu", User: "alice"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer log.file.Close()
+
+	log.setSource("", "pods(\"prod/\").any.delete!\n")
+	if err := log.write("delete", auditObject{APIVersion: "v1", Resource: "pods", Namespace: "prod", Name: "web-a"}, auditResult{Code: 200, Status: "Success"}); err != nil {
+		t.Fatal(err)
+	}
+	log.setSource("deploy.rb:12", "deployments(\"prod/web\").scale!(3)\n")
+	if err := log.write("scale", auditObject{APIVersion: "apps/v1", Resource: "deployments", Namespace: "prod", Name: "web", Subresource: "scale"}, auditResult{Code: 200, Status: "Success"}); err != nil {
+		t.Fatal(err)
+	}
+
+	f, err := os.Open(log.path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+
+	entries := []auditEntry{}
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		entry := auditEntry{}
+		if err := json.Unmarshal(scanner.Bytes(), &entry); err != nil {
+			t.Fatal(err)
+		}
+		entries = append(entries, entry)
+	}
+	if len(entries) != 2 {
+		t.Fatalf("expected 2 entries, got %d", len(entries))
+	}
+
+	if e := entries[0]; e.Context != "prod-eu" || e.User != "alice" || e.Verb != "delete" || e.Location != "" || e.Source != "pods(\"prod/\").any.delete!\n" {
+		t.Errorf("unexpected entry of REPL input: %+v", e)
+	}
+	if e := entries[1]; e.Verb != "scale" || e.Location != "deploy.rb:12" || e.Source != "deployments(\"prod/web\").scale!(3)\n" {
+		t.Errorf("unexpected entry of a script: %+v", e)
+	}
+
+	tests := []struct {
+		filter  auditFilter
+		matches []bool
+	}{
+		{auditFilter{}, []bool{true, true}},
+		{auditFilter{text: "web-a"}, []bool{true, false}},
+		{auditFilter{text: "deploy.rb"}, []bool{false, true}},
+		{auditFilter{text: "scale!"}, []bool{false, true}},
+		{auditFilter{verb: "delete"}, []bool{true, false}},
+		{auditFilter{context: "prod-*"}, []bool{true, true}},
+		{auditFilter{context: "dev-*"}, []bool{false, false}},
+		{auditFilter{user: "bob"}, []bool{false, false}},
+	}
+
+	for _, test := range tests {
+		for i, e := range entries {
+			if match := test.filter.match(e); match != test.matches[i] {
+				t.Errorf("expected %+v to match entry %d: %v, got %v", test.filter, i, test.matches[i], match)
+			}
+		}
+	}
+}
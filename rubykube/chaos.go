@@ -0,0 +1,428 @@
+package rubykube
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"math/rand"
+	"os"
+	"os/signal"
+	"strconv"
+	"strings"
+	"time"
+
+	mruby "github.com/mitchellh/go-mruby"
+	corev1 "k8s.io/api/core/v1"
+	policyv1beta1 "k8s.io/api/policy/v1beta1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+)
+
+// chaosOptions are parameters of `chaos` verb, other keys of the hash (`labels` and `fields`)
+// are passed on to `pods`
+type chaosOptions struct {
+	interval    time.Duration
+	probability float64
+	rounds      int     // 0 means run until interrupted
+	maxPods     int     // per round, 0 means no limit
+	maxPerOwner int     // per round, 0 means no limit, unless ownerRatio is set
+	ownerRatio  float64 // per round, as a fraction of pods of each owner (rounded down)
+	dryRun      bool
+	respectPDBs bool
+	logFile     string
+}
+
+var chaosOptionKeys = []string{"interval", "probability", "rounds", "max_pods", "max_per_owner", "dry_run", "respect_pdb", "log"}
+
+// chaosEvent is an entry of the log `chaos` keeps
+type chaosEvent struct {
+	Time      time.Time `json:"time"`
+	Action    string    `json:"action"` // "killed", "would kill" or "skipped"
+	Namespace string    `json:"namespace"`
+	Pod       string    `json:"pod"`
+	Owner     string    `json:"owner,omitempty"`
+	Reason    string    `json:"reason,omitempty"`
+}
+
+func (e chaosEvent) String() string {
+	s := fmt.Sprintf("[%s] %s %s/%s", e.Time.Format("15:04:05"), e.Action, e.Namespace, e.Pod)
+	if e.Owner != "" {
+		s += fmt.Sprintf(" (owner: %s)", e.Owner)
+	}
+	if e.Reason != "" {
+		s += " – " + e.Reason
+	}
+	return s
+}
+
+func isChaosOption(key string) bool {
+	for _, k := range chaosOptionKeys {
+		if k == key {
+			return true
+		}
+	}
+	return false
+}
+
+func numberValue(key string, value *mruby.MrbValue) (float64, error) {
+	switch value.Type() {
+	case mruby.TypeFixnum:
+		return float64(value.Fixnum()), nil
+	case mruby.TypeFloat:
+		return value.Float(), nil
+	default:
+		return 0, newArgumentError("%q must be a number", key)
+	}
+}
+
+// parseChaosOptions takes chaos options out of the hash, and returns a hash with the rest (if any)
+func parseChaosOptions(m *mruby.Mrb, hash *mruby.MrbValue) (*chaosOptions, *mruby.MrbValue, error) {
+	o := &chaosOptions{interval: time.Minute, probability: 0.1, respectPDBs: true}
+
+	if hash == nil {
+		return o, nil, nil
+	}
+
+	rest, err := m.LoadString("{}")
+	if err != nil {
+		return nil, nil, err
+	}
+	if err := protectValue(m, rest); err != nil {
+		return nil, nil, err
+	}
+	defer unprotectValue(m, rest)
+	hasRest := false
+
+	if err := iterateHash(hash, func(key, value *mruby.MrbValue) error {
+		k := key.String()
+		if !isChaosOption(k) {
+			hasRest = true
+			return rest.Hash().Set(key, value)
+		}
+
+		switch k {
+		case "interval":
+			n, err := numberValue(k, value)
+			if err != nil {
+				return err
+			}
+			o.interval = time.Duration(n * float64(time.Second))
+		case "probability":
+			n, err := numberValue(k, value)
+			if err != nil {
+				return err
+			}
+			if n < 0 || n > 1 {
+				return newArgumentError("probability must be between 0 and 1")
+			}
+			o.probability = n
+		case "rounds", "max_pods":
+			if value.Type() != mruby.TypeFixnum || value.Fixnum() < 0 {
+				return newArgumentError("%q must be a non-negative integer", k)
+			}
+			if k == "rounds" {
+				o.rounds = value.Fixnum()
+			} else {
+				o.maxPods = value.Fixnum()
+			}
+		case "max_per_owner":
+			switch value.Type() {
+			case mruby.TypeFixnum:
+				o.maxPerOwner = value.Fixnum()
+			case mruby.TypeString:
+				percent, err := strconv.ParseFloat(strings.TrimSuffix(value.String(), "%"), 64)
+				if err != nil || !strings.HasSuffix(value.String(), "%") || percent <= 0 || percent > 100 {
+					return newArgumentError("max_per_owner must be an integer or a percentage, e.g. \"25%%\"")
+				}
+				o.ownerRatio = percent / 100
+			default:
+				return newArgumentError("max_per_owner must be an integer or a percentage, e.g. \"25%%\"")
+			}
+		case "dry_run", "respect_pdb":
+			t := value.Type()
+			if t != mruby.TypeTrue && t != mruby.TypeFalse {
+				return newArgumentError("%q must be true or false", k)
+			}
+			if k == "dry_run" {
+				o.dryRun = t == mruby.TypeTrue
+			} else {
+				o.respectPDBs = t == mruby.TypeTrue
+			}
+		case "log":
+			o.logFile = value.String()
+		}
+		return nil
+	}); err != nil {
+		return nil, nil, err
+	}
+
+	if o.interval <= 0 {
+		return nil, nil, newArgumentError("interval must be positive")
+	}
+
+	if !hasRest {
+		return o, nil, nil
+	}
+	return o, rest, nil
+}
+
+func podOwner(pod *corev1.Pod) string {
+	if ref := metav1.GetControllerOf(pod); ref != nil {
+		return ref.Kind + "/" + ref.Name
+	}
+	return ""
+}
+
+// chaosMonkey holds state of a single `chaos` run
+type chaosMonkey struct {
+	rk      *RubyKube
+	options *chaosOptions
+	events  []chaosEvent
+	log     *os.File
+	random  *rand.Rand
+}
+
+func (c *chaosMonkey) record(action string, pod *corev1.Pod, reason string) error {
+	event := chaosEvent{
+		Time:      time.Now(),
+		Action:    action,
+		Namespace: pod.ObjectMeta.Namespace,
+		Pod:       pod.ObjectMeta.Name,
+		Owner:     podOwner(pod),
+		Reason:    reason,
+	}
+	c.events = append(c.events, event)
+	fmt.Println(event)
+
+	if c.log == nil {
+		return nil
+	}
+	data, err := json.Marshal(event)
+	if err != nil {
+		return err
+	}
+	_, err = c.log.Write(append(data, '\n'))
+	return err
+}
+
+// disruptionBudgets returns PDBs that select the pod, PDBs are listed once per namespace in each round
+func (c *chaosMonkey) disruptionBudgets(pod *corev1.Pod, budgets map[string][]policyv1beta1.PodDisruptionBudget) ([]policyv1beta1.PodDisruptionBudget, error) {
+	ns := pod.ObjectMeta.Namespace
+	if _, ok := budgets[ns]; !ok {
+		list, err := c.rk.clientset.Policy().PodDisruptionBudgets(ns).List(metav1.ListOptions{})
+		if err != nil {
+			return nil, err
+		}
+		budgets[ns] = list.Items
+	}
+
+	matching := []policyv1beta1.PodDisruptionBudget{}
+	for _, pdb := range budgets[ns] {
+		selector, err := metav1.LabelSelectorAsSelector(pdb.Spec.Selector)
+		if err != nil || selector.Empty() || !selector.Matches(labels.Set(pod.ObjectMeta.Labels)) {
+			continue
+		}
+		matching = append(matching, pdb)
+	}
+	return matching, nil
+}
+
+// disruptionAllowed checks PDBs on the client, which is only needed in dry-run mode, as eviction API
+// does the same on the server; disrupted counts pods that would have been evicted in this round
+func (c *chaosMonkey) disruptionAllowed(pod *corev1.Pod, budgets map[string][]policyv1beta1.PodDisruptionBudget, disrupted map[string]int) (string, error) {
+	matching, err := c.disruptionBudgets(pod, budgets)
+	if err != nil {
+		return "", err
+	}
+
+	for _, pdb := range matching {
+		if int(pdb.Status.PodDisruptionsAllowed)-disrupted[pdb.ObjectMeta.Namespace+"/"+pdb.ObjectMeta.Name] <= 0 {
+			return pdb.ObjectMeta.Name, nil
+		}
+	}
+	for _, pdb := range matching {
+		disrupted[pdb.ObjectMeta.Namespace+"/"+pdb.ObjectMeta.Name]++
+	}
+	return "", nil
+}
+
+// round picks victims from the pods matching the query, and kills them
+func (c *chaosMonkey) round(args []*mruby.MrbValue) error {
+	newPodsObj, err := c.rk.classes.Pods.New()
+	if err != nil {
+		return err
+	}
+	if _, err := newPodsObj.Update(args...); err != nil {
+		return err
+	}
+
+	candidates := []corev1.Pod{}
+	owned := map[string]int{}
+	for _, pod := range newPodsObj.vars.pods.Items {
+		owned[podOwner(&pod)]++
+		if pod.Status.Phase == corev1.PodRunning && pod.ObjectMeta.DeletionTimestamp == nil {
+			candidates = append(candidates, pod)
+		}
+	}
+
+	killed := 0
+	killedPerOwner := map[string]int{}
+	// PDBs are checked on the client only in dry-run mode, otherwise eviction API does it
+	budgets := map[string][]policyv1beta1.PodDisruptionBudget{}
+	disrupted := map[string]int{}
+
+	for _, i := range c.random.Perm(len(candidates)) {
+		pod := &candidates[i]
+		if c.random.Float64() >= c.options.probability {
+			continue
+		}
+
+		if c.options.maxPods > 0 && killed >= c.options.maxPods {
+			// that's it for this round
+			return c.record("skipped", pod, fmt.Sprintf("max_pods limit of %d reached", c.options.maxPods))
+		}
+
+		owner := podOwner(pod)
+		if owner != "" {
+			limit := c.options.maxPerOwner
+			if c.options.ownerRatio > 0 {
+				limit = int(math.Floor(c.options.ownerRatio * float64(owned[owner])))
+			}
+			if (limit > 0 || c.options.ownerRatio > 0) && killedPerOwner[owner] >= limit {
+				if err := c.record("skipped", pod, fmt.Sprintf("max_per_owner limit of %d reached for %s", limit, owner)); err != nil {
+					return err
+				}
+				continue
+			}
+		}
+
+		if c.options.dryRun {
+			if c.options.respectPDBs {
+				blockedBy, err := c.disruptionAllowed(pod, budgets, disrupted)
+				if err != nil {
+					return err
+				}
+				if blockedBy != "" {
+					if err := c.record("skipped", pod, fmt.Sprintf("disruption budget %q doesn't allow it", blockedBy)); err != nil {
+						return err
+					}
+					continue
+				}
+			}
+			if err := c.record("would kill", pod, ""); err != nil {
+				return err
+			}
+		} else {
+			if err := c.kill(pod); err != nil {
+				if apierrors.IsTooManyRequests(err) {
+					if err := c.record("skipped", pod, "a disruption budget doesn't allow it"); err != nil {
+						return err
+					}
+					continue
+				}
+				if apierrors.IsNotFound(err) {
+					continue
+				}
+				return err
+			}
+			if err := c.record("killed", pod, ""); err != nil {
+				return err
+			}
+		}
+
+		killed++
+		killedPerOwner[owner]++
+	}
+
+	return nil
+}
+
+func (c *chaosMonkey) kill(pod *corev1.Pod) error {
+	pods := c.rk.clientset.Core().Pods(pod.ObjectMeta.Namespace)
+	if !c.options.respectPDBs {
+		return pods.Delete(pod.ObjectMeta.Name, &metav1.DeleteOptions{})
+	}
+	return pods.Evict(&policyv1beta1.Eviction{
+		ObjectMeta: metav1.ObjectMeta{Namespace: pod.ObjectMeta.Namespace, Name: pod.ObjectMeta.Name},
+	})
+}
+
+// chaos kills random pods matching a query every `interval` seconds, until interrupted with ^C or
+// after given number of `rounds`; it returns the log of what it has done
+func chaos(rk *RubyKube, args []*mruby.MrbValue, m *mruby.Mrb, self *mruby.MrbValue) (mruby.Value, mruby.Value) {
+	var hash *mruby.MrbValue
+	podsArgs := []*mruby.MrbValue{}
+	for _, arg := range args {
+		if arg.Type() == mruby.TypeHash {
+			hash = arg
+			continue
+		}
+		podsArgs = append(podsArgs, arg)
+	}
+
+	options, rest, err := parseChaosOptions(m, hash)
+	if err != nil {
+		return nil, createError(m, err)
+	}
+	if rest != nil {
+		// the hash is only referenced from Go, and it's used until the verb returns
+		if err := protectValue(m, rest); err != nil {
+			return nil, createError(m, err)
+		}
+		defer unprotectValue(m, rest)
+		podsArgs = append(podsArgs, rest)
+	}
+
+	monkey := &chaosMonkey{
+		rk:      rk,
+		events:  []chaosEvent{},
+		options: options,
+		random:  rand.New(rand.NewSource(time.Now().UnixNano())),
+	}
+
+	if options.logFile != "" {
+		monkey.log, err = os.OpenFile(options.logFile, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0644)
+		if err != nil {
+			return nil, createError(m, err)
+		}
+		defer monkey.log.Close()
+	}
+
+	interrupt := make(chan os.Signal, 1)
+	signal.Notify(interrupt, os.Interrupt)
+	defer signal.Stop(interrupt)
+
+	mode := ""
+	if options.dryRun {
+		mode = " (dry run)"
+	} else if err := rk.allowChange("evict random pods with chaos monkey"); err != nil {
+		return nil, createError(m, err)
+	}
+	fmt.Printf("chaos monkey started%s, probability=%g, interval=%s – press ^C to stop\n", mode, options.probability, options.interval)
+
+loop:
+	for round := 1; options.rounds == 0 || round <= options.rounds; round++ {
+		if err := monkey.round(podsArgs); err != nil {
+			return nil, createError(m, err)
+		}
+
+		if round == options.rounds {
+			break
+		}
+
+		select {
+		case <-interrupt:
+			break loop
+		case <-time.After(options.interval):
+		}
+	}
+
+	fmt.Printf("chaos monkey stopped, %d event(s) logged\n", len(monkey.events))
+
+	events, err := nativeRubyValueOf(m, monkey.events)
+	if err != nil {
+		return nil, createError(m, err)
+	}
+	return events, nil
+}
@@ -0,0 +1,291 @@
+package rubykube
+
+import (
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	mruby "github.com/mitchellh/go-mruby"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// completionCacheTTL is how long namespace and object names are kept for, so that
+// completion doesn't query API server on every keypress
+const completionCacheTTL = 10 * time.Second
+
+// verbClasses maps verbs to classes of the objects they return, so methods can be
+// completed without evaluating anything, e.g. `pods("foo/").<TAB>`
+var verbClasses = map[string]string{
+	"pods":                "Pods",
+	"services":            "Services",
+	"deployments":         "Deployments",
+	"replicasets":         "ReplicaSets",
+	"daemonsets":          "DaemonSets",
+	"jobs":                "Jobs",
+	"events":              "Events",
+	"make_pod":            "Pod",
+	"make_label_selector": "LabelSelector",
+	"make_field_selector": "FieldSelector",
+}
+
+var replKeywords = []string{"exit", "help", "quit"}
+
+type completionCacheEntry struct {
+	names   []string
+	fetched time.Time
+}
+
+type completer struct {
+	rk    *RubyKube
+	mutex sync.Mutex
+	cache map[string]completionCacheEntry
+}
+
+func newCompleter(rk *RubyKube) *completer {
+	return &completer{rk: rk, cache: map[string]completionCacheEntry{}}
+}
+
+// Do implements `readline.AutoCompleter`, it returns suffixes of the candidates and
+// the length of the prefix they share with the line
+func (c *completer) Do(line []rune, pos int) ([][]rune, int) {
+	text := string(line[:pos])
+
+	if quote, ok := openStringLiteral(text); ok {
+		return c.completeString(text[:quote], text[quote+1:])
+	}
+
+	word := lastIdentifier(text)
+	before := strings.TrimRight(text[:len(text)-len(word)], " ")
+
+	if strings.HasSuffix(before, ".") {
+		return suffixes(c.methodNames(strings.TrimSuffix(before, ".")), word)
+	}
+
+	if before == "" || strings.HasSuffix(before, ";") || strings.HasSuffix(before, "=") || strings.HasSuffix(before, "(") {
+		return suffixes(c.verbNames(), word)
+	}
+
+	return nil, 0
+}
+
+// openStringLiteral returns position of the opening quote, if the cursor is inside a string literal
+func openStringLiteral(text string) (int, bool) {
+	var quote rune
+	start := -1
+	escaped := false
+	for i, r := range text {
+		switch {
+		case escaped:
+			escaped = false
+		case r == '\\':
+			escaped = true
+		case quote == 0 && (r == '"' || r == '\''):
+			quote, start = r, i
+		case r == quote:
+			quote, start = 0, -1
+		}
+	}
+	return start, start >= 0
+}
+
+func isIdentifierRune(r rune) bool {
+	return r == '_' || r == '@' || r == '?' || r == '!' || r == '$' ||
+		(r >= 'a' && r <= 'z') || (r >= 'A' && r <= 'Z') || (r >= '0' && r <= '9')
+}
+
+func lastIdentifier(text string) string {
+	i := len(text)
+	for i > 0 && isIdentifierRune(rune(text[i-1])) {
+		i--
+	}
+	return text[i:]
+}
+
+// receiverVerb finds the verb a method or a string argument belongs to, e.g. `pods("foo/")` or `pods "`
+func (c *completer) receiverVerb(text string) string {
+	text = strings.TrimRight(text, " (")
+	if strings.HasSuffix(text, ")") {
+		depth := 0
+		for i := len(text) - 1; i >= 0; i-- {
+			switch text[i] {
+			case ')':
+				depth++
+			case '(':
+				depth--
+			}
+			if depth == 0 {
+				text = strings.TrimRight(text[:i], " ")
+				break
+			}
+		}
+	}
+	verb := lastIdentifier(text)
+	if v, ok := c.rk.aliases[verb]; ok {
+		return v
+	}
+	return verb
+}
+
+func (c *completer) verbNames() []string {
+	names := append([]string{}, replKeywords...)
+	for name := range verbJumpTable {
+		names = append(names, name)
+	}
+	for name := range funcJumpTable {
+		names = append(names, name)
+	}
+	for name := range c.rk.aliases {
+		names = append(names, name)
+	}
+	return names
+}
+
+// methodNames returns methods of the receiver's class, verbs are resolved statically and only
+// variables (e.g. `@pod` or `_`) are looked up, so completion never evaluates code
+func (c *completer) methodNames(receiver string) []string {
+	className := ""
+	if verb := c.receiverVerb(receiver); verb != "" {
+		className = verbClasses[verb]
+	}
+
+	if className == "" {
+		variable := lastIdentifier(receiver)
+		if variable != receiver || (variable != "_" && !strings.HasPrefix(variable, "@")) {
+			return nil
+		}
+		className = c.variableClass(variable)
+	}
+
+	return c.rk.instanceMethods[className]
+}
+
+// variableClass returns class name of the value `_` or an instance variable refers to, it
+// reads the value directly while holding the interpreter, as the REPL may be evaluating code
+func (c *completer) variableClass(variable string) string {
+	c.rk.Lock()
+	defer c.rk.Unlock()
+
+	var value *mruby.MrbValue
+	if variable == "_" {
+		value = c.rk.mrb.GetGlobalVariable(lastValueVariable)
+	} else {
+		value = c.rk.mrb.TopSelf().GetInstanceVariable(variable)
+	}
+	if value == nil || value.Type() == mruby.TypeNil {
+		return ""
+	}
+	return value.Class().String()
+}
+
+// completeString completes globs given to resource verbs, as well as `namespace`
+func (c *completer) completeString(before, prefix string) ([][]rune, int) {
+	verb := c.receiverVerb(before)
+
+	if verb == "namespace" {
+		return suffixes(c.namespaceNames(), prefix)
+	}
+
+	lister := c.nameLister(verb)
+	if lister == nil {
+		return nil, 0
+	}
+
+	if i := strings.Index(prefix, "/"); i >= 0 {
+		ns := prefix[:i]
+		if ns == "*" {
+			ns = ""
+		} else if hasGlobMeta(ns) {
+			return nil, 0
+		}
+
+		candidates := []string{}
+		for _, name := range c.objectNames(verb, lister, ns) {
+			candidates = append(candidates, prefix[:i+1]+nameOf(name))
+		}
+		return suffixes(candidates, prefix)
+	}
+
+	candidates := []string{"*/"}
+	for _, ns := range c.namespaceNames() {
+		candidates = append(candidates, ns+"/")
+	}
+	for _, name := range c.objectNames(verb, lister, c.rk.GetNamespace("")) {
+		candidates = append(candidates, nameOf(name))
+	}
+	return suffixes(candidates, prefix)
+}
+
+func nameOf(namespacedName string) string {
+	return namespacedName[strings.Index(namespacedName, "/")+1:]
+}
+
+func (c *completer) nameLister(verb string) func(string) ([]string, error) {
+	switch verb {
+	case "pods":
+		return c.rk.classes.Pods.listNames
+	case "services":
+		return c.rk.classes.Services.listNames
+	case "deployments":
+		return c.rk.classes.Deployments.listNames
+	case "replicasets":
+		return c.rk.classes.ReplicaSets.listNames
+	case "daemonsets":
+		return c.rk.classes.DaemonSets.listNames
+	case "jobs":
+		return c.rk.classes.Jobs.listNames
+	case "events":
+		return c.rk.classes.Events.listNames
+	}
+	return nil
+}
+
+func (c *completer) namespaceNames() []string {
+	return c.cached("namespaces", func() ([]string, error) {
+		namespaces, err := c.rk.clientset.Core().Namespaces().List(metav1.ListOptions{})
+		if err != nil {
+			return nil, err
+		}
+		names := []string{}
+		for _, ns := range namespaces.Items {
+			names = append(names, ns.ObjectMeta.Name)
+		}
+		return names, nil
+	})
+}
+
+func (c *completer) objectNames(verb string, lister func(string) ([]string, error), ns string) []string {
+	return c.cached(verb+"/"+ns, func() ([]string, error) { return lister(ns) })
+}
+
+func (c *completer) cached(key string, fetch func() ([]string, error)) []string {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	if entry, ok := c.cache[key]; ok && time.Since(entry.fetched) < completionCacheTTL {
+		return entry.names
+	}
+
+	names, err := fetch()
+	if err != nil {
+		return nil
+	}
+	c.cache[key] = completionCacheEntry{names: names, fetched: time.Now()}
+	return names
+}
+
+// suffixes returns what readline expects – the remainder of each candidate matching the prefix
+func suffixes(candidates []string, prefix string) ([][]rune, int) {
+	sort.Strings(candidates)
+
+	seen := map[string]bool{}
+	out := [][]rune{}
+	for _, candidate := range candidates {
+		if seen[candidate] || !strings.HasPrefix(candidate, prefix) {
+			continue
+		}
+		seen[candidate] = true
+		out = append(out, []rune(candidate[len(prefix):]))
+	}
+	return out, len([]rune(prefix))
+}
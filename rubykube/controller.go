@@ -0,0 +1,637 @@
+package rubykube
+
+import (
+	"fmt"
+	"os"
+	"os/signal"
+	"reflect"
+	"sync"
+	"time"
+
+	mruby "github.com/mitchellh/go-mruby"
+	appsv1 "k8s.io/api/apps/v1"
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/util/rand"
+	"k8s.io/apimachinery/pkg/watch"
+	"k8s.io/client-go/tools/cache"
+	"k8s.io/client-go/tools/leaderelection/resourcelock"
+	"k8s.io/client-go/util/workqueue"
+)
+
+const (
+	defaultResyncPeriod = 5 * time.Minute
+	defaultMaxRetries   = 5
+
+	// these are the defaults used by Kubernetes components
+	leaseDuration = 15 * time.Second
+	renewDeadline = 10 * time.Second
+	retryPeriod   = 2 * time.Second
+)
+
+// controllerKind tells a controller how to list and watch objects of a kind, and how to make
+// Ruby objects out of them
+type controllerKind struct {
+	objType   runtime.Object
+	list      func(rk *RubyKube, ns string, listOptions metav1.ListOptions) (runtime.Object, error)
+	watch     func(rk *RubyKube, ns string, listOptions metav1.ListOptions) (watch.Interface, error)
+	newObject func(rk *RubyKube, obj runtime.Object) (*mruby.MrbValue, error)
+}
+
+var controllerKinds = map[string]controllerKind{
+	"pods": {
+		objType: &corev1.Pod{},
+		list: func(rk *RubyKube, ns string, listOptions metav1.ListOptions) (runtime.Object, error) {
+			return rk.classes.Pods.getList(ns, listOptions)
+		},
+		watch: func(rk *RubyKube, ns string, listOptions metav1.ListOptions) (watch.Interface, error) {
+			return rk.classes.Pod.watchSingleton(ns, listOptions)
+		},
+		newObject: func(rk *RubyKube, obj runtime.Object) (*mruby.MrbValue, error) {
+			o, err := rk.classes.Pods.getItem(podListTypeAlias{Items: []corev1.Pod{*obj.(*corev1.Pod)}}, 0)
+			if err != nil {
+				return nil, err
+			}
+			return o.self, nil
+		},
+	},
+	"services": {
+		objType: &corev1.Service{},
+		list: func(rk *RubyKube, ns string, listOptions metav1.ListOptions) (runtime.Object, error) {
+			return rk.classes.Services.getList(ns, listOptions)
+		},
+		watch: func(rk *RubyKube, ns string, listOptions metav1.ListOptions) (watch.Interface, error) {
+			return rk.classes.Service.watchSingleton(ns, listOptions)
+		},
+		newObject: func(rk *RubyKube, obj runtime.Object) (*mruby.MrbValue, error) {
+			o, err := rk.classes.Services.getItem(serviceListTypeAlias{Items: []corev1.Service{*obj.(*corev1.Service)}}, 0)
+			if err != nil {
+				return nil, err
+			}
+			return o.self, nil
+		},
+	},
+	"deployments": {
+		objType: &appsv1.Deployment{},
+		list: func(rk *RubyKube, ns string, listOptions metav1.ListOptions) (runtime.Object, error) {
+			return rk.classes.Deployments.getList(ns, listOptions)
+		},
+		watch: func(rk *RubyKube, ns string, listOptions metav1.ListOptions) (watch.Interface, error) {
+			return rk.classes.Deployment.watchSingleton(ns, listOptions)
+		},
+		newObject: func(rk *RubyKube, obj runtime.Object) (*mruby.MrbValue, error) {
+			o, err := rk.classes.Deployments.getItem(deploymentListTypeAlias{Items: []appsv1.Deployment{*obj.(*appsv1.Deployment)}}, 0)
+			if err != nil {
+				return nil, err
+			}
+			return o.self, nil
+		},
+	},
+	"replicasets": {
+		objType: &appsv1.ReplicaSet{},
+		list: func(rk *RubyKube, ns string, listOptions metav1.ListOptions) (runtime.Object, error) {
+			return rk.classes.ReplicaSets.getList(ns, listOptions)
+		},
+		watch: func(rk *RubyKube, ns string, listOptions metav1.ListOptions) (watch.Interface, error) {
+			return rk.classes.ReplicaSet.watchSingleton(ns, listOptions)
+		},
+		newObject: func(rk *RubyKube, obj runtime.Object) (*mruby.MrbValue, error) {
+			o, err := rk.classes.ReplicaSets.getItem(replicaSetListTypeAlias{Items: []appsv1.ReplicaSet{*obj.(*appsv1.ReplicaSet)}}, 0)
+			if err != nil {
+				return nil, err
+			}
+			return o.self, nil
+		},
+	},
+	"daemonsets": {
+		objType: &appsv1.DaemonSet{},
+		list: func(rk *RubyKube, ns string, listOptions metav1.ListOptions) (runtime.Object, error) {
+			return rk.classes.DaemonSets.getList(ns, listOptions)
+		},
+		watch: func(rk *RubyKube, ns string, listOptions metav1.ListOptions) (watch.Interface, error) {
+			return rk.classes.DaemonSet.watchSingleton(ns, listOptions)
+		},
+		newObject: func(rk *RubyKube, obj runtime.Object) (*mruby.MrbValue, error) {
+			o, err := rk.classes.DaemonSets.getItem(daemonSetListTypeAlias{Items: []appsv1.DaemonSet{*obj.(*appsv1.DaemonSet)}}, 0)
+			if err != nil {
+				return nil, err
+			}
+			return o.self, nil
+		},
+	},
+	"jobs": {
+		objType: &batchv1.Job{},
+		list: func(rk *RubyKube, ns string, listOptions metav1.ListOptions) (runtime.Object, error) {
+			return rk.classes.Jobs.getList(ns, listOptions)
+		},
+		watch: func(rk *RubyKube, ns string, listOptions metav1.ListOptions) (watch.Interface, error) {
+			return rk.classes.Job.watchSingleton(ns, listOptions)
+		},
+		newObject: func(rk *RubyKube, obj runtime.Object) (*mruby.MrbValue, error) {
+			o, err := rk.classes.Jobs.getItem(jobListTypeAlias{Items: []batchv1.Job{*obj.(*batchv1.Job)}}, 0)
+			if err != nil {
+				return nil, err
+			}
+			return o.self, nil
+		},
+	},
+}
+
+// controllerOptions are parameters of `controller` verb, other keys of the hash (`labels` and `fields`)
+// select objects the same way they do for `pods` and other resource verbs
+type controllerOptions struct {
+	resync         time.Duration
+	retries        int
+	leaderElection string // name of the lock, empty if leader election is disabled
+}
+
+var controllerOptionKeys = []string{"resync", "retries", "leader_election"}
+
+func isControllerOption(key string) bool {
+	for _, k := range controllerOptionKeys {
+		if k == key {
+			return true
+		}
+	}
+	return false
+}
+
+// parseControllerOptions takes controller options out of the hash, and returns a hash with the rest (if any)
+func parseControllerOptions(m *mruby.Mrb, kind string, hash *mruby.MrbValue) (*controllerOptions, *mruby.MrbValue, error) {
+	o := &controllerOptions{resync: defaultResyncPeriod, retries: defaultMaxRetries}
+
+	if hash == nil {
+		return o, nil, nil
+	}
+
+	rest, err := m.LoadString("{}")
+	if err != nil {
+		return nil, nil, err
+	}
+	if err := protectValue(m, rest); err != nil {
+		return nil, nil, err
+	}
+	defer unprotectValue(m, rest)
+	hasRest := false
+
+	if err := iterateHash(hash, func(key, value *mruby.MrbValue) error {
+		k := key.String()
+		if !isControllerOption(k) {
+			hasRest = true
+			return rest.Hash().Set(key, value)
+		}
+
+		switch k {
+		case "resync":
+			n, err := numberValue(k, value)
+			if err != nil {
+				return err
+			}
+			if n < 0 {
+				return newArgumentError("resync must not be negative")
+			}
+			o.resync = time.Duration(n * float64(time.Second))
+		case "retries":
+			if value.Type() != mruby.TypeFixnum || value.Fixnum() < 0 {
+				return newArgumentError("%q must be a non-negative integer", k)
+			}
+			o.retries = value.Fixnum()
+		case "leader_election":
+			switch value.Type() {
+			case mruby.TypeTrue:
+				o.leaderElection = "kubeplay-controller-" + kind
+			case mruby.TypeFalse, mruby.TypeNil:
+				o.leaderElection = ""
+			case mruby.TypeString, mruby.TypeSymbol:
+				o.leaderElection = value.String()
+			default:
+				return newArgumentError("leader_election must be true or a name of the lock")
+			}
+		}
+		return nil
+	}); err != nil {
+		return nil, nil, err
+	}
+
+	if !hasRest {
+		return o, nil, nil
+	}
+	return o, rest, nil
+}
+
+// controllerEvent is what goes into the work queue, the queue doesn't hold objects, so that
+// multiple updates of the same object collapse into one, and the handler sees the latest version
+type controllerEvent struct {
+	action string // "added", "updated" or "deleted"
+	key    string
+}
+
+type controller struct {
+	rk      *RubyKube
+	kind    controllerKind
+	options *controllerOptions
+	handler *mruby.MrbValue
+
+	queries []resourceQuery
+	fields  []fieldExpression
+
+	informer cache.SharedIndexInformer
+	queue    workqueue.RateLimitingInterface
+
+	// deleted objects are not in the informer's store anymore, so last known state is kept here
+	deletedLock sync.Mutex
+	deleted     map[string]runtime.Object
+}
+
+// informerNamespace returns the namespace to watch, that's all namespaces unless every query
+// refers to the same one
+func (c *controller) informerNamespace() string {
+	if len(c.queries) == 0 {
+		return c.rk.GetNamespace("")
+	}
+	ns := c.rk.GetNamespace(c.queries[0].namespace)
+	for _, q := range c.queries[1:] {
+		if c.rk.GetNamespace(q.namespace) != ns {
+			return ""
+		}
+	}
+	return ns
+}
+
+// matches evaluates globs and field selectors on the client, as field selectors may expand into
+// multiple queries, while an informer can only do one
+func (c *controller) matches(obj interface{}) bool {
+	accessor, err := meta.Accessor(obj)
+	if err != nil {
+		return false
+	}
+	if len(c.queries) > 0 {
+		found := false
+		for _, q := range c.queries {
+			if q.match(accessor.GetNamespace(), accessor.GetName()) {
+				found = true
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+	ok, err := matchFieldExpressions(obj, c.fields)
+	return err == nil && ok
+}
+
+func (c *controller) enqueue(action string, obj interface{}) {
+	if tombstone, ok := obj.(cache.DeletedFinalStateUnknown); ok {
+		obj = tombstone.Obj
+	}
+	if !c.matches(obj) {
+		return
+	}
+	key, err := cache.DeletionHandlingMetaNamespaceKeyFunc(obj)
+	if err != nil {
+		return
+	}
+	if action == "deleted" {
+		if o, ok := obj.(runtime.Object); ok {
+			c.deletedLock.Lock()
+			c.deleted[key] = o
+			c.deletedLock.Unlock()
+		}
+	}
+	c.queue.Add(controllerEvent{action: action, key: key})
+}
+
+func (c *controller) setupInformer(labelSelector string) {
+	ns := c.informerNamespace()
+	withSelector := func(listOptions metav1.ListOptions) metav1.ListOptions {
+		listOptions.LabelSelector = labelSelector
+		return listOptions
+	}
+
+	c.informer = cache.NewSharedIndexInformer(
+		&cache.ListWatch{
+			ListFunc: func(listOptions metav1.ListOptions) (runtime.Object, error) {
+				return c.kind.list(c.rk, ns, withSelector(listOptions))
+			},
+			WatchFunc: func(listOptions metav1.ListOptions) (watch.Interface, error) {
+				return c.kind.watch(c.rk, ns, withSelector(listOptions))
+			},
+		},
+		c.kind.objType,
+		c.options.resync,
+		cache.Indexers{},
+	)
+
+	c.informer.AddEventHandler(cache.ResourceEventHandlerFuncs{
+		AddFunc: func(obj interface{}) { c.enqueue("added", obj) },
+		UpdateFunc: func(oldObj, newObj interface{}) {
+			// resyncs deliver updates with identical objects, these are passed on too, as
+			// handlers are meant to reconcile state of the object anyway
+			c.enqueue("updated", newObj)
+		},
+		DeleteFunc: func(obj interface{}) { c.enqueue("deleted", obj) },
+	})
+}
+
+// processNextEvent runs the handler for one event, it returns false once the queue has been shut down
+func (c *controller) processNextEvent() bool {
+	item, shutdown := c.queue.Get()
+	if shutdown {
+		return false
+	}
+	defer c.queue.Done(item)
+
+	event := item.(controllerEvent)
+
+	var obj runtime.Object
+	if event.action == "deleted" {
+		c.deletedLock.Lock()
+		obj = c.deleted[event.key]
+		delete(c.deleted, event.key)
+		c.deletedLock.Unlock()
+	} else {
+		o, exists, err := c.informer.GetStore().GetByKey(event.key)
+		if err != nil || !exists {
+			// it's been deleted since, and there is a "deleted" event in the queue
+			c.queue.Forget(item)
+			return true
+		}
+		obj = o.(runtime.Object)
+	}
+	if obj == nil {
+		c.queue.Forget(item)
+		return true
+	}
+
+	if err := c.handle(event.action, obj); err != nil {
+		if c.queue.NumRequeues(item) < c.options.retries {
+			fmt.Printf("+++ Error: handling %s event for %q – %s (will retry)\n", event.action, event.key, FormatError(err))
+			if event.action == "deleted" {
+				c.deletedLock.Lock()
+				c.deleted[event.key] = obj
+				c.deletedLock.Unlock()
+			}
+			c.queue.AddRateLimited(item)
+			return true
+		}
+		fmt.Printf("+++ Error: handling %s event for %q – %s (giving up after %d retries)\n", event.action, event.key, FormatError(err), c.options.retries)
+	}
+	c.queue.Forget(item)
+	return true
+}
+
+func (c *controller) handle(action string, obj runtime.Object) error {
+	m := c.rk.mrb
+
+	// informer's cache must not be modified, and Ruby methods like `refresh!` do that
+	obj = obj.DeepCopyObject()
+
+	rubyObj, err := c.kind.newObject(c.rk, obj)
+	if err != nil {
+		return err
+	}
+
+	event, err := m.StringValue(action).Call("to_sym")
+	if err != nil {
+		return err
+	}
+
+	_, err = c.handler.Call("call", event, rubyObj)
+	return err
+}
+
+// leaderElector is a minimal version of client-go's leader election, that one cannot be stopped once
+// started; it uses a ConfigMap lock, as client-go we build with predates `Lease` objects
+type leaderElector struct {
+	lock resourcelock.Interface
+
+	observedRecord resourcelock.LeaderElectionRecord
+	observedTime   time.Time
+}
+
+func (rk *RubyKube) newLeaderElector(name string) (*leaderElector, error) {
+	hostname, err := os.Hostname()
+	if err != nil {
+		return nil, err
+	}
+	lock, err := resourcelock.New(resourcelock.ConfigMapsResourceLock,
+		rk.GetDefaultNamespace(""), name,
+		rk.clientset.Core(),
+		resourcelock.ResourceLockConfig{Identity: hostname + "_" + rand.String(8)},
+	)
+	if err != nil {
+		return nil, err
+	}
+	return &leaderElector{lock: lock}, nil
+}
+
+func (le *leaderElector) tryAcquireOrRenew() (bool, error) {
+	now := metav1.Now()
+	record := resourcelock.LeaderElectionRecord{
+		HolderIdentity:       le.lock.Identity(),
+		LeaseDurationSeconds: int(leaseDuration / time.Second),
+		RenewTime:            now,
+		AcquireTime:          now,
+	}
+
+	oldRecord, err := le.lock.Get()
+	if err != nil {
+		if !apierrors.IsNotFound(err) {
+			return false, err
+		}
+		if err := le.lock.Create(record); err != nil {
+			return false, err
+		}
+		le.observedRecord, le.observedTime = record, now.Time
+		return true, nil
+	}
+
+	if !reflect.DeepEqual(le.observedRecord, *oldRecord) {
+		le.observedRecord, le.observedTime = *oldRecord, now.Time
+	}
+	isLeader := oldRecord.HolderIdentity == le.lock.Identity()
+	if oldRecord.HolderIdentity != "" && !isLeader && le.observedTime.Add(leaseDuration).After(now.Time) {
+		return false, nil
+	}
+
+	if isLeader {
+		record.AcquireTime = oldRecord.AcquireTime
+		record.LeaderTransitions = oldRecord.LeaderTransitions
+	} else {
+		record.LeaderTransitions = oldRecord.LeaderTransitions + 1
+	}
+
+	if err := le.lock.Update(record); err != nil {
+		return false, err
+	}
+	le.observedRecord, le.observedTime = record, now.Time
+	return true, nil
+}
+
+// release gives up the lock, so that another instance doesn't have to wait for it to expire
+func (le *leaderElector) release() error {
+	if le.observedRecord.HolderIdentity != le.lock.Identity() {
+		return nil
+	}
+	now := metav1.Now()
+	return le.lock.Update(resourcelock.LeaderElectionRecord{
+		LeaseDurationSeconds: 1,
+		RenewTime:            now,
+		AcquireTime:          now,
+		LeaderTransitions:    le.observedRecord.LeaderTransitions,
+	})
+}
+
+// run tries to acquire the lock and keeps renewing it, true is sent on leading once it's acquired,
+// and false if it cannot be renewed in time; the lock is released when stop is closed
+func (le *leaderElector) run(stop <-chan struct{}, leading chan<- bool) {
+	ticker := time.NewTicker(retryPeriod)
+	defer ticker.Stop()
+
+	isLeading := false
+	lastRenewed := time.Time{}
+	for {
+		ok, err := le.tryAcquireOrRenew()
+		switch {
+		case ok && !isLeading:
+			isLeading, lastRenewed = true, time.Now()
+			leading <- true
+		case ok:
+			lastRenewed = time.Now()
+		case isLeading && time.Since(lastRenewed) > renewDeadline:
+			if err != nil {
+				fmt.Printf("+++ Error: could not renew leader lock %s – %v\n", le.lock.Describe(), err)
+			}
+			leading <- false
+			return
+		}
+
+		select {
+		case <-stop:
+			le.release()
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// controllerVerb implements `controller(:pods, "web-*", labels: "app=web") { |event, obj| ... }`, it
+// runs until interrupted with ^C; handlers run one at a time on the same goroutine as the REPL,
+// as mruby is not safe to use from multiple goroutines
+func controllerVerb(rk *RubyKube, args []*mruby.MrbValue, m *mruby.Mrb, self *mruby.MrbValue) (mruby.Value, mruby.Value) {
+	usage := "Usage: controller(:pods, \"<glob>\", labels: \"...\", resync: 300, retries: 5, leader_election: true) { |event, obj| ... }"
+
+	block := blockArg(args)
+	if len(args) == 0 || block == nil {
+		return nil, createException(m, usage)
+	}
+
+	kindName := args[0].String()
+	kind, ok := controllerKinds[kindName]
+	if !ok {
+		return nil, createException(m, fmt.Sprintf("Unknown kind %q – %s", kindName, usage))
+	}
+
+	var hash *mruby.MrbValue
+	queryArgs := []*mruby.MrbValue{}
+	for _, arg := range args[1:] {
+		switch arg.Type() {
+		case mruby.TypeProc:
+		case mruby.TypeHash:
+			hash = arg
+		default:
+			queryArgs = append(queryArgs, arg)
+		}
+	}
+
+	options, rest, err := parseControllerOptions(m, kindName, hash)
+	if err != nil {
+		return nil, createError(m, err)
+	}
+	if rest != nil {
+		// the hash is only referenced from Go, and it's used until the verb returns
+		if err := protectValue(m, rest); err != nil {
+			return nil, createError(m, err)
+		}
+		defer unprotectValue(m, rest)
+		queryArgs = append(queryArgs, rest)
+	}
+
+	queries, selectors, err := rk.resourceArgs(queryArgs)
+	if err != nil {
+		return nil, createError(m, err)
+	}
+
+	c := &controller{
+		rk:      rk,
+		kind:    kind,
+		options: options,
+		handler: block,
+		queries: queries,
+		fields:  selectors.fields,
+		queue:   workqueue.NewRateLimitingQueue(workqueue.DefaultControllerRateLimiter()),
+		deleted: map[string]runtime.Object{},
+	}
+	c.setupInformer(selectors.labelSelector)
+
+	interrupt := make(chan os.Signal, 1)
+	signal.Notify(interrupt, os.Interrupt)
+	defer signal.Stop(interrupt)
+
+	// stop is closed on ^C too, so that waiting for the cache to sync returns
+	stop := make(chan struct{})
+	var stopOnce sync.Once
+	closeStop := func() { stopOnce.Do(func() { close(stop) }) }
+	defer closeStop()
+
+	// leading receives at most two values, so it's buffered to not block once we stop reading from it
+	leading := make(chan bool, 2)
+	if options.leaderElection != "" {
+		// the lock is a config map, that's written to while the controller runs
+		if err := rk.allowChange(fmt.Sprintf("hold leader lock %q", options.leaderElection)); err != nil {
+			return nil, createError(m, err)
+		}
+		le, err := rk.newLeaderElector(options.leaderElection)
+		if err != nil {
+			return nil, createError(m, err)
+		}
+		go le.run(stop, leading)
+
+		fmt.Printf("waiting to acquire leader lock %s – press ^C to stop\n", le.lock.Describe())
+		select {
+		case <-interrupt:
+			return nil, nil
+		case <-leading:
+		}
+	}
+
+	go c.informer.Run(stop)
+
+	// the queue is shut down when it's time to stop, so that `Get` returns
+	go func() {
+		select {
+		case <-interrupt:
+		case <-leading:
+			fmt.Println("+++ Error: lost leader lock, stopping")
+		case <-stop:
+		}
+		closeStop()
+		c.queue.ShutDown()
+	}()
+
+	// it only returns false once stop is closed
+	if !cache.WaitForCacheSync(stop, c.informer.HasSynced) {
+		return nil, nil
+	}
+
+	fmt.Printf("controller for %s started (resync=%s) – press ^C to stop\n", kindName, options.resync)
+	for c.processNextEvent() {
+	}
+	fmt.Printf("controller for %s stopped\n", kindName)
+
+	return nil, nil
+}
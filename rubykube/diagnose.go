@@ -0,0 +1,494 @@
+package rubykube
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"strings"
+
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+)
+
+const (
+	// diagnoseLogLines is how many lines from the end of previous container log a diagnosis includes
+	diagnoseLogLines = 20
+	// diagnoseEventCount is how many of the latest warning events are included for each object
+	diagnoseEventCount = 10
+)
+
+type containerDiagnosis struct {
+	Name         string   `json:"name"`
+	Image        string   `json:"image"`
+	Init         bool     `json:"init,omitempty"`
+	State        string   `json:"state"`
+	Reason       string   `json:"reason,omitempty"`
+	Message      string   `json:"message,omitempty"`
+	ExitCode     *int32   `json:"exitCode,omitempty"`
+	Ready        bool     `json:"ready"`
+	Restarts     int32    `json:"restarts"`
+	LastReason   string   `json:"lastReason,omitempty"`
+	LastExitCode *int32   `json:"lastExitCode,omitempty"`
+	MemoryLimit  string   `json:"memoryLimit,omitempty"`
+	PreviousLog  []string `json:"previousLog,omitempty"`
+}
+
+type podDiagnosis struct {
+	Name       string               `json:"name"`
+	Phase      string               `json:"phase"`
+	Reason     string               `json:"reason,omitempty"`
+	Message    string               `json:"message,omitempty"`
+	Node       string               `json:"node,omitempty"`
+	Containers []containerDiagnosis `json:"containers"`
+	Events     []string             `json:"events,omitempty"`
+	Causes     []string             `json:"causes,omitempty"`
+}
+
+// diagnosis is what `diagnose` methods return, workload status and causes are only set for workloads,
+// and only pods that aren't healthy are diagnosed
+type diagnosis struct {
+	Subject     string          `json:"subject"`
+	Status      []string        `json:"status,omitempty"`
+	Events      []string        `json:"events,omitempty"`
+	Causes      []string        `json:"causes,omitempty"`
+	HealthyPods int             `json:"healthyPods"`
+	Pods        []*podDiagnosis `json:"pods"`
+}
+
+// exitCodeMeaning explains common exit codes, codes above 128 mean that the process was killed by a signal
+func exitCodeMeaning(code int32) string {
+	switch code {
+	case 0:
+		return "success"
+	case 126:
+		return "command cannot be executed"
+	case 127:
+		return "command not found"
+	case 137:
+		return "killed with SIGKILL, e.g. by OOM killer or after a failed liveness probe"
+	case 139:
+		return "segmentation fault"
+	case 143:
+		return "terminated with SIGTERM"
+	}
+	if code > 128 {
+		return fmt.Sprintf("killed by signal %d", code-128)
+	}
+	return "application error"
+}
+
+// schedulingHint suggests what to do about node-fit reasons reported by the scheduler
+func schedulingHint(message string) string {
+	switch {
+	case strings.Contains(message, "Insufficient"):
+		return "requests of the pod don't fit on any node, lower the requests or add nodes"
+	case strings.Contains(message, "node selector"), strings.Contains(message, "affinity"):
+		return "no node matches nodeSelector or affinity rules of the pod"
+	case strings.Contains(message, "taint"):
+		return "nodes have taints that the pod doesn't tolerate"
+	case strings.Contains(message, "PersistentVolumeClaim"), strings.Contains(message, "volume"):
+		return "a persistent volume claim of the pod is not bound, or its volume is in another zone"
+	}
+	return ""
+}
+
+// podHealthy is true for pods that are running with all containers ready and no restarts, or have succeeded
+func podHealthy(pod *corev1.Pod) bool {
+	switch pod.Status.Phase {
+	case corev1.PodSucceeded:
+		return true
+	case corev1.PodRunning:
+		for _, status := range pod.Status.ContainerStatuses {
+			if !status.Ready || status.RestartCount > 0 {
+				return false
+			}
+		}
+		return true
+	}
+	return false
+}
+
+func eventLines(events []corev1.Event) []string {
+	if len(events) > diagnoseEventCount {
+		events = events[len(events)-diagnoseEventCount:]
+	}
+	lines := []string{}
+	for i := range events {
+		lines = append(lines, eventLine(&events[i]))
+	}
+	return lines
+}
+
+// warningEventsOf returns time-sorted warning events of the objects
+func (rk *RubyKube) warningEventsOf(ns string, uids ...types.UID) ([]corev1.Event, error) {
+	warnings := []corev1.Event{}
+	for _, uid := range uids {
+		events, err := rk.eventsOf(ns, uid)
+		if err != nil {
+			return nil, err
+		}
+		for _, event := range events {
+			if event.Type == corev1.EventTypeWarning {
+				warnings = append(warnings, event)
+			}
+		}
+	}
+	sortEvents(warnings)
+	return warnings, nil
+}
+
+func (rk *RubyKube) diagnoseContainer(pod *corev1.Pod, spec *corev1.Container, status corev1.ContainerStatus, init bool) containerDiagnosis {
+	d := containerDiagnosis{
+		Name:     status.Name,
+		Image:    status.Image,
+		Init:     init,
+		Ready:    status.Ready,
+		Restarts: status.RestartCount,
+	}
+
+	switch state := status.State; {
+	case state.Waiting != nil:
+		d.State, d.Reason, d.Message = "waiting", state.Waiting.Reason, state.Waiting.Message
+	case state.Running != nil:
+		d.State = "running"
+	case state.Terminated != nil:
+		d.State, d.Reason, d.Message = "terminated", state.Terminated.Reason, state.Terminated.Message
+		exitCode := state.Terminated.ExitCode
+		d.ExitCode = &exitCode
+	}
+
+	if spec != nil {
+		if limit, ok := spec.Resources.Limits[corev1.ResourceMemory]; ok {
+			d.MemoryLimit = limit.String()
+		}
+	}
+
+	last := status.LastTerminationState.Terminated
+	if last == nil {
+		return d
+	}
+	d.LastReason = last.Reason
+	exitCode := last.ExitCode
+	d.LastExitCode = &exitCode
+
+	lines := int64(diagnoseLogLines)
+	logs, err := rk.fetchLogs(pod, &corev1.PodLogOptions{Container: status.Name, Previous: true, TailLines: &lines})
+	if err != nil {
+		d.PreviousLog = []string{fmt.Sprintf("(not available – %v)", err)}
+		return d
+	}
+	scanner := bufio.NewScanner(logs)
+	for scanner.Scan() {
+		d.PreviousLog = append(d.PreviousLog, scanner.Text())
+	}
+	return d
+}
+
+// podCauses suggests likely causes based on container states and warning events
+func podCauses(pod *corev1.Pod, d *podDiagnosis, warnings []corev1.Event) []string {
+	causes := []string{}
+	add := func(format string, a ...interface{}) {
+		causes = append(causes, fmt.Sprintf(format, a...))
+	}
+
+	if pod.Status.Reason == "Evicted" {
+		add("pod was evicted by the kubelet – %s", pod.Status.Message)
+	}
+
+	for _, c := range d.Containers {
+		oomKilled := c.LastReason == "OOMKilled" || c.Reason == "OOMKilled"
+
+		switch c.Reason {
+		case "CrashLoopBackOff":
+			if c.LastExitCode != nil && !oomKilled {
+				add("container %q is crash-looping, it exited with code %d (%s) and has restarted %d times, see its previous log",
+					c.Name, *c.LastExitCode, exitCodeMeaning(*c.LastExitCode), c.Restarts)
+			} else if !oomKilled {
+				add("container %q is crash-looping and has restarted %d times", c.Name, c.Restarts)
+			}
+		case "ErrImagePull", "ImagePullBackOff":
+			add("image %q of container %q cannot be pulled, check that the image exists and that the registry doesn't need imagePullSecrets – %s",
+				c.Image, c.Name, c.Message)
+		case "InvalidImageName":
+			add("image name %q of container %q is invalid", c.Image, c.Name)
+		case "CreateContainerConfigError":
+			add("container %q cannot be configured, a ConfigMap, Secret or a key it refers to is probably missing – %s", c.Name, c.Message)
+		case "CreateContainerError", "RunContainerError":
+			add("container %q cannot be started – %s", c.Name, c.Message)
+		}
+
+		switch {
+		case oomKilled && c.MemoryLimit != "":
+			add("container %q was killed for using more than its memory limit of %s, raise the limit or look for a leak", c.Name, c.MemoryLimit)
+		case oomKilled:
+			add("container %q was killed for running out of memory without a limit of its own, so the node was short of memory, set memory requests and limits", c.Name)
+		case c.State == "terminated" && c.ExitCode != nil && *c.ExitCode != 0 && pod.Spec.RestartPolicy == corev1.RestartPolicyNever:
+			add("container %q exited with code %d (%s)", c.Name, *c.ExitCode, exitCodeMeaning(*c.ExitCode))
+		}
+	}
+
+	// only the latest event of each reason is considered, as older ones may no longer apply
+	latest := map[string]corev1.Event{}
+	reasons := []string{}
+	for _, event := range warnings {
+		if _, ok := latest[event.Reason]; !ok {
+			reasons = append(reasons, event.Reason)
+		}
+		latest[event.Reason] = event
+	}
+
+	for _, reason := range reasons {
+		message := strings.TrimSpace(latest[reason].Message)
+		switch reason {
+		case "FailedScheduling":
+			if pod.Spec.NodeName != "" {
+				continue
+			}
+			if hint := schedulingHint(message); hint != "" {
+				add("pod cannot be scheduled, %s – %s", hint, message)
+			} else {
+				add("pod cannot be scheduled – %s", message)
+			}
+		case "Unhealthy":
+			switch {
+			case strings.HasPrefix(message, "Liveness"):
+				add("liveness probe is failing, so the container gets restarted – %s", message)
+			case strings.HasPrefix(message, "Readiness"):
+				add("readiness probe is failing, so the pod is not ready and gets no traffic – %s", message)
+			default:
+				add("probe is failing – %s", message)
+			}
+		case "FailedMount", "FailedAttachVolume":
+			add("volume cannot be mounted – %s", message)
+		case "FailedCreatePodSandBox":
+			add("pod sandbox cannot be created, which is usually a problem with the network plugin on the node – %s", message)
+		}
+	}
+
+	if len(causes) == 0 && pod.Status.Phase == corev1.PodPending && pod.Spec.NodeName == "" {
+		add("pod has not been scheduled yet")
+	}
+	return causes
+}
+
+func (rk *RubyKube) diagnosePod(pod *corev1.Pod) (*podDiagnosis, error) {
+	d := &podDiagnosis{
+		Name:       objectName(pod.ObjectMeta),
+		Phase:      string(pod.Status.Phase),
+		Reason:     pod.Status.Reason,
+		Message:    pod.Status.Message,
+		Node:       pod.Spec.NodeName,
+		Containers: []containerDiagnosis{},
+	}
+
+	warnings, err := rk.warningEventsOf(pod.ObjectMeta.Namespace, pod.ObjectMeta.UID)
+	if err != nil {
+		return nil, err
+	}
+	d.Events = eventLines(warnings)
+
+	specs := map[string]*corev1.Container{}
+	for i := range pod.Spec.InitContainers {
+		specs[pod.Spec.InitContainers[i].Name] = &pod.Spec.InitContainers[i]
+	}
+	for i := range pod.Spec.Containers {
+		specs[pod.Spec.Containers[i].Name] = &pod.Spec.Containers[i]
+	}
+
+	for _, status := range pod.Status.InitContainerStatuses {
+		d.Containers = append(d.Containers, rk.diagnoseContainer(pod, specs[status.Name], status, true))
+	}
+	for _, status := range pod.Status.ContainerStatuses {
+		d.Containers = append(d.Containers, rk.diagnoseContainer(pod, specs[status.Name], status, false))
+	}
+
+	d.Causes = podCauses(pod, d, warnings)
+	return d, nil
+}
+
+// diagnosePods diagnoses pods that aren't healthy concurrently, and returns how many are healthy
+func (rk *RubyKube) diagnosePods(pods []corev1.Pod) ([]*podDiagnosis, int, error) {
+	unhealthy := []*corev1.Pod{}
+	for i := range pods {
+		if !podHealthy(&pods[i]) {
+			unhealthy = append(unhealthy, &pods[i])
+		}
+	}
+
+	diagnoses := make([]*podDiagnosis, len(unhealthy))
+	if err := rk.parallel(len(unhealthy), func(i int) error {
+		d, err := rk.diagnosePod(unhealthy[i])
+		diagnoses[i] = d
+		return err
+	}); err != nil {
+		return nil, 0, err
+	}
+	return diagnoses, len(pods) - len(unhealthy), nil
+}
+
+func (rk *RubyKube) diagnoseDeployment(deployment *appsv1.Deployment, pods []corev1.Pod) (*diagnosis, error) {
+	d := &diagnosis{Subject: "deployment " + objectName(deployment.ObjectMeta)}
+	add := func(format string, a ...interface{}) {
+		d.Causes = append(d.Causes, fmt.Sprintf(format, a...))
+	}
+
+	replicas := int32(1)
+	if deployment.Spec.Replicas != nil {
+		replicas = *deployment.Spec.Replicas
+	}
+	status := deployment.Status
+	d.Status = append(d.Status, fmt.Sprintf("replicas: %d desired, %d updated, %d ready, %d available, %d unavailable",
+		replicas, status.UpdatedReplicas, status.ReadyReplicas, status.AvailableReplicas, status.UnavailableReplicas))
+
+	if deployment.Spec.Paused {
+		add("deployment is paused, so changes of its template are not rolled out")
+	}
+	if replicas == 0 {
+		add("deployment is scaled to 0 replicas")
+	}
+
+	for _, condition := range status.Conditions {
+		line := fmt.Sprintf("condition %s=%s", condition.Type, condition.Status)
+		if condition.Reason != "" {
+			line += fmt.Sprintf(" (%s)", condition.Reason)
+		}
+		if condition.Message != "" {
+			line += ": " + condition.Message
+		}
+		d.Status = append(d.Status, line)
+
+		switch {
+		case condition.Type == appsv1.DeploymentProgressing && condition.Reason == "ProgressDeadlineExceeded":
+			add("rollout has not made progress within progressDeadlineSeconds – %s", condition.Message)
+		case condition.Type == appsv1.DeploymentReplicaFailure && condition.Status == corev1.ConditionTrue:
+			add("pods cannot be created, e.g. because of a quota or admission policy – %s", condition.Message)
+		}
+	}
+
+	// replica sets report when they fail to create pods, the deployment doesn't
+	uids := []types.UID{deployment.ObjectMeta.UID}
+	replicaSets, err := rk.classes.ReplicaSets.getList(deployment.ObjectMeta.Namespace,
+		metav1.ListOptions{LabelSelector: metav1.FormatLabelSelector(deployment.Spec.Selector)})
+	if err != nil {
+		return nil, err
+	}
+	for _, replicaSet := range replicaSets.Items {
+		if ref := metav1.GetControllerOf(&replicaSet); ref != nil && ref.UID == deployment.ObjectMeta.UID {
+			uids = append(uids, replicaSet.ObjectMeta.UID)
+		}
+	}
+
+	warnings, err := rk.warningEventsOf(deployment.ObjectMeta.Namespace, uids...)
+	if err != nil {
+		return nil, err
+	}
+	d.Events = eventLines(warnings)
+
+	failedCreate := ""
+	for _, event := range warnings {
+		if event.Reason == "FailedCreate" {
+			failedCreate = strings.TrimSpace(event.Message)
+		}
+	}
+	if failedCreate != "" {
+		add("replica set cannot create pods – %s", failedCreate)
+	}
+
+	if d.Pods, d.HealthyPods, err = rk.diagnosePods(pods); err != nil {
+		return nil, err
+	}
+	return d, nil
+}
+
+// causes returns causes of the workload along with causes of each of its pods
+func (d *diagnosis) causes() []string {
+	causes := append([]string{}, d.Causes...)
+	for _, pod := range d.Pods {
+		for _, cause := range pod.Causes {
+			causes = append(causes, fmt.Sprintf("pod %s: %s", pod.Name, cause))
+		}
+	}
+	return causes
+}
+
+func writeSection(text *bytes.Buffer, indent, title string, lines []string) {
+	if len(lines) == 0 {
+		return
+	}
+	fmt.Fprintf(text, "%s%s:\n", indent, title)
+	for _, line := range lines {
+		fmt.Fprintf(text, "%s  %s\n", indent, line)
+	}
+}
+
+func (c *containerDiagnosis) write(text *bytes.Buffer) {
+	kind := "container"
+	if c.Init {
+		kind = "init container"
+	}
+	state := c.State
+	if c.Reason != "" {
+		state += fmt.Sprintf(" (%s)", c.Reason)
+	}
+	ready := "ready"
+	if !c.Ready {
+		ready = "not ready"
+	}
+	fmt.Fprintf(text, "  %s %s (%s): %s, %s, %d restarts\n", kind, c.Name, c.Image, state, ready, c.Restarts)
+
+	if c.Message != "" {
+		fmt.Fprintf(text, "    %s\n", strings.TrimSpace(c.Message))
+	}
+	if c.LastExitCode != nil {
+		fmt.Fprintf(text, "    last terminated: %s, exit code %d (%s)\n", c.LastReason, *c.LastExitCode, exitCodeMeaning(*c.LastExitCode))
+	}
+	if len(c.PreviousLog) > 0 {
+		fmt.Fprintf(text, "    previous log:\n")
+		for _, line := range c.PreviousLog {
+			fmt.Fprintf(text, "      | %s\n", line)
+		}
+	}
+}
+
+func (d *podDiagnosis) write(text *bytes.Buffer) {
+	fmt.Fprintf(text, "pod %s: %s", d.Name, d.Phase)
+	if d.Node != "" {
+		fmt.Fprintf(text, " on %s", d.Node)
+	}
+	text.WriteString("\n")
+
+	if d.Reason != "" || d.Message != "" {
+		fmt.Fprintf(text, "  %s\n", strings.TrimSpace(d.Reason+" "+d.Message))
+	}
+	for i := range d.Containers {
+		d.Containers[i].write(text)
+	}
+	writeSection(text, "  ", "events", d.Events)
+	writeSection(text, "  ", "likely causes", d.Causes)
+}
+
+func (d *diagnosis) String() string {
+	text := bytes.Buffer{}
+	fmt.Fprintf(&text, "Diagnosis of %s\n", d.Subject)
+
+	for _, line := range d.Status {
+		fmt.Fprintf(&text, "  %s\n", line)
+	}
+	writeSection(&text, "  ", "events", d.Events)
+	writeSection(&text, "  ", "likely causes", d.Causes)
+	if d.HealthyPods > 0 {
+		fmt.Fprintf(&text, "  %d of %d pods are healthy\n", d.HealthyPods, d.HealthyPods+len(d.Pods))
+	}
+
+	for _, pod := range d.Pods {
+		text.WriteString("\n")
+		pod.write(&text)
+	}
+
+	if len(d.causes()) == 0 {
+		text.WriteString("\nNo likely causes found\n")
+	}
+	return text.String()
+}
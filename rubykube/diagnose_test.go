@@ -0,0 +1,138 @@
+package rubykube
+
+import (
+	"reflect"
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+)
+
+func TestExitCodeMeaning(t *testing.T) {
+	tests := []struct {
+		code    int32
+		meaning string
+	}{
+		{0, "success"},
+		{1, "application error"},
+		{126, "command cannot be executed"},
+		{127, "command not found"},
+		{128, "application error"},
+		{130, "killed by signal 2"},
+		{137, "killed with SIGKILL, e.g. by OOM killer or after a failed liveness probe"},
+		{139, "segmentation fault"},
+		{143, "terminated with SIGTERM"},
+	}
+
+	for _, test := range tests {
+		if meaning := exitCodeMeaning(test.code); meaning != test.meaning {
+			t.Errorf("expected meaning of %d to be %q, got %q", test.code, test.meaning, meaning)
+		}
+	}
+}
+
+func TestSchedulingHint(t *testing.T) {
+	tests := []struct {
+		message, hint string
+	}{
+		{"0/3 nodes are available: 3 Insufficient memory.", "requests of the pod don't fit on any node, lower the requests or add nodes"},
+		{"0/3 nodes are available: 3 node(s) didn't match node selector.", "no node matches nodeSelector or affinity rules of the pod"},
+		{"0/3 nodes are available: 3 node(s) didn't match pod affinity rules.", "no node matches nodeSelector or affinity rules of the pod"},
+		{"0/3 nodes are available: 3 node(s) had taints that the pod didn't tolerate.", "nodes have taints that the pod doesn't tolerate"},
+		{"pod has unbound immediate PersistentVolumeClaims", "a persistent volume claim of the pod is not bound, or its volume is in another zone"},
+		{"no nodes available to schedule pods", ""},
+	}
+
+	for _, test := range tests {
+		if hint := schedulingHint(test.message); hint != test.hint {
+			t.Errorf("expected hint for %q to be %q, got %q", test.message, test.hint, hint)
+		}
+	}
+}
+
+func TestPodCauses(t *testing.T) {
+	exitCode := func(code int32) *int32 { return &code }
+	warning := func(reason, message string) corev1.Event {
+		return corev1.Event{Type: corev1.EventTypeWarning, Reason: reason, Message: message}
+	}
+
+	tests := []struct {
+		name       string
+		phase      corev1.PodPhase
+		node       string
+		restart    corev1.RestartPolicy
+		containers []containerDiagnosis
+		warnings   []corev1.Event
+		causes     []string
+	}{
+		{
+			name: "OOMKilled with a memory limit", phase: corev1.PodRunning, node: "node-1",
+			containers: []containerDiagnosis{{Name: "web", State: "running", Restarts: 3, LastReason: "OOMKilled", LastExitCode: exitCode(137), MemoryLimit: "128Mi"}},
+			causes:     []string{`container "web" was killed for using more than its memory limit of 128Mi, raise the limit or look for a leak`},
+		},
+		{
+			name: "OOMKilled without a memory limit", phase: corev1.PodRunning, node: "node-1",
+			containers: []containerDiagnosis{{Name: "web", State: "running", Restarts: 1, LastReason: "OOMKilled", LastExitCode: exitCode(137)}},
+			causes:     []string{`container "web" was killed for running out of memory without a limit of its own, so the node was short of memory, set memory requests and limits`},
+		},
+		{
+			name: "OOMKilled while crash-looping", phase: corev1.PodRunning, node: "node-1",
+			containers: []containerDiagnosis{{Name: "web", State: "waiting", Reason: "CrashLoopBackOff", Restarts: 5, LastReason: "OOMKilled", LastExitCode: exitCode(137), MemoryLimit: "64Mi"}},
+			causes:     []string{`container "web" was killed for using more than its memory limit of 64Mi, raise the limit or look for a leak`},
+		},
+		{
+			name: "CrashLoopBackOff with an exit code", phase: corev1.PodRunning, node: "node-1",
+			containers: []containerDiagnosis{{Name: "web", State: "waiting", Reason: "CrashLoopBackOff", Restarts: 4, LastReason: "Error", LastExitCode: exitCode(127)}},
+			causes:     []string{`container "web" is crash-looping, it exited with code 127 (command not found) and has restarted 4 times, see its previous log`},
+		},
+		{
+			name: "CrashLoopBackOff without an exit code", phase: corev1.PodRunning, node: "node-1",
+			containers: []containerDiagnosis{{Name: "web", State: "waiting", Reason: "CrashLoopBackOff", Restarts: 2}},
+			causes:     []string{`container "web" is crash-looping and has restarted 2 times`},
+		},
+		{
+			name: "ImagePullBackOff", phase: corev1.PodPending, node: "node-1",
+			containers: []containerDiagnosis{{Name: "web", Image: "nginx:nope", State: "waiting", Reason: "ImagePullBackOff", Message: `Back-off pulling image "nginx:nope"`}},
+			causes:     []string{`image "nginx:nope" of container "web" cannot be pulled, check that the image exists and that the registry doesn't need imagePullSecrets – Back-off pulling image "nginx:nope"`},
+		},
+		{
+			name: "exited with restartPolicy Never", phase: corev1.PodFailed, node: "node-1", restart: corev1.RestartPolicyNever,
+			containers: []containerDiagnosis{{Name: "job", State: "terminated", Reason: "Error", ExitCode: exitCode(1)}},
+			causes:     []string{`container "job" exited with code 1 (application error)`},
+		},
+		{
+			name: "FailedScheduling", phase: corev1.PodPending,
+			warnings: []corev1.Event{warning("FailedScheduling", "0/3 nodes are available: 3 Insufficient cpu.")},
+			causes:   []string{"pod cannot be scheduled, requests of the pod don't fit on any node, lower the requests or add nodes – 0/3 nodes are available: 3 Insufficient cpu."},
+		},
+		{
+			name: "FailedScheduling on a scheduled pod", phase: corev1.PodRunning, node: "node-1",
+			containers: []containerDiagnosis{{Name: "web", State: "running", Ready: true}},
+			warnings:   []corev1.Event{warning("FailedScheduling", "0/3 nodes are available: 3 Insufficient cpu.")},
+			causes:     []string{},
+		},
+		{
+			name: "only the latest event of a reason", phase: corev1.PodRunning, node: "node-1",
+			containers: []containerDiagnosis{{Name: "web", State: "running"}},
+			warnings: []corev1.Event{
+				warning("Unhealthy", "Liveness probe failed: connection refused"),
+				warning("Unhealthy", "Readiness probe failed: HTTP probe failed with statuscode: 503"),
+			},
+			causes: []string{"readiness probe is failing, so the pod is not ready and gets no traffic – Readiness probe failed: HTTP probe failed with statuscode: 503"},
+		},
+		{
+			name: "not scheduled yet", phase: corev1.PodPending,
+			causes: []string{"pod has not been scheduled yet"},
+		},
+	}
+
+	for _, test := range tests {
+		pod := &corev1.Pod{
+			Spec:   corev1.PodSpec{NodeName: test.node, RestartPolicy: test.restart},
+			Status: corev1.PodStatus{Phase: test.phase},
+		}
+		d := &podDiagnosis{Containers: test.containers}
+		if causes := podCauses(pod, d, test.warnings); !reflect.DeepEqual(causes, test.causes) {
+			t.Errorf("%s: expected causes %q, got %q", test.name, test.causes, causes)
+		}
+	}
+}
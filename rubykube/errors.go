@@ -0,0 +1,199 @@
+package rubykube
+
+import (
+	"fmt"
+	"strings"
+
+	mruby "github.com/mitchellh/go-mruby"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/wait"
+)
+
+// errorClasses defines `RubyKube::Error` hierarchy, so that scripts can rescue specific API errors,
+// e.g. `rescue RubyKube::NotFound`; `code`, `reason`, `details` and `resource` are only set for errors
+// that came from API server
+const errorClasses = `
+class RubyKube
+  class Error < StandardError
+    attr_reader :code, :reason, :details, :resource
+
+    def initialize(message = nil, code = nil, reason = nil, details = nil, resource = nil)
+      super(message)
+      @code = code
+      @reason = reason
+      @details = details || { "causes" => [] }
+      @details["causes"] ||= []
+      @resource = resource
+    end
+  end
+
+  class NotFound < Error; end
+  class AlreadyExists < Error; end
+  class Conflict < Error; end
+  class Forbidden < Error; end
+  class Invalid < Error; end
+  class Timeout < Error; end
+  class ArgumentError < Error; end
+  class Interrupted < Error; end
+  class Refused < Error; end
+end
+`
+
+// argumentError is returned by argument checks, it maps to `RubyKube::ArgumentError`
+type argumentError struct{ error }
+
+func newArgumentError(format string, a ...interface{}) error {
+	return &argumentError{fmt.Errorf(format, a...)}
+}
+
+// errorClassName picks a class from the hierarchy for given error
+func errorClassName(err error) string {
+	switch err.(type) {
+	case *argumentError:
+		return "ArgumentError"
+	case *timeoutError:
+		return "Timeout"
+	case *interruptedError:
+		return "Interrupted"
+	case *refusedError:
+		return "Refused"
+	}
+
+	switch {
+	case apierrors.IsNotFound(err):
+		return "NotFound"
+	case apierrors.IsAlreadyExists(err):
+		return "AlreadyExists"
+	case apierrors.IsConflict(err):
+		return "Conflict"
+	case apierrors.IsForbidden(err), apierrors.IsUnauthorized(err):
+		return "Forbidden"
+	case apierrors.IsInvalid(err), apierrors.IsBadRequest(err):
+		return "Invalid"
+	case apierrors.IsTimeout(err), apierrors.IsServerTimeout(err), err == wait.ErrWaitTimeout:
+		return "Timeout"
+	default:
+		return "Error"
+	}
+}
+
+// createError constructs an exception of the class that matches the error, with status details
+// attached if it's an API error; exceptions raised by Ruby code (e.g. in a file given to `load`)
+// are passed through as they are
+func createError(m *mruby.Mrb, err error) mruby.Value {
+	if exc, ok := err.(*mruby.Exception); ok && exc.MrbValue != nil {
+		return exc.MrbValue
+	}
+
+	class := m.Class(errorClassName(err), m.Class("RubyKube", nil))
+
+	args := []mruby.Value{mruby.String(err.Error())}
+
+	if apiErr, ok := err.(apierrors.APIStatus); ok {
+		status := apiErr.Status()
+
+		details := status.Details
+		if details == nil {
+			details = &metav1.StatusDetails{}
+		}
+
+		// details are converted via JSON, so that keys are the same as in API responses and `causes`
+		// is an array of hashes; if that fails, the exception is raised without details, as it's more
+		// useful than failing to raise it
+		var detailsValue mruby.Value = m.NilValue()
+		if v, err := nativeRubyValueOf(m, details); err == nil {
+			if err := protectValue(m, v); err == nil {
+				defer unprotectValue(m, v)
+				detailsValue = v
+			}
+		}
+
+		var resource mruby.Value = m.NilValue()
+		if details.Kind != "" {
+			resource = m.StringValue(strings.TrimSuffix(details.Kind+"/"+details.Name, "/"))
+		}
+
+		args = append(args, m.FixnumValue(int(status.Code)), m.StringValue(string(status.Reason)), detailsValue, resource)
+	}
+
+	val, newErr := class.New(args...)
+	if newErr != nil && len(args) > 1 {
+		val, newErr = class.New(args[0])
+	}
+	if newErr != nil {
+		panic(fmt.Sprintf("could not construct exception for return: %v", newErr))
+	}
+
+	return val
+}
+
+// FormatError returns a human-readable description of an error returned by `Run` or `RunCode`,
+// for `RubyKube::Error` it includes status code, reason, resource and causes of API errors
+func FormatError(err error) string {
+	exc, ok := err.(*mruby.Exception)
+	if !ok || exc.MrbValue == nil {
+		return err.Error()
+	}
+
+	isError, callErr := exc.Call("is_a?", exc.Mrb().Class("Error", exc.Mrb().Class("RubyKube", nil)))
+	if callErr != nil || isError.Type() != mruby.TypeTrue {
+		return err.Error()
+	}
+
+	value := func(method string) *mruby.MrbValue {
+		v, err := exc.Call(method)
+		if err != nil || v.Type() == mruby.TypeNil {
+			return nil
+		}
+		return v
+	}
+
+	className := ""
+	if v := value("class"); v != nil {
+		className = v.String()
+	}
+	message := ""
+	if v := value("message"); v != nil {
+		message = v.String()
+	}
+
+	if code := value("code"); code != nil {
+		className += fmt.Sprintf(" (%d", code.Fixnum())
+		if reason := value("reason"); reason != nil {
+			className += " " + reason.String()
+		}
+		className += ")"
+	}
+
+	lines := []string{fmt.Sprintf("%s: %s", className, message)}
+
+	if resource := value("resource"); resource != nil {
+		lines = append(lines, "  resource: "+resource.String())
+	}
+
+	if details := value("details"); details != nil {
+		if causes, err := details.Hash().Get(mruby.String("causes")); err == nil && causes.Type() == mruby.TypeArray {
+			iterateArray(causes, func(_ int, cause *mruby.MrbValue) error {
+				lines = append(lines, "  cause: "+formatCause(cause))
+				return nil
+			})
+		}
+	}
+
+	return strings.Join(lines, "\n")
+}
+
+func formatCause(cause *mruby.MrbValue) string {
+	if cause.Type() != mruby.TypeHash {
+		return cause.String()
+	}
+
+	parts := []string{}
+	for _, key := range []string{"field", "reason", "message"} {
+		if v, err := cause.Hash().Get(mruby.String(key)); err == nil && v.Type() != mruby.TypeNil {
+			parts = append(parts, v.String())
+		}
+	}
+	return strings.Join(parts, " – ")
+}
@@ -0,0 +1,44 @@
+package rubykube
+
+import (
+	"fmt"
+	"testing"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/util/validation/field"
+	"k8s.io/apimachinery/pkg/util/wait"
+)
+
+func TestErrorClassName(t *testing.T) {
+	pods := schema.GroupResource{Resource: "pods"}
+	deployment := schema.GroupKind{Group: "apps", Kind: "Deployment"}
+
+	tests := []struct {
+		err   error
+		class string
+	}{
+		{newArgumentError("invalid %s", "argument"), "ArgumentError"},
+		{&timeoutError{fmt.Errorf("timed out")}, "Timeout"},
+		{newInterruptedError(), "Interrupted"},
+		{newRefusedError("refused to delete %s", "prod"), "Refused"},
+		{apierrors.NewNotFound(pods, "web"), "NotFound"},
+		{apierrors.NewAlreadyExists(pods, "web"), "AlreadyExists"},
+		{apierrors.NewConflict(pods, "web", fmt.Errorf("modified")), "Conflict"},
+		{apierrors.NewForbidden(pods, "web", fmt.Errorf("denied")), "Forbidden"},
+		{apierrors.NewUnauthorized("no credentials"), "Forbidden"},
+		{apierrors.NewInvalid(deployment, "web", field.ErrorList{field.Required(field.NewPath("spec", "selector"), "")}), "Invalid"},
+		{apierrors.NewBadRequest("bad request"), "Invalid"},
+		{apierrors.NewTimeoutError("timed out", 1), "Timeout"},
+		{apierrors.NewServerTimeout(pods, "list", 1), "Timeout"},
+		{wait.ErrWaitTimeout, "Timeout"},
+		{apierrors.NewInternalError(fmt.Errorf("boom")), "Error"},
+		{fmt.Errorf("something else"), "Error"},
+	}
+
+	for _, test := range tests {
+		if class := errorClassName(test.err); class != test.class {
+			t.Errorf("expected %q for %T (%v), got %q", test.class, test.err, test.err, class)
+		}
+	}
+}
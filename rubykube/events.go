@@ -0,0 +1,379 @@
+package rubykube
+
+import (
+	"bytes"
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	mruby "github.com/mitchellh/go-mruby"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/fields"
+	"k8s.io/apimachinery/pkg/types"
+)
+
+// eventOwnerKinds are kinds that own other objects, so `events` of these kinds also include
+// events of what they own, e.g. replica sets and pods of a deployment
+var eventOwnerKinds = map[string]bool{
+	"Deployment": true,
+	"ReplicaSet": true,
+	"DaemonSet":  true,
+	"Job":        true,
+}
+
+// eventOptions are options of `events` that aren't label or field selectors
+type eventOptions struct {
+	since     time.Duration
+	eventType string
+}
+
+func isEventOption(key string) bool {
+	return key == "since" || key == "type"
+}
+
+// parseEventOptions takes event options out of the hash, and returns a hash with the rest (if any)
+func parseEventOptions(m *mruby.Mrb, hash *mruby.MrbValue) (*eventOptions, *mruby.MrbValue, error) {
+	o := &eventOptions{}
+
+	if hash == nil {
+		return o, nil, nil
+	}
+
+	rest, err := m.LoadString("{}")
+	if err != nil {
+		return nil, nil, err
+	}
+	if err := protectValue(m, rest); err != nil {
+		return nil, nil, err
+	}
+	defer unprotectValue(m, rest)
+	hasRest := false
+
+	if err := iterateHash(hash, func(key, value *mruby.MrbValue) error {
+		k := key.String()
+		if !isEventOption(k) {
+			hasRest = true
+			return rest.Hash().Set(key, value)
+		}
+
+		switch k {
+		case "since":
+			d, err := durationValue(k, value)
+			if err != nil {
+				return err
+			}
+			o.since = d
+		case "type":
+			switch t := value.String(); {
+			case strings.EqualFold(t, corev1.EventTypeNormal):
+				o.eventType = corev1.EventTypeNormal
+			case strings.EqualFold(t, corev1.EventTypeWarning):
+				o.eventType = corev1.EventTypeWarning
+			default:
+				return newArgumentError("type must be either :Normal or :Warning, not %q", t)
+			}
+		}
+		return nil
+	}); err != nil {
+		return nil, nil, err
+	}
+
+	if o.since < 0 {
+		return nil, nil, newArgumentError("since must not be negative")
+	}
+
+	if !hasRest {
+		return o, nil, nil
+	}
+	return o, rest, nil
+}
+
+// durationValue takes a number of seconds or a duration string, e.g. `"1h"`
+func durationValue(key string, value *mruby.MrbValue) (time.Duration, error) {
+	if value.Type() == mruby.TypeString {
+		d, err := time.ParseDuration(value.String())
+		if err != nil {
+			return 0, newArgumentError("invalid duration %q for %q – %v", value.String(), key, err)
+		}
+		return d, nil
+	}
+	n, err := numberValue(key, value)
+	if err != nil {
+		return 0, err
+	}
+	return time.Duration(n * float64(time.Second)), nil
+}
+
+func (o *eventOptions) filter(events []corev1.Event) []corev1.Event {
+	filtered := []corev1.Event{}
+	for i := range events {
+		if o.eventType != "" && events[i].Type != o.eventType {
+			continue
+		}
+		if o.since > 0 && time.Since(eventTime(&events[i])) > o.since {
+			continue
+		}
+		filtered = append(filtered, events[i])
+	}
+	return filtered
+}
+
+// eventTime returns when the event was last seen, not all of the timestamps are set by every component
+func eventTime(event *corev1.Event) time.Time {
+	switch {
+	case !event.LastTimestamp.IsZero():
+		return event.LastTimestamp.Time
+	case !event.EventTime.IsZero():
+		return event.EventTime.Time
+	case !event.FirstTimestamp.IsZero():
+		return event.FirstTimestamp.Time
+	}
+	return event.ObjectMeta.CreationTimestamp.Time
+}
+
+func sortEvents(events []corev1.Event) {
+	sort.SliceStable(events, func(i, j int) bool {
+		return eventTime(&events[i]).Before(eventTime(&events[j]))
+	})
+}
+
+func involvedObjectName(event *corev1.Event) string {
+	ref := event.InvolvedObject
+	if ref.Namespace == "" {
+		return strings.ToLower(ref.Kind) + "/" + ref.Name
+	}
+	return strings.ToLower(ref.Kind) + "/" + ref.Namespace + "/" + ref.Name
+}
+
+// eventLine formats a single line of the timeline
+func eventLine(event *corev1.Event) string {
+	line := fmt.Sprintf("%s %s %s %s: %s",
+		eventTime(event).Format(time.RFC3339), event.Type, involvedObjectName(event),
+		event.Reason, strings.TrimSpace(event.Message))
+	if event.Count > 1 {
+		line += fmt.Sprintf(" (x%d)", event.Count)
+	}
+	return line
+}
+
+// timeline returns events sorted by time, without reordering the list itself
+func (vars *eventsClassInstanceVars) timeline() []corev1.Event {
+	events := append([]corev1.Event{}, vars.events.Items...)
+	sortEvents(events)
+	return events
+}
+
+func (vars *eventsClassInstanceVars) String() string {
+	text := bytes.Buffer{}
+	for _, event := range vars.timeline() {
+		fmt.Fprintln(&text, eventLine(&event))
+	}
+	return text.String()
+}
+
+// Table returns events in columns, like `kubectl get events` does
+func (vars *eventsClassInstanceVars) Table() string {
+	text := bytes.Buffer{}
+	w := tabwriter.NewWriter(&text, 0, 8, 2, ' ', 0)
+	fmt.Fprintln(w, "TIME\tTYPE\tREASON\tOBJECT\tCOUNT\tMESSAGE")
+	for _, event := range vars.timeline() {
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%d\t%s\n",
+			eventTime(&event).Format(time.RFC3339), event.Type, event.Reason,
+			involvedObjectName(&event), event.Count, strings.TrimSpace(event.Message))
+	}
+	w.Flush()
+	return text.String()
+}
+
+// grep returns a new list of events which have a timeline line that matches any of the expressions
+func (c *eventsClass) grep(vars *eventsClassInstanceVars, matchAgainst []*regexp.Regexp) (*eventsClassInstance, error) {
+	newEventsObj, err := c.New()
+	if err != nil {
+		return nil, err
+	}
+	newEventsObj.vars.events = vars.events
+	newEventsObj.vars.events.Items = nil
+
+	for i := range vars.events.Items {
+		line := eventLine(&vars.events.Items[i])
+		for _, re := range matchAgainst {
+			if re.MatchString(line) {
+				newEventsObj.vars.events.Items = append(newEventsObj.vars.events.Items, vars.events.Items[i])
+				break
+			}
+		}
+	}
+	return newEventsObj, nil
+}
+
+// ownedObjects returns UID of the object along with UIDs of all objects it owns directly or
+// indirectly, following owner references of replica sets, jobs and pods in the namespace
+func (rk *RubyKube) ownedObjects(ns string, uid types.UID) ([]types.UID, error) {
+	listers := []func() ([]metav1.ObjectMeta, error){
+		func() ([]metav1.ObjectMeta, error) {
+			list, err := rk.classes.ReplicaSets.getList(ns, metav1.ListOptions{})
+			if err != nil {
+				return nil, err
+			}
+			metas := []metav1.ObjectMeta{}
+			for _, item := range list.Items {
+				metas = append(metas, item.ObjectMeta)
+			}
+			return metas, nil
+		},
+		func() ([]metav1.ObjectMeta, error) {
+			list, err := rk.classes.Jobs.getList(ns, metav1.ListOptions{})
+			if err != nil {
+				return nil, err
+			}
+			metas := []metav1.ObjectMeta{}
+			for _, item := range list.Items {
+				metas = append(metas, item.ObjectMeta)
+			}
+			return metas, nil
+		},
+		func() ([]metav1.ObjectMeta, error) {
+			list, err := rk.classes.Pods.getList(ns, metav1.ListOptions{})
+			if err != nil {
+				return nil, err
+			}
+			metas := []metav1.ObjectMeta{}
+			for _, item := range list.Items {
+				metas = append(metas, item.ObjectMeta)
+			}
+			return metas, nil
+		},
+	}
+
+	results := make([][]metav1.ObjectMeta, len(listers))
+	if err := rk.parallel(len(listers), func(i int) error {
+		metas, err := listers[i]()
+		results[i] = metas
+		return err
+	}); err != nil {
+		return nil, err
+	}
+
+	children := map[types.UID][]types.UID{}
+	for _, metas := range results {
+		for _, meta := range metas {
+			for _, ref := range meta.OwnerReferences {
+				children[ref.UID] = append(children[ref.UID], meta.UID)
+			}
+		}
+	}
+
+	uids := []types.UID{uid}
+	seen := map[types.UID]bool{uid: true}
+	for i := 0; i < len(uids); i++ {
+		for _, child := range children[uids[i]] {
+			if !seen[child] {
+				seen[child] = true
+				uids = append(uids, child)
+			}
+		}
+	}
+	return uids, nil
+}
+
+// eventsOf returns events of a single object, selected by `involvedObject.uid`
+func (rk *RubyKube) eventsOf(ns string, uid types.UID) ([]corev1.Event, error) {
+	listOptions := metav1.ListOptions{
+		FieldSelector: fields.OneTermEqualSelector("involvedObject.uid", string(uid)).String(),
+	}
+	list, err := rk.clientset.Core().Events(ns).List(listOptions)
+	if err != nil {
+		return nil, err
+	}
+	return list.Items, nil
+}
+
+// objectEvents implements `events` method of resource classes, events are selected by
+// `involvedObject.uid` of the object and, for kinds in `eventOwnerKinds`, of what it owns
+func (rk *RubyKube) objectEvents(kind string, meta metav1.ObjectMeta) (*eventsClassInstance, error) {
+	if meta.UID == "" {
+		return nil, newArgumentError("%s %s has no UID, it has to be created first", strings.ToLower(kind), objectName(meta))
+	}
+
+	ns := rk.GetDefaultNamespace(meta.Namespace)
+
+	uids := []types.UID{meta.UID}
+	if eventOwnerKinds[kind] {
+		var err error
+		if uids, err = rk.ownedObjects(ns, meta.UID); err != nil {
+			return nil, err
+		}
+	}
+
+	lists := make([][]corev1.Event, len(uids))
+	if err := rk.parallel(len(uids), func(i int) error {
+		events, err := rk.eventsOf(ns, uids[i])
+		lists[i] = events
+		return err
+	}); err != nil {
+		return nil, err
+	}
+
+	newEventsObj, err := rk.classes.Events.New()
+	if err != nil {
+		return nil, err
+	}
+
+	seen := map[types.UID]bool{}
+	for _, list := range lists {
+		for _, item := range list {
+			if !seen[item.ObjectMeta.UID] {
+				seen[item.ObjectMeta.UID] = true
+				newEventsObj.vars.events.Items = append(newEventsObj.vars.events.Items, item)
+			}
+		}
+	}
+	sortEvents(newEventsObj.vars.events.Items)
+
+	return newEventsObj, nil
+}
+
+// events implements `events` verb, it takes the same arguments as other resource verbs, as well
+// as `since:` (seconds or a duration string, e.g. `"1h"`) and `type:` (`:Normal` or `:Warning`)
+func events(rk *RubyKube, args []*mruby.MrbValue, m *mruby.Mrb, self *mruby.MrbValue) (mruby.Value, mruby.Value) {
+	var hash *mruby.MrbValue
+	eventsArgs := []*mruby.MrbValue{}
+	for _, arg := range args {
+		if arg.Type() == mruby.TypeHash {
+			hash = arg
+			continue
+		}
+		eventsArgs = append(eventsArgs, arg)
+	}
+
+	options, rest, err := parseEventOptions(m, hash)
+	if err != nil {
+		return nil, createError(m, err)
+	}
+	if rest != nil {
+		// the hash is only referenced from Go, and it's used until the verb returns
+		if err := protectValue(m, rest); err != nil {
+			return nil, createError(m, err)
+		}
+		defer unprotectValue(m, rest)
+		eventsArgs = append(eventsArgs, rest)
+	}
+
+	newEventsObj, err := rk.classes.Events.New()
+	if err != nil {
+		return nil, createError(m, err)
+	}
+
+	if _, err := newEventsObj.Update(eventsArgs...); err != nil {
+		return nil, createError(m, err)
+	}
+
+	newEventsObj.vars.events.Items = options.filter(newEventsObj.vars.events.Items)
+	sortEvents(newEventsObj.vars.events.Items)
+
+	return newEventsObj.self, nil
+}
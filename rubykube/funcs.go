@@ -34,17 +34,17 @@ var funcJumpTable = map[string]funcDefinition{
 func loadFunc(rk *RubyKube, m *mruby.Mrb, self *mruby.MrbValue) (mruby.Value, mruby.Value) {
 	args := m.GetArgs()
 	if err := checkArgs(args, 1); err != nil {
-		return nil, createException(m, err.Error())
+		return nil, createError(m, err)
 	}
 
 	content, err := ioutil.ReadFile(args[0].String())
 	if err != nil {
-		return nil, createException(m, err.Error())
+		return nil, createError(m, err)
 	}
 
 	val, err := rk.Run(string(content))
 	if err != nil {
-		return nil, createException(m, err.Error())
+		return nil, createError(m, err)
 	}
 
 	return val, nil
@@ -57,7 +57,7 @@ func getenv(rk *RubyKube, m *mruby.Mrb, self *mruby.MrbValue) (mruby.Value, mrub
 	args := m.GetArgs()
 
 	if err := standardCheck(rk, args, 1); err != nil {
-		return nil, createException(m, err.Error())
+		return nil, createError(m, err)
 	}
 
 	return mruby.String(os.Getenv(args[0].String())), nil
@@ -66,6 +66,9 @@ func getenv(rk *RubyKube, m *mruby.Mrb, self *mruby.MrbValue) (mruby.Value, mrub
 const (
 	classMethod = iota
 	instanceMethod
+	// mutatingMethod is an instance method that changes the cluster, it's refused in read-only mode
+	// and may require confirmation, see `allowChange`
+	mutatingMethod
 )
 
 type methodDefintion struct {
@@ -85,6 +88,7 @@ func emptyMethod() methodDefintion {
 
 func (rk *RubyKube) defineClass(name string, methods map[string]methodDefintion) *mruby.Class {
 	class := rk.mrb.DefineClass(name, rk.classes.Root)
+	rk.classNames[class] = name
 	rk.appendMethods(class, methods)
 	return class
 }
@@ -94,7 +98,15 @@ func (rk *RubyKube) appendMethods(class *mruby.Class, methods map[string]methodD
 		if m.methodType == classMethod {
 			class.DefineClassMethod(name, m.methodFunc, m.argSpec)
 		} else {
-			class.DefineMethod(name, m.methodFunc, m.argSpec)
+			fn := m.methodFunc
+			if m.methodType == mutatingMethod {
+				fn = rk.guardChange(name, fn)
+			}
+			class.DefineMethod(name, fn, m.argSpec)
+			// keep track of instance methods for tab completion
+			if className, ok := rk.classNames[class]; ok && name != "method_missing" {
+				rk.instanceMethods[className] = append(rk.instanceMethods[className], name)
+			}
 		}
 	}
 }
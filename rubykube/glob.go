@@ -0,0 +1,152 @@
+package rubykube
+
+import (
+	"fmt"
+	"path"
+	"strings"
+)
+
+// resourceQuery is what a single glob expression compiles to, e.g. `"team-*/api-?"`
+type resourceQuery struct {
+	namespace     string // namespace to list objects in, empty means current one and "*" means all
+	namespaceGlob string // filter namespaces by this, if namespace is "*"
+	nameGlob      string // filter names by this
+}
+
+func hasGlobMeta(s string) bool {
+	return strings.ContainsAny(s, `*?[\`)
+}
+
+func validateGlob(s string) error {
+	if _, err := path.Match(s, ""); err != nil {
+		return fmt.Errorf("Invalid glob expression %q – %v", s, err)
+	}
+	return nil
+}
+
+// newResourceQuery parses shell-style glob expressions, these are the forms we understand:
+//  - `"<namespace>/"` or `"<namespace>/*"` – everything in given namespace
+//  - `"*/"` – everything in all namespaces
+//  - `"<name>"` – objects in current namespace with name matching the glob
+//  - `"<namespace>/<name>"` – both parts may be globs, e.g. `"team-*/api-*"`
+// Globs may have multiple `*`, as well as `?` and character classes (e.g. `[a-f]`)
+func newResourceQuery(glob string) (*resourceQuery, error) {
+	glob = strings.TrimSpace(glob)
+	if glob == "" {
+		return nil, fmt.Errorf("Empty glob expression")
+	}
+
+	q := &resourceQuery{}
+
+	name := glob
+	if i := strings.Index(glob, "/"); i >= 0 {
+		ns := glob[:i]
+		name = glob[i+1:]
+
+		switch {
+		case ns == "":
+			return nil, fmt.Errorf("Invalid glob expression %q – namespace must be given before \"/\", try `pods \"<namespace>/\"`, `pods \"*/\"` or `pods \"*/foo-*\"`", glob)
+		case ns == "*":
+			q.namespace = "*"
+		case hasGlobMeta(ns):
+			if err := validateGlob(ns); err != nil {
+				return nil, err
+			}
+			q.namespace = "*"
+			q.namespaceGlob = ns
+		default:
+			q.namespace = ns
+		}
+
+		if strings.Contains(name, "/") {
+			return nil, fmt.Errorf("Invalid glob expression %q – only one \"/\" is allowed", glob)
+		}
+	}
+
+	if name != "" && name != "*" {
+		if err := validateGlob(name); err != nil {
+			return nil, err
+		}
+		q.nameGlob = name
+	}
+
+	return q, nil
+}
+
+func (q *resourceQuery) match(namespace, name string) bool {
+	if q.namespaceGlob != "" {
+		if ok, _ := path.Match(q.namespaceGlob, namespace); !ok {
+			return false
+		}
+	}
+	if q.nameGlob != "" {
+		if ok, _ := path.Match(q.nameGlob, name); !ok {
+			return false
+		}
+	}
+	return true
+}
+
+// glob turns the query back into a glob expression, current namespace is filled in, so that the
+// glob selects the same objects after namespace is changed
+func (q *resourceQuery) glob(currentNamespace string) string {
+	ns := q.namespace
+	switch {
+	case q.namespaceGlob != "":
+		ns = q.namespaceGlob
+	case ns == "":
+		ns = currentNamespace
+	}
+	if ns == "" {
+		ns = "*"
+	}
+	return ns + "/" + q.nameGlob
+}
+
+// listQuery is what a list was fetched with, it's kept in snapshots, so that current state can be
+// listed in the same way
+type listQuery struct {
+	Globs  []string         `json:"globs"`
+	Labels string           `json:"labels,omitempty"`
+	Fields []listQueryField `json:"fields,omitempty"`
+}
+
+type listQueryField struct {
+	Key      string   `json:"key"`
+	Operator string   `json:"operator"`
+	Values   []string `json:"values"`
+}
+
+func (rk *RubyKube) newListQuery(queries []resourceQuery, selectors *resourceSelectors) *listQuery {
+	lq := &listQuery{Globs: []string{}, Labels: selectors.labelSelector}
+	for i := range queries {
+		lq.Globs = append(lq.Globs, queries[i].glob(rk.GetNamespace("")))
+	}
+	for _, e := range selectors.fields {
+		lq.Fields = append(lq.Fields, listQueryField{Key: e.key, Operator: e.operator, Values: e.values})
+	}
+	return lq
+}
+
+// parse compiles the query the same way as `resourceArgs` does with arguments of a list verb
+func (lq *listQuery) parse() ([]resourceQuery, *resourceSelectors, error) {
+	queries := []resourceQuery{}
+	for _, glob := range lq.Globs {
+		q, err := newResourceQuery(glob)
+		if err != nil {
+			return nil, nil, err
+		}
+		queries = append(queries, *q)
+	}
+
+	selectors := &resourceSelectors{}
+	if lq.Labels != "" {
+		if err := selectors.setLabelSelector(lq.Labels); err != nil {
+			return nil, nil, err
+		}
+	}
+	for _, f := range lq.Fields {
+		selectors.fields = append(selectors.fields, fieldExpression{key: f.Key, operator: f.Operator, values: f.Values})
+	}
+	return queries, selectors, nil
+}
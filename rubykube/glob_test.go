@@ -0,0 +1,74 @@
+package rubykube
+
+import (
+	"testing"
+)
+
+func TestNewResourceQuery(t *testing.T) {
+	tests := []struct {
+		glob  string
+		query resourceQuery
+		err   bool
+	}{
+		{glob: "prod/", query: resourceQuery{namespace: "prod"}},
+		{glob: "prod/*", query: resourceQuery{namespace: "prod"}},
+		{glob: "*/", query: resourceQuery{namespace: "*"}},
+		{glob: "web-*", query: resourceQuery{nameGlob: "web-*"}},
+		{glob: " web ", query: resourceQuery{nameGlob: "web"}},
+		{glob: "prod/web-?", query: resourceQuery{namespace: "prod", nameGlob: "web-?"}},
+		{glob: "team-*/api-[a-f]", query: resourceQuery{namespace: "*", namespaceGlob: "team-*", nameGlob: "api-[a-f]"}},
+		{glob: "*/web", query: resourceQuery{namespace: "*", nameGlob: "web"}},
+		{glob: "", err: true},
+		{glob: "/web", err: true},
+		{glob: "prod/web/a", err: true},
+		{glob: "prod/web-[", err: true},
+		{glob: "team-[/web", err: true},
+	}
+
+	for _, test := range tests {
+		q, err := newResourceQuery(test.glob)
+		if test.err {
+			if err == nil {
+				t.Errorf("expected an error for %q, got %+v", test.glob, *q)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("unexpected error for %q: %v", test.glob, err)
+			continue
+		}
+		if *q != test.query {
+			t.Errorf("expected %+v for %q, got %+v", test.query, test.glob, *q)
+		}
+	}
+}
+
+func TestResourceQueryMatch(t *testing.T) {
+	tests := []struct {
+		glob            string
+		namespace, name string
+		match           bool
+	}{
+		{"prod/", "prod", "web", true},
+		{"*/", "dev", "web", true},
+		{"web-*", "prod", "web-a", true},
+		{"web-*", "prod", "api-a", false},
+		{"web-?", "prod", "web-ab", false},
+		{"prod/web-[a-c]", "prod", "web-b", true},
+		{"prod/web-[a-c]", "prod", "web-d", false},
+		{"team-*/api-*", "team-a", "api-1", true},
+		{"team-*/api-*", "prod", "api-1", false},
+		{"team-*/api-*", "team-a", "web-1", false},
+	}
+
+	for _, test := range tests {
+		q, err := newResourceQuery(test.glob)
+		if err != nil {
+			t.Errorf("unexpected error for %q: %v", test.glob, err)
+			continue
+		}
+		if match := q.match(test.namespace, test.name); match != test.match {
+			t.Errorf("expected %q to match %s/%s: %v, got %v", test.glob, test.namespace, test.name, test.match, match)
+		}
+	}
+}
@@ -1,8 +1,6 @@
 package rubykube
 
 import (
-	"fmt"
-
 	"github.com/errordeveloper/kubeplay/rubykube/converter"
 	mruby "github.com/mitchellh/go-mruby"
 )
@@ -11,7 +9,7 @@ import (
 
 type daemonSetClass struct {
 	class   *mruby.Class
-	objects []daemonSetClassInstance
+	objects *instanceRegistry
 	rk      *RubyKube
 }
 
@@ -22,10 +20,12 @@ type daemonSetClassInstance struct {
 
 type daemonSetClassInstanceVars struct {
 	daemonSet daemonSetTypeAlias
+	// query is what a list was fetched with by `get!`, it's nil for singletons and lists made in other ways
+	query *listQuery
 }
 
 func newDaemonSetClass(rk *RubyKube) *daemonSetClass {
-	c := &daemonSetClass{objects: []daemonSetClassInstance{}, rk: rk}
+	c := &daemonSetClass{objects: rk.newInstanceRegistry("DaemonSet"), rk: rk}
 	c.class = defineDaemonSetClass(rk, c)
 	return c
 }
@@ -37,12 +37,12 @@ func defineDaemonSetClass(rk *RubyKube, c *daemonSetClass) *mruby.Class {
 			mruby.ArgsReq(0), func(m *mruby.Mrb, self *mruby.MrbValue) (mruby.Value, mruby.Value) {
 				vars, err := c.LookupVars(self)
 				if err != nil {
-					return nil, createException(m, err.Error())
+					return nil, createError(m, err)
 				}
 
 				rbconv := converter.New(m)
 				if err := rbconv.Convert(vars.daemonSet); err != nil {
-					return nil, createException(m, err.Error())
+					return nil, createError(m, err)
 				}
 
 				return rbconv.Value(), nil
@@ -53,17 +53,21 @@ func defineDaemonSetClass(rk *RubyKube, c *daemonSetClass) *mruby.Class {
 			mruby.ArgsReq(0), func(m *mruby.Mrb, self *mruby.MrbValue) (mruby.Value, mruby.Value) {
 				vars, err := c.LookupVars(self)
 				if err != nil {
-					return nil, createException(m, err.Error())
+					return nil, createError(m, err)
 				}
 				return marshalToJSON(vars.daemonSet, m)
 			},
 			instanceMethod,
 		},
-		"object_count": {
-			mruby.ArgsNone(), func(m *mruby.Mrb, self *mruby.MrbValue) (mruby.Value, mruby.Value) {
-				return m.FixnumValue(len(c.objects)), nil
+		"jsonpath": {
+			mruby.ArgsReq(1), func(m *mruby.Mrb, self *mruby.MrbValue) (mruby.Value, mruby.Value) {
+				vars, err := c.LookupVars(self)
+				if err != nil {
+					return nil, createError(m, err)
+				}
+				return jsonPathMethod(m, &vars.daemonSet)
 			},
-			classMethod,
+			instanceMethod,
 		},
 	})
 }
@@ -73,21 +77,25 @@ func (c *daemonSetClass) New() (*daemonSetClassInstance, error) {
 	if err != nil {
 		return nil, err
 	}
-	o := daemonSetClassInstance{
+	o := &daemonSetClassInstance{
 		self: s,
 		vars: &daemonSetClassInstanceVars{
-			daemonSetTypeAlias{},
+			daemonSet: daemonSetTypeAlias{},
 		},
 	}
-	c.objects = append(c.objects, o)
-	return &o, nil
+	c.objects.add(s, o)
+	return o, nil
 }
 
 func (c *daemonSetClass) LookupVars(this *mruby.MrbValue) (*daemonSetClassInstanceVars, error) {
-	for _, that := range c.objects {
-		if *this == *that.self {
-			return that.vars, nil
-		}
+	o, err := c.objects.lookup(this)
+	if err != nil {
+		return nil, err
 	}
-	return nil, fmt.Errorf("%s: could not find class instance", "DaemonSet")
+	return o.(*daemonSetClassInstance).vars, nil
+}
+
+// object returns the resource object, so that it can be looked up without knowing the class
+func (o *daemonSetClassInstance) object() interface{} {
+	return &o.vars.daemonSet
 }
@@ -2,8 +2,13 @@ package rubykube
 
 import (
 	"fmt"
+	"strings"
+	"time"
 
 	mruby "github.com/mitchellh/go-mruby"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/watch"
 )
 
 // template type RubyKubeClass(parentClass, classNameString, instanceVariableName, instanceVariableType)
@@ -16,30 +21,87 @@ func (c *daemonSetClass) defineSingletonMethods() {
 			mruby.ArgsReq(0), func(m *mruby.Mrb, self *mruby.MrbValue) (mruby.Value, mruby.Value) {
 				vars, err := c.LookupVars(self)
 				if err != nil {
-					return nil, createException(m, err.Error())
+					return nil, createError(m, err)
 				}
 
 				meta := vars.daemonSet.ObjectMeta
 				daemonSet, err := c.getSingleton(meta.Namespace, meta.Name)
 				if err != nil {
-					return nil, createException(m, err.Error())
+					return nil, createError(m, err)
 				}
 				vars.daemonSet = daemonSetTypeAlias(*daemonSet)
 				return self, nil
 			},
 			instanceMethod,
 		},
+		"to_s": {
+			mruby.ArgsReq(0), func(m *mruby.Mrb, self *mruby.MrbValue) (mruby.Value, mruby.Value) {
+				vars, err := c.LookupVars(self)
+				if err != nil {
+					return nil, createError(m, err)
+				}
+
+				return m.StringValue(objectName(vars.daemonSet.ObjectMeta)), nil
+			},
+			instanceMethod,
+		},
 		"inspect": {
 			mruby.ArgsReq(0), func(m *mruby.Mrb, self *mruby.MrbValue) (mruby.Value, mruby.Value) {
 				vars, err := c.LookupVars(self)
 				if err != nil {
-					return nil, createException(m, err.Error())
+					return nil, createError(m, err)
+				}
+
+				return m.StringValue(fmt.Sprintf("#<%s %s>", "daemonSet", objectName(vars.daemonSet.ObjectMeta))), nil
+			},
+			instanceMethod,
+		},
+		"events": {
+			mruby.ArgsNone(), func(m *mruby.Mrb, self *mruby.MrbValue) (mruby.Value, mruby.Value) {
+				vars, err := c.LookupVars(self)
+				if err != nil {
+					return nil, createError(m, err)
+				}
+
+				newEventsObj, err := c.rk.objectEvents("daemonSet", vars.daemonSet.ObjectMeta)
+				if err != nil {
+					return nil, createError(m, err)
+				}
+				return newEventsObj.self, nil
+			},
+			instanceMethod,
+		},
+		"wait_deleted": {
+			mruby.ArgsOpt(1), func(m *mruby.Mrb, self *mruby.MrbValue) (mruby.Value, mruby.Value) {
+				vars, err := c.LookupVars(self)
+				if err != nil {
+					return nil, createError(m, err)
 				}
 
-				fmt.Printf("self: %s/%s\n", vars.daemonSet.ObjectMeta.Namespace, vars.daemonSet.ObjectMeta.Name)
+				timeout, err := waitTimeout(m.GetArgs())
+				if err != nil {
+					return nil, createError(m, err)
+				}
+
+				if _, err := c.waitFor(vars.daemonSet.ObjectMeta, "to be deleted", timeout, objectDeleted); err != nil {
+					return nil, createError(m, err)
+				}
 				return self, nil
 			},
 			instanceMethod,
 		},
 	})
 }
+
+// waitFor watches the object until condition is met, see `waitForObject`
+func (c *daemonSetClass) waitFor(meta metav1.ObjectMeta, what string, timeout time.Duration, condition waitCondition) (runtime.Object, error) {
+	ns := c.rk.GetDefaultNamespace(meta.Namespace)
+	what = fmt.Sprintf("%s %s/%s %s", strings.ToLower("daemonSet"), ns, meta.Name, what)
+
+	return c.rk.waitForObject(what, meta.Name, timeout,
+		func() (runtime.Object, error) { return c.getSingleton(ns, meta.Name) },
+		func(listOptions metav1.ListOptions) (watch.Interface, error) {
+			return c.watchSingleton(ns, listOptions)
+		},
+		condition)
+}
@@ -1,8 +1,6 @@
 package rubykube
 
 import (
-	"fmt"
-
 	"github.com/errordeveloper/kubeplay/rubykube/converter"
 	mruby "github.com/mitchellh/go-mruby"
 )
@@ -11,7 +9,7 @@ import (
 
 type daemonSetsClass struct {
 	class   *mruby.Class
-	objects []daemonSetsClassInstance
+	objects *instanceRegistry
 	rk      *RubyKube
 }
 
@@ -22,10 +20,12 @@ type daemonSetsClassInstance struct {
 
 type daemonSetsClassInstanceVars struct {
 	daemonSets daemonSetListTypeAlias
+	// query is what a list was fetched with by `get!`, it's nil for singletons and lists made in other ways
+	query *listQuery
 }
 
 func newDaemonSetsClass(rk *RubyKube) *daemonSetsClass {
-	c := &daemonSetsClass{objects: []daemonSetsClassInstance{}, rk: rk}
+	c := &daemonSetsClass{objects: rk.newInstanceRegistry("DaemonSets"), rk: rk}
 	c.class = defineDaemonSetsClass(rk, c)
 	return c
 }
@@ -37,12 +37,12 @@ func defineDaemonSetsClass(rk *RubyKube, c *daemonSetsClass) *mruby.Class {
 			mruby.ArgsReq(0), func(m *mruby.Mrb, self *mruby.MrbValue) (mruby.Value, mruby.Value) {
 				vars, err := c.LookupVars(self)
 				if err != nil {
-					return nil, createException(m, err.Error())
+					return nil, createError(m, err)
 				}
 
 				rbconv := converter.New(m)
 				if err := rbconv.Convert(vars.daemonSets); err != nil {
-					return nil, createException(m, err.Error())
+					return nil, createError(m, err)
 				}
 
 				return rbconv.Value(), nil
@@ -53,17 +53,21 @@ func defineDaemonSetsClass(rk *RubyKube, c *daemonSetsClass) *mruby.Class {
 			mruby.ArgsReq(0), func(m *mruby.Mrb, self *mruby.MrbValue) (mruby.Value, mruby.Value) {
 				vars, err := c.LookupVars(self)
 				if err != nil {
-					return nil, createException(m, err.Error())
+					return nil, createError(m, err)
 				}
 				return marshalToJSON(vars.daemonSets, m)
 			},
 			instanceMethod,
 		},
-		"object_count": {
-			mruby.ArgsNone(), func(m *mruby.Mrb, self *mruby.MrbValue) (mruby.Value, mruby.Value) {
-				return m.FixnumValue(len(c.objects)), nil
+		"jsonpath": {
+			mruby.ArgsReq(1), func(m *mruby.Mrb, self *mruby.MrbValue) (mruby.Value, mruby.Value) {
+				vars, err := c.LookupVars(self)
+				if err != nil {
+					return nil, createError(m, err)
+				}
+				return jsonPathMethod(m, &vars.daemonSets)
 			},
-			classMethod,
+			instanceMethod,
 		},
 	})
 }
@@ -73,21 +77,25 @@ func (c *daemonSetsClass) New() (*daemonSetsClassInstance, error) {
 	if err != nil {
 		return nil, err
 	}
-	o := daemonSetsClassInstance{
+	o := &daemonSetsClassInstance{
 		self: s,
 		vars: &daemonSetsClassInstanceVars{
-			daemonSetListTypeAlias{},
+			daemonSets: daemonSetListTypeAlias{},
 		},
 	}
-	c.objects = append(c.objects, o)
-	return &o, nil
+	c.objects.add(s, o)
+	return o, nil
 }
 
 func (c *daemonSetsClass) LookupVars(this *mruby.MrbValue) (*daemonSetsClassInstanceVars, error) {
-	for _, that := range c.objects {
-		if *this == *that.self {
-			return that.vars, nil
-		}
+	o, err := c.objects.lookup(this)
+	if err != nil {
+		return nil, err
 	}
-	return nil, fmt.Errorf("%s: could not find class instance", "DaemonSets")
+	return o.(*daemonSetsClassInstance).vars, nil
+}
+
+// object returns the resource object, so that it can be looked up without knowing the class
+func (o *daemonSetsClassInstance) object() interface{} {
+	return &o.vars.daemonSets
 }
@@ -1,8 +1,6 @@
 package rubykube
 
 import (
-	"fmt"
-
 	"github.com/errordeveloper/kubeplay/rubykube/converter"
 	mruby "github.com/mitchellh/go-mruby"
 )
@@ -11,7 +9,7 @@ import (
 
 type deploymentClass struct {
 	class   *mruby.Class
-	objects []deploymentClassInstance
+	objects *instanceRegistry
 	rk      *RubyKube
 }
 
@@ -22,10 +20,12 @@ type deploymentClassInstance struct {
 
 type deploymentClassInstanceVars struct {
 	deployment deploymentTypeAlias
+	// query is what a list was fetched with by `get!`, it's nil for singletons and lists made in other ways
+	query *listQuery
 }
 
 func newDeploymentClass(rk *RubyKube) *deploymentClass {
-	c := &deploymentClass{objects: []deploymentClassInstance{}, rk: rk}
+	c := &deploymentClass{objects: rk.newInstanceRegistry("Deployment"), rk: rk}
 	c.class = defineDeploymentClass(rk, c)
 	return c
 }
@@ -37,12 +37,12 @@ func defineDeploymentClass(rk *RubyKube, c *deploymentClass) *mruby.Class {
 			mruby.ArgsReq(0), func(m *mruby.Mrb, self *mruby.MrbValue) (mruby.Value, mruby.Value) {
 				vars, err := c.LookupVars(self)
 				if err != nil {
-					return nil, createException(m, err.Error())
+					return nil, createError(m, err)
 				}
 
 				rbconv := converter.New(m)
 				if err := rbconv.Convert(vars.deployment); err != nil {
-					return nil, createException(m, err.Error())
+					return nil, createError(m, err)
 				}
 
 				return rbconv.Value(), nil
@@ -53,17 +53,21 @@ func defineDeploymentClass(rk *RubyKube, c *deploymentClass) *mruby.Class {
 			mruby.ArgsReq(0), func(m *mruby.Mrb, self *mruby.MrbValue) (mruby.Value, mruby.Value) {
 				vars, err := c.LookupVars(self)
 				if err != nil {
-					return nil, createException(m, err.Error())
+					return nil, createError(m, err)
 				}
 				return marshalToJSON(vars.deployment, m)
 			},
 			instanceMethod,
 		},
-		"object_count": {
-			mruby.ArgsNone(), func(m *mruby.Mrb, self *mruby.MrbValue) (mruby.Value, mruby.Value) {
-				return m.FixnumValue(len(c.objects)), nil
+		"jsonpath": {
+			mruby.ArgsReq(1), func(m *mruby.Mrb, self *mruby.MrbValue) (mruby.Value, mruby.Value) {
+				vars, err := c.LookupVars(self)
+				if err != nil {
+					return nil, createError(m, err)
+				}
+				return jsonPathMethod(m, &vars.deployment)
 			},
-			classMethod,
+			instanceMethod,
 		},
 	})
 }
@@ -73,21 +77,25 @@ func (c *deploymentClass) New() (*deploymentClassInstance, error) {
 	if err != nil {
 		return nil, err
 	}
-	o := deploymentClassInstance{
+	o := &deploymentClassInstance{
 		self: s,
 		vars: &deploymentClassInstanceVars{
-			deploymentTypeAlias{},
+			deployment: deploymentTypeAlias{},
 		},
 	}
-	c.objects = append(c.objects, o)
-	return &o, nil
+	c.objects.add(s, o)
+	return o, nil
 }
 
 func (c *deploymentClass) LookupVars(this *mruby.MrbValue) (*deploymentClassInstanceVars, error) {
-	for _, that := range c.objects {
-		if *this == *that.self {
-			return that.vars, nil
-		}
+	o, err := c.objects.lookup(this)
+	if err != nil {
+		return nil, err
 	}
-	return nil, fmt.Errorf("%s: could not find class instance", "Deployment")
+	return o.(*deploymentClassInstance).vars, nil
+}
+
+// object returns the resource object, so that it can be looked up without knowing the class
+func (o *deploymentClassInstance) object() interface{} {
+	return &o.vars.deployment
 }
@@ -1,8 +1,6 @@
 package rubykube
 
 import (
-	"fmt"
-
 	"github.com/errordeveloper/kubeplay/rubykube/converter"
 	mruby "github.com/mitchellh/go-mruby"
 )
@@ -11,7 +9,7 @@ import (
 
 type deploymentsClass struct {
 	class   *mruby.Class
-	objects []deploymentsClassInstance
+	objects *instanceRegistry
 	rk      *RubyKube
 }
 
@@ -22,10 +20,12 @@ type deploymentsClassInstance struct {
 
 type deploymentsClassInstanceVars struct {
 	deployments deploymentListTypeAlias
+	// query is what a list was fetched with by `get!`, it's nil for singletons and lists made in other ways
+	query *listQuery
 }
 
 func newDeploymentsClass(rk *RubyKube) *deploymentsClass {
-	c := &deploymentsClass{objects: []deploymentsClassInstance{}, rk: rk}
+	c := &deploymentsClass{objects: rk.newInstanceRegistry("Deployments"), rk: rk}
 	c.class = defineDeploymentsClass(rk, c)
 	return c
 }
@@ -37,12 +37,12 @@ func defineDeploymentsClass(rk *RubyKube, c *deploymentsClass) *mruby.Class {
 			mruby.ArgsReq(0), func(m *mruby.Mrb, self *mruby.MrbValue) (mruby.Value, mruby.Value) {
 				vars, err := c.LookupVars(self)
 				if err != nil {
-					return nil, createException(m, err.Error())
+					return nil, createError(m, err)
 				}
 
 				rbconv := converter.New(m)
 				if err := rbconv.Convert(vars.deployments); err != nil {
-					return nil, createException(m, err.Error())
+					return nil, createError(m, err)
 				}
 
 				return rbconv.Value(), nil
@@ -53,17 +53,21 @@ func defineDeploymentsClass(rk *RubyKube, c *deploymentsClass) *mruby.Class {
 			mruby.ArgsReq(0), func(m *mruby.Mrb, self *mruby.MrbValue) (mruby.Value, mruby.Value) {
 				vars, err := c.LookupVars(self)
 				if err != nil {
-					return nil, createException(m, err.Error())
+					return nil, createError(m, err)
 				}
 				return marshalToJSON(vars.deployments, m)
 			},
 			instanceMethod,
 		},
-		"object_count": {
-			mruby.ArgsNone(), func(m *mruby.Mrb, self *mruby.MrbValue) (mruby.Value, mruby.Value) {
-				return m.FixnumValue(len(c.objects)), nil
+		"jsonpath": {
+			mruby.ArgsReq(1), func(m *mruby.Mrb, self *mruby.MrbValue) (mruby.Value, mruby.Value) {
+				vars, err := c.LookupVars(self)
+				if err != nil {
+					return nil, createError(m, err)
+				}
+				return jsonPathMethod(m, &vars.deployments)
 			},
-			classMethod,
+			instanceMethod,
 		},
 	})
 }
@@ -73,21 +77,25 @@ func (c *deploymentsClass) New() (*deploymentsClassInstance, error) {
 	if err != nil {
 		return nil, err
 	}
-	o := deploymentsClassInstance{
+	o := &deploymentsClassInstance{
 		self: s,
 		vars: &deploymentsClassInstanceVars{
-			deploymentListTypeAlias{},
+			deployments: deploymentListTypeAlias{},
 		},
 	}
-	c.objects = append(c.objects, o)
-	return &o, nil
+	c.objects.add(s, o)
+	return o, nil
 }
 
 func (c *deploymentsClass) LookupVars(this *mruby.MrbValue) (*deploymentsClassInstanceVars, error) {
-	for _, that := range c.objects {
-		if *this == *that.self {
-			return that.vars, nil
-		}
+	o, err := c.objects.lookup(this)
+	if err != nil {
+		return nil, err
 	}
-	return nil, fmt.Errorf("%s: could not find class instance", "Deployments")
+	return o.(*deploymentsClassInstance).vars, nil
+}
+
+// object returns the resource object, so that it can be looked up without knowing the class
+func (o *deploymentsClassInstance) object() interface{} {
+	return &o.vars.deployments
 }
@@ -3,43 +3,133 @@ package rubykube
 import (
 	"fmt"
 	"math/rand"
+	"strings"
 
 	mruby "github.com/mitchellh/go-mruby"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 )
 
 // template type RubyKubeClass(parentClass, classNameString, instanceVariableName, instanceVariableType)
 
 type deploymentsListModule struct{}
 
+// listNames returns `<namespace>/<name>` of all objects in given namespace, it is used for tab completion
+func (c *deploymentsClass) listNames(ns string) ([]string, error) {
+	deployments, err := c.getList(ns, metav1.ListOptions{})
+	if err != nil {
+		return nil, err
+	}
+
+	names := []string{}
+	for _, item := range deployments.Items {
+		names = append(names, item.ObjectMeta.Namespace+"/"+item.ObjectMeta.Name)
+	}
+	return names, nil
+}
+
+// getQuery lists objects that match any of the queries and the selectors, it is used by `get!`,
+// and to list current state of objects in a snapshot
+func (c *deploymentsClass) getQuery(queries []resourceQuery, selectors *resourceSelectors) (*deploymentListTypeAlias, error) {
+	// set-valued field selectors expand into multiple queries, and those
+	// not supported by API server are evaluated on the client
+	listOptions, clientFields := selectors.listOptions("Deployments")
+
+	// each namespace is listed only once, as multiple globs may refer to the same one,
+	// and lists are fetched concurrently
+	type listRequest struct {
+		ns          string
+		listOptions metav1.ListOptions
+	}
+	requests := []listRequest{}
+	requested := map[string]int{}
+	for _, q := range queries {
+		ns := c.rk.GetNamespace(q.namespace)
+		for _, lo := range listOptions {
+			if _, ok := requested[ns+"?"+lo.FieldSelector]; !ok {
+				requested[ns+"?"+lo.FieldSelector] = len(requests)
+				requests = append(requests, listRequest{ns, lo})
+			}
+		}
+	}
+
+	lists := make([]*deploymentListTypeAlias, len(requests))
+	if err := c.rk.parallel(len(requests), func(i int) error {
+		list, err := c.getList(requests[i].ns, requests[i].listOptions)
+		if err != nil {
+			return err
+		}
+		lists[i] = (*deploymentListTypeAlias)(list)
+		return nil
+	}); err != nil {
+		return nil, err
+	}
+
+	seen := map[string]bool{}
+
+	result := &deploymentListTypeAlias{}
+	for _, q := range queries {
+		ns := c.rk.GetNamespace(q.namespace)
+		for _, lo := range listOptions {
+			deployments := lists[requested[ns+"?"+lo.FieldSelector]]
+			result.TypeMeta = deployments.TypeMeta
+			result.ListMeta = deployments.ListMeta
+
+			for i, item := range deployments.Items {
+				key := item.ObjectMeta.Namespace + "/" + item.ObjectMeta.Name
+				if seen[key] || !q.match(item.ObjectMeta.Namespace, item.ObjectMeta.Name) {
+					continue
+				}
+				ok, err := matchFieldExpressions(&deployments.Items[i], clientFields)
+				if err != nil {
+					return nil, err
+				}
+				if !ok {
+					continue
+				}
+				seen[key] = true
+				result.Items = append(result.Items, item)
+			}
+		}
+	}
+	return result, nil
+}
+
 func (c *deploymentsClass) defineListMethods() {
 	c.rk.appendMethods(c.class, map[string]methodDefintion{
 		"get!": {
 			mruby.ArgsReq(0) | mruby.ArgsOpt(2), func(m *mruby.Mrb, self *mruby.MrbValue) (mruby.Value, mruby.Value) {
 				vars, err := c.LookupVars(self)
 				if err != nil {
-					return nil, createException(m, err.Error())
+					return nil, createError(m, err)
 				}
 
-				ns, nameRegexp, listOptions, err := c.rk.resourceArgs(m.GetArgs())
+				queries, selectors, err := c.rk.resourceArgs(m.GetArgs())
 				if err != nil {
-					return nil, createException(m, err.Error())
+					return nil, createError(m, err)
 				}
 
-				deployments, err := c.getList(c.rk.GetNamespace(ns), *listOptions)
+				deployments, err := c.getQuery(queries, selectors)
+				if err != nil {
+					return nil, createError(m, err)
+				}
+				vars.deployments = *deployments
+				vars.query = c.rk.newListQuery(queries, selectors)
+				return self, nil
+			},
+			instanceMethod,
+		},
+		"to_s": {
+			mruby.ArgsReq(0), func(m *mruby.Mrb, self *mruby.MrbValue) (mruby.Value, mruby.Value) {
+				vars, err := c.LookupVars(self)
 				if err != nil {
-					return nil, createException(m, err.Error())
+					return nil, createError(m, err)
 				}
 
-				if nameRegexp != nil {
-					for _, item := range deployments.Items {
-						if nameRegexp.MatchString(item.ObjectMeta.Name) {
-							vars.deployments.Items = append(vars.deployments.Items, item)
-						}
-					}
-				} else {
-					vars.deployments = deploymentListTypeAlias(*deployments)
+				names := []string{}
+				for _, item := range vars.deployments.Items {
+					names = append(names, objectName(item.ObjectMeta))
 				}
-				return self, nil
+				return m.StringValue(strings.Join(names, "\n")), nil
 			},
 			instanceMethod,
 		},
@@ -47,13 +137,14 @@ func (c *deploymentsClass) defineListMethods() {
 			mruby.ArgsReq(0), func(m *mruby.Mrb, self *mruby.MrbValue) (mruby.Value, mruby.Value) {
 				vars, err := c.LookupVars(self)
 				if err != nil {
-					return nil, createException(m, err.Error())
+					return nil, createError(m, err)
 				}
 
+				lines := []string{fmt.Sprintf("#<%s count=%d>", "Deployments", len(vars.deployments.Items))}
 				for n, item := range vars.deployments.Items {
-					fmt.Printf("%d: %s/%s\n", n, item.ObjectMeta.Namespace, item.ObjectMeta.Name)
+					lines = append(lines, fmt.Sprintf("%d: %s", n, objectName(item.ObjectMeta)))
 				}
-				return self, nil
+				return m.StringValue(strings.Join(lines, "\n")), nil
 			},
 			instanceMethod,
 		},
@@ -61,24 +152,83 @@ func (c *deploymentsClass) defineListMethods() {
 			mruby.ArgsReq(0), func(m *mruby.Mrb, self *mruby.MrbValue) (mruby.Value, mruby.Value) {
 				vars, err := c.LookupVars(self)
 				if err != nil {
-					return nil, createException(m, err.Error())
+					return nil, createError(m, err)
 				}
 
 				return m.FixnumValue(len(vars.deployments.Items)), nil
 			},
 			instanceMethod,
 		},
+		"pluck": {
+			mruby.ArgsReq(1), func(m *mruby.Mrb, self *mruby.MrbValue) (mruby.Value, mruby.Value) {
+				vars, err := c.LookupVars(self)
+				if err != nil {
+					return nil, createError(m, err)
+				}
+
+				args := m.GetArgs()
+				if err := standardCheck(c.rk, args, 1); err != nil {
+					return nil, createError(m, err)
+				}
+
+				array, err := newArrayOf(m, len(vars.deployments.Items), func(i int) (mruby.Value, error) {
+					return pluckValue(m, &vars.deployments.Items[i], args[0].String())
+				})
+				if err != nil {
+					return nil, createError(m, err)
+				}
+				return array, nil
+			},
+			instanceMethod,
+		},
+		"where": {
+			mruby.ArgsReq(1), func(m *mruby.Mrb, self *mruby.MrbValue) (mruby.Value, mruby.Value) {
+				vars, err := c.LookupVars(self)
+				if err != nil {
+					return nil, createError(m, err)
+				}
+
+				args := m.GetArgs()
+				if err := standardCheck(c.rk, args, 1); err != nil {
+					return nil, createError(m, err)
+				}
+
+				conditions, err := newWhereConditions(args[0])
+				if err != nil {
+					return nil, createError(m, err)
+				}
+
+				newListObj, err := c.New()
+				if err != nil {
+					return nil, createError(m, err)
+				}
+
+				newListObj.vars.deployments = vars.deployments
+				newListObj.vars.deployments.Items = nil
+				for i, item := range vars.deployments.Items {
+					ok, err := matchWhereConditions(&vars.deployments.Items[i], conditions)
+					if err != nil {
+						return nil, createError(m, err)
+					}
+					if ok {
+						newListObj.vars.deployments.Items = append(newListObj.vars.deployments.Items, item)
+					}
+				}
+				return newListObj.self, nil
+			},
+			instanceMethod,
+		},
 		"[]": {
 			mruby.ArgsReq(1), func(m *mruby.Mrb, self *mruby.MrbValue) (mruby.Value, mruby.Value) {
 				vars, err := c.LookupVars(self)
 				if err != nil {
-					return nil, createException(m, err.Error())
+					return nil, createError(m, err)
 				}
 
 				args := m.GetArgs()
 				err = standardCheck(c.rk, args, 1)
 				if err != nil {
-					return nil, createException(m, err.Error())
+					return nil, createError(m, err)
 				}
 				n := args[0]
 				if n.Type() != mruby.TypeFixnum {
@@ -104,7 +254,7 @@ func (c *deploymentsClass) defineListMethods() {
 
 				obj, err := c.getItem(vars.deployments, i)
 				if err != nil {
-					return nil, createException(m, err.Error())
+					return nil, createError(m, err)
 				}
 				return obj.self, nil
 			},
@@ -114,13 +264,13 @@ func (c *deploymentsClass) defineListMethods() {
 			mruby.ArgsNone(), func(m *mruby.Mrb, self *mruby.MrbValue) (mruby.Value, mruby.Value) {
 				vars, err := c.LookupVars(self)
 				if err != nil {
-					return nil, createException(m, err.Error())
+					return nil, createError(m, err)
 				}
 
 				if len(vars.deployments.Items) > 0 {
 					obj, err := c.getItem(vars.deployments, 0)
 					if err != nil {
-						return nil, createException(m, err.Error())
+						return nil, createError(m, err)
 					}
 					return obj.self, nil
 				}
@@ -132,14 +282,14 @@ func (c *deploymentsClass) defineListMethods() {
 			mruby.ArgsNone(), func(m *mruby.Mrb, self *mruby.MrbValue) (mruby.Value, mruby.Value) {
 				vars, err := c.LookupVars(self)
 				if err != nil {
-					return nil, createException(m, err.Error())
+					return nil, createError(m, err)
 				}
 
 				l := len(vars.deployments.Items)
 				if l > 0 {
 					obj, err := c.getItem(vars.deployments, rand.Intn(l))
 					if err != nil {
-						return nil, createException(m, err.Error())
+						return nil, createError(m, err)
 					}
 					return obj.self, nil
 				}
@@ -151,7 +301,7 @@ func (c *deploymentsClass) defineListMethods() {
 			mruby.ArgsNone(), func(m *mruby.Mrb, self *mruby.MrbValue) (mruby.Value, mruby.Value) {
 				vars, err := c.LookupVars(self)
 				if err != nil {
-					return nil, createException(m, err.Error())
+					return nil, createError(m, err)
 				}
 
 				l := len(vars.deployments.Items)
@@ -159,7 +309,7 @@ func (c *deploymentsClass) defineListMethods() {
 				if l > 0 {
 					obj, err := c.getItem(vars.deployments, l-1)
 					if err != nil {
-						return nil, createException(m, err.Error())
+						return nil, createError(m, err)
 					}
 					return obj.self, nil
 				}
@@ -0,0 +1,63 @@
+package rubykube
+
+import (
+	mruby "github.com/mitchellh/go-mruby"
+)
+
+// template type RubyKubeClass(classNameString, newClassInstanceVars, classInstanceVarsType)
+
+type diagnosisClass struct {
+	class   *mruby.Class
+	objects *instanceRegistry
+	rk      *RubyKube
+}
+
+type diagnosisClassInstance struct {
+	self *mruby.MrbValue
+	vars *diagnosisClassInstanceVars
+}
+
+func newDiagnosisClass(rk *RubyKube) *diagnosisClass {
+	c := &diagnosisClass{objects: rk.newInstanceRegistry("Diagnosis"), rk: rk}
+	c.class = defineDiagnosisClass(rk, c)
+	return c
+}
+
+func defineDiagnosisClass(rk *RubyKube, c *diagnosisClass) *mruby.Class {
+	// common methods
+	return rk.defineClass("Diagnosis", map[string]methodDefintion{
+		"inspect": {
+			mruby.ArgsReq(0), func(m *mruby.Mrb, self *mruby.MrbValue) (mruby.Value, mruby.Value) {
+				return m.StringValue("#<" + "Diagnosis" + ">"), nil
+			},
+			instanceMethod,
+		},
+	})
+}
+
+func (c *diagnosisClass) New(args ...mruby.Value) (*diagnosisClassInstance, error) {
+	s, err := c.class.New()
+	if err != nil {
+		return nil, err
+	}
+
+	v, err := newDiagnosisClassInstanceVars(c, s, args...)
+	if err != nil {
+		return nil, err
+	}
+
+	o := &diagnosisClassInstance{
+		self: s,
+		vars: v,
+	}
+	c.objects.add(s, o)
+	return o, nil
+}
+
+func (c *diagnosisClass) LookupVars(this *mruby.MrbValue) (*diagnosisClassInstanceVars, error) {
+	o, err := c.objects.lookup(this)
+	if err != nil {
+		return nil, err
+	}
+	return o.(*diagnosisClassInstance).vars, nil
+}
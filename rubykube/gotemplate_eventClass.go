@@ -0,0 +1,101 @@
+package rubykube
+
+import (
+	"github.com/errordeveloper/kubeplay/rubykube/converter"
+	mruby "github.com/mitchellh/go-mruby"
+)
+
+// template type RubyKubeClass(classNameString, instanceVariableName, instanceVariableType)
+
+type eventClass struct {
+	class   *mruby.Class
+	objects *instanceRegistry
+	rk      *RubyKube
+}
+
+type eventClassInstance struct {
+	self *mruby.MrbValue
+	vars *eventClassInstanceVars
+}
+
+type eventClassInstanceVars struct {
+	event eventTypeAlias
+	// query is what a list was fetched with by `get!`, it's nil for singletons and lists made in other ways
+	query *listQuery
+}
+
+func newEventClass(rk *RubyKube) *eventClass {
+	c := &eventClass{objects: rk.newInstanceRegistry("Event"), rk: rk}
+	c.class = defineEventClass(rk, c)
+	return c
+}
+
+func defineEventClass(rk *RubyKube, c *eventClass) *mruby.Class {
+	// common methods
+	return rk.defineClass("Event", map[string]methodDefintion{
+		"to_ruby": {
+			mruby.ArgsReq(0), func(m *mruby.Mrb, self *mruby.MrbValue) (mruby.Value, mruby.Value) {
+				vars, err := c.LookupVars(self)
+				if err != nil {
+					return nil, createError(m, err)
+				}
+
+				rbconv := converter.New(m)
+				if err := rbconv.Convert(vars.event); err != nil {
+					return nil, createError(m, err)
+				}
+
+				return rbconv.Value(), nil
+			},
+			instanceMethod,
+		},
+		"to_json": {
+			mruby.ArgsReq(0), func(m *mruby.Mrb, self *mruby.MrbValue) (mruby.Value, mruby.Value) {
+				vars, err := c.LookupVars(self)
+				if err != nil {
+					return nil, createError(m, err)
+				}
+				return marshalToJSON(vars.event, m)
+			},
+			instanceMethod,
+		},
+		"jsonpath": {
+			mruby.ArgsReq(1), func(m *mruby.Mrb, self *mruby.MrbValue) (mruby.Value, mruby.Value) {
+				vars, err := c.LookupVars(self)
+				if err != nil {
+					return nil, createError(m, err)
+				}
+				return jsonPathMethod(m, &vars.event)
+			},
+			instanceMethod,
+		},
+	})
+}
+
+func (c *eventClass) New() (*eventClassInstance, error) {
+	s, err := c.class.New()
+	if err != nil {
+		return nil, err
+	}
+	o := &eventClassInstance{
+		self: s,
+		vars: &eventClassInstanceVars{
+			event: eventTypeAlias{},
+		},
+	}
+	c.objects.add(s, o)
+	return o, nil
+}
+
+func (c *eventClass) LookupVars(this *mruby.MrbValue) (*eventClassInstanceVars, error) {
+	o, err := c.objects.lookup(this)
+	if err != nil {
+		return nil, err
+	}
+	return o.(*eventClassInstance).vars, nil
+}
+
+// object returns the resource object, so that it can be looked up without knowing the class
+func (o *eventClassInstance) object() interface{} {
+	return &o.vars.event
+}
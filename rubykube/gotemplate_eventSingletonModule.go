@@ -0,0 +1,107 @@
+package rubykube
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	mruby "github.com/mitchellh/go-mruby"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/watch"
+)
+
+// template type RubyKubeClass(parentClass, classNameString, instanceVariableName, instanceVariableType)
+
+type eventSingletonModule struct{}
+
+func (c *eventClass) defineSingletonMethods() {
+	c.rk.appendMethods(c.class, map[string]methodDefintion{
+		"get!": {
+			mruby.ArgsReq(0), func(m *mruby.Mrb, self *mruby.MrbValue) (mruby.Value, mruby.Value) {
+				vars, err := c.LookupVars(self)
+				if err != nil {
+					return nil, createError(m, err)
+				}
+
+				meta := vars.event.ObjectMeta
+				event, err := c.getSingleton(meta.Namespace, meta.Name)
+				if err != nil {
+					return nil, createError(m, err)
+				}
+				vars.event = eventTypeAlias(*event)
+				return self, nil
+			},
+			instanceMethod,
+		},
+		"to_s": {
+			mruby.ArgsReq(0), func(m *mruby.Mrb, self *mruby.MrbValue) (mruby.Value, mruby.Value) {
+				vars, err := c.LookupVars(self)
+				if err != nil {
+					return nil, createError(m, err)
+				}
+
+				return m.StringValue(objectName(vars.event.ObjectMeta)), nil
+			},
+			instanceMethod,
+		},
+		"inspect": {
+			mruby.ArgsReq(0), func(m *mruby.Mrb, self *mruby.MrbValue) (mruby.Value, mruby.Value) {
+				vars, err := c.LookupVars(self)
+				if err != nil {
+					return nil, createError(m, err)
+				}
+
+				return m.StringValue(fmt.Sprintf("#<%s %s>", "Event", objectName(vars.event.ObjectMeta))), nil
+			},
+			instanceMethod,
+		},
+		"events": {
+			mruby.ArgsNone(), func(m *mruby.Mrb, self *mruby.MrbValue) (mruby.Value, mruby.Value) {
+				vars, err := c.LookupVars(self)
+				if err != nil {
+					return nil, createError(m, err)
+				}
+
+				newEventsObj, err := c.rk.objectEvents("Event", vars.event.ObjectMeta)
+				if err != nil {
+					return nil, createError(m, err)
+				}
+				return newEventsObj.self, nil
+			},
+			instanceMethod,
+		},
+		"wait_deleted": {
+			mruby.ArgsOpt(1), func(m *mruby.Mrb, self *mruby.MrbValue) (mruby.Value, mruby.Value) {
+				vars, err := c.LookupVars(self)
+				if err != nil {
+					return nil, createError(m, err)
+				}
+
+				timeout, err := waitTimeout(m.GetArgs())
+				if err != nil {
+					return nil, createError(m, err)
+				}
+
+				if _, err := c.waitFor(vars.event.ObjectMeta, "to be deleted", timeout, objectDeleted); err != nil {
+					return nil, createError(m, err)
+				}
+				return self, nil
+			},
+			instanceMethod,
+		},
+	})
+}
+
+// waitFor watches the object until condition is met, see `waitForObject`
+func (c *eventClass) waitFor(meta metav1.ObjectMeta, what string, timeout time.Duration, condition waitCondition) (runtime.Object, error) {
+	ns := c.rk.GetDefaultNamespace(meta.Namespace)
+	what = fmt.Sprintf("%s %s/%s %s", strings.ToLower("Event"), ns, meta.Name, what)
+
+	return c.rk.waitForObject(what, meta.Name, timeout,
+		func() (runtime.Object, error) { return c.getSingleton(ns, meta.Name) },
+		func(listOptions metav1.ListOptions) (watch.Interface, error) {
+			return c.watchSingleton(ns, listOptions)
+		},
+		condition)
+}
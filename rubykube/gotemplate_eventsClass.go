@@ -0,0 +1,101 @@
+package rubykube
+
+import (
+	"github.com/errordeveloper/kubeplay/rubykube/converter"
+	mruby "github.com/mitchellh/go-mruby"
+)
+
+// template type RubyKubeClass(classNameString, instanceVariableName, instanceVariableType)
+
+type eventsClass struct {
+	class   *mruby.Class
+	objects *instanceRegistry
+	rk      *RubyKube
+}
+
+type eventsClassInstance struct {
+	self *mruby.MrbValue
+	vars *eventsClassInstanceVars
+}
+
+type eventsClassInstanceVars struct {
+	events eventListTypeAlias
+	// query is what a list was fetched with by `get!`, it's nil for singletons and lists made in other ways
+	query *listQuery
+}
+
+func newEventsClass(rk *RubyKube) *eventsClass {
+	c := &eventsClass{objects: rk.newInstanceRegistry("Events"), rk: rk}
+	c.class = defineEventsClass(rk, c)
+	return c
+}
+
+func defineEventsClass(rk *RubyKube, c *eventsClass) *mruby.Class {
+	// common methods
+	return rk.defineClass("Events", map[string]methodDefintion{
+		"to_ruby": {
+			mruby.ArgsReq(0), func(m *mruby.Mrb, self *mruby.MrbValue) (mruby.Value, mruby.Value) {
+				vars, err := c.LookupVars(self)
+				if err != nil {
+					return nil, createError(m, err)
+				}
+
+				rbconv := converter.New(m)
+				if err := rbconv.Convert(vars.events); err != nil {
+					return nil, createError(m, err)
+				}
+
+				return rbconv.Value(), nil
+			},
+			instanceMethod,
+		},
+		"to_json": {
+			mruby.ArgsReq(0), func(m *mruby.Mrb, self *mruby.MrbValue) (mruby.Value, mruby.Value) {
+				vars, err := c.LookupVars(self)
+				if err != nil {
+					return nil, createError(m, err)
+				}
+				return marshalToJSON(vars.events, m)
+			},
+			instanceMethod,
+		},
+		"jsonpath": {
+			mruby.ArgsReq(1), func(m *mruby.Mrb, self *mruby.MrbValue) (mruby.Value, mruby.Value) {
+				vars, err := c.LookupVars(self)
+				if err != nil {
+					return nil, createError(m, err)
+				}
+				return jsonPathMethod(m, &vars.events)
+			},
+			instanceMethod,
+		},
+	})
+}
+
+func (c *eventsClass) New() (*eventsClassInstance, error) {
+	s, err := c.class.New()
+	if err != nil {
+		return nil, err
+	}
+	o := &eventsClassInstance{
+		self: s,
+		vars: &eventsClassInstanceVars{
+			events: eventListTypeAlias{},
+		},
+	}
+	c.objects.add(s, o)
+	return o, nil
+}
+
+func (c *eventsClass) LookupVars(this *mruby.MrbValue) (*eventsClassInstanceVars, error) {
+	o, err := c.objects.lookup(this)
+	if err != nil {
+		return nil, err
+	}
+	return o.(*eventsClassInstance).vars, nil
+}
+
+// object returns the resource object, so that it can be looked up without knowing the class
+func (o *eventsClassInstance) object() interface{} {
+	return &o.vars.events
+}
@@ -0,0 +1,321 @@
+package rubykube
+
+import (
+	"fmt"
+	"math/rand"
+	"strings"
+
+	mruby "github.com/mitchellh/go-mruby"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// template type RubyKubeClass(parentClass, classNameString, instanceVariableName, instanceVariableType)
+
+type eventsListModule struct{}
+
+// listNames returns `<namespace>/<name>` of all objects in given namespace, it is used for tab completion
+func (c *eventsClass) listNames(ns string) ([]string, error) {
+	events, err := c.getList(ns, metav1.ListOptions{})
+	if err != nil {
+		return nil, err
+	}
+
+	names := []string{}
+	for _, item := range events.Items {
+		names = append(names, item.ObjectMeta.Namespace+"/"+item.ObjectMeta.Name)
+	}
+	return names, nil
+}
+
+// getQuery lists objects that match any of the queries and the selectors, it is used by `get!`,
+// and to list current state of objects in a snapshot
+func (c *eventsClass) getQuery(queries []resourceQuery, selectors *resourceSelectors) (*eventListTypeAlias, error) {
+	// set-valued field selectors expand into multiple queries, and those
+	// not supported by API server are evaluated on the client
+	listOptions, clientFields := selectors.listOptions("Events")
+
+	// each namespace is listed only once, as multiple globs may refer to the same one,
+	// and lists are fetched concurrently
+	type listRequest struct {
+		ns          string
+		listOptions metav1.ListOptions
+	}
+	requests := []listRequest{}
+	requested := map[string]int{}
+	for _, q := range queries {
+		ns := c.rk.GetNamespace(q.namespace)
+		for _, lo := range listOptions {
+			if _, ok := requested[ns+"?"+lo.FieldSelector]; !ok {
+				requested[ns+"?"+lo.FieldSelector] = len(requests)
+				requests = append(requests, listRequest{ns, lo})
+			}
+		}
+	}
+
+	lists := make([]*eventListTypeAlias, len(requests))
+	if err := c.rk.parallel(len(requests), func(i int) error {
+		list, err := c.getList(requests[i].ns, requests[i].listOptions)
+		if err != nil {
+			return err
+		}
+		lists[i] = (*eventListTypeAlias)(list)
+		return nil
+	}); err != nil {
+		return nil, err
+	}
+
+	seen := map[string]bool{}
+
+	result := &eventListTypeAlias{}
+	for _, q := range queries {
+		ns := c.rk.GetNamespace(q.namespace)
+		for _, lo := range listOptions {
+			events := lists[requested[ns+"?"+lo.FieldSelector]]
+			result.TypeMeta = events.TypeMeta
+			result.ListMeta = events.ListMeta
+
+			for i, item := range events.Items {
+				key := item.ObjectMeta.Namespace + "/" + item.ObjectMeta.Name
+				if seen[key] || !q.match(item.ObjectMeta.Namespace, item.ObjectMeta.Name) {
+					continue
+				}
+				ok, err := matchFieldExpressions(&events.Items[i], clientFields)
+				if err != nil {
+					return nil, err
+				}
+				if !ok {
+					continue
+				}
+				seen[key] = true
+				result.Items = append(result.Items, item)
+			}
+		}
+	}
+	return result, nil
+}
+
+func (c *eventsClass) defineListMethods() {
+	c.rk.appendMethods(c.class, map[string]methodDefintion{
+		"get!": {
+			mruby.ArgsReq(0) | mruby.ArgsOpt(2), func(m *mruby.Mrb, self *mruby.MrbValue) (mruby.Value, mruby.Value) {
+				vars, err := c.LookupVars(self)
+				if err != nil {
+					return nil, createError(m, err)
+				}
+
+				queries, selectors, err := c.rk.resourceArgs(m.GetArgs())
+				if err != nil {
+					return nil, createError(m, err)
+				}
+
+				events, err := c.getQuery(queries, selectors)
+				if err != nil {
+					return nil, createError(m, err)
+				}
+				vars.events = *events
+				vars.query = c.rk.newListQuery(queries, selectors)
+				return self, nil
+			},
+			instanceMethod,
+		},
+		"to_s": {
+			mruby.ArgsReq(0), func(m *mruby.Mrb, self *mruby.MrbValue) (mruby.Value, mruby.Value) {
+				vars, err := c.LookupVars(self)
+				if err != nil {
+					return nil, createError(m, err)
+				}
+
+				names := []string{}
+				for _, item := range vars.events.Items {
+					names = append(names, objectName(item.ObjectMeta))
+				}
+				return m.StringValue(strings.Join(names, "\n")), nil
+			},
+			instanceMethod,
+		},
+		"inspect": {
+			mruby.ArgsReq(0), func(m *mruby.Mrb, self *mruby.MrbValue) (mruby.Value, mruby.Value) {
+				vars, err := c.LookupVars(self)
+				if err != nil {
+					return nil, createError(m, err)
+				}
+
+				lines := []string{fmt.Sprintf("#<%s count=%d>", "Events", len(vars.events.Items))}
+				for n, item := range vars.events.Items {
+					lines = append(lines, fmt.Sprintf("%d: %s", n, objectName(item.ObjectMeta)))
+				}
+				return m.StringValue(strings.Join(lines, "\n")), nil
+			},
+			instanceMethod,
+		},
+		"count": {
+			mruby.ArgsReq(0), func(m *mruby.Mrb, self *mruby.MrbValue) (mruby.Value, mruby.Value) {
+				vars, err := c.LookupVars(self)
+				if err != nil {
+					return nil, createError(m, err)
+				}
+
+				return m.FixnumValue(len(vars.events.Items)), nil
+			},
+			instanceMethod,
+		},
+		"pluck": {
+			mruby.ArgsReq(1), func(m *mruby.Mrb, self *mruby.MrbValue) (mruby.Value, mruby.Value) {
+				vars, err := c.LookupVars(self)
+				if err != nil {
+					return nil, createError(m, err)
+				}
+
+				args := m.GetArgs()
+				if err := standardCheck(c.rk, args, 1); err != nil {
+					return nil, createError(m, err)
+				}
+
+				array, err := newArrayOf(m, len(vars.events.Items), func(i int) (mruby.Value, error) {
+					return pluckValue(m, &vars.events.Items[i], args[0].String())
+				})
+				if err != nil {
+					return nil, createError(m, err)
+				}
+				return array, nil
+			},
+			instanceMethod,
+		},
+		"where": {
+			mruby.ArgsReq(1), func(m *mruby.Mrb, self *mruby.MrbValue) (mruby.Value, mruby.Value) {
+				vars, err := c.LookupVars(self)
+				if err != nil {
+					return nil, createError(m, err)
+				}
+
+				args := m.GetArgs()
+				if err := standardCheck(c.rk, args, 1); err != nil {
+					return nil, createError(m, err)
+				}
+
+				conditions, err := newWhereConditions(args[0])
+				if err != nil {
+					return nil, createError(m, err)
+				}
+
+				newListObj, err := c.New()
+				if err != nil {
+					return nil, createError(m, err)
+				}
+
+				newListObj.vars.events = vars.events
+				newListObj.vars.events.Items = nil
+				for i, item := range vars.events.Items {
+					ok, err := matchWhereConditions(&vars.events.Items[i], conditions)
+					if err != nil {
+						return nil, createError(m, err)
+					}
+					if ok {
+						newListObj.vars.events.Items = append(newListObj.vars.events.Items, item)
+					}
+				}
+				return newListObj.self, nil
+			},
+			instanceMethod,
+		},
+		"[]": {
+			mruby.ArgsReq(1), func(m *mruby.Mrb, self *mruby.MrbValue) (mruby.Value, mruby.Value) {
+				vars, err := c.LookupVars(self)
+				if err != nil {
+					return nil, createError(m, err)
+				}
+
+				args := m.GetArgs()
+				err = standardCheck(c.rk, args, 1)
+				if err != nil {
+					return nil, createError(m, err)
+				}
+				n := args[0]
+				if n.Type() != mruby.TypeFixnum {
+					return nil, createException(m, "Argument must be an integer")
+				}
+
+				l := len(vars.events.Items)
+				i := n.Fixnum()
+
+				if i >= l {
+					return nil, nil
+				}
+
+				if i < 0 {
+					// handle negative index in the way Ruby does it, i.e. no infinit wrapping
+					if -i <= l {
+						i %= l
+						i *= -1 // in Go, unlike Ruby this needs to be converted to positive value
+					} else {
+						return nil, nil
+					}
+				}
+
+				obj, err := c.getItem(vars.events, i)
+				if err != nil {
+					return nil, createError(m, err)
+				}
+				return obj.self, nil
+			},
+			instanceMethod,
+		},
+		"first": {
+			mruby.ArgsNone(), func(m *mruby.Mrb, self *mruby.MrbValue) (mruby.Value, mruby.Value) {
+				vars, err := c.LookupVars(self)
+				if err != nil {
+					return nil, createError(m, err)
+				}
+
+				if len(vars.events.Items) > 0 {
+					obj, err := c.getItem(vars.events, 0)
+					if err != nil {
+						return nil, createError(m, err)
+					}
+					return obj.self, nil
+				}
+				return nil, nil
+			},
+			instanceMethod,
+		},
+		"any": {
+			mruby.ArgsNone(), func(m *mruby.Mrb, self *mruby.MrbValue) (mruby.Value, mruby.Value) {
+				vars, err := c.LookupVars(self)
+				if err != nil {
+					return nil, createError(m, err)
+				}
+
+				l := len(vars.events.Items)
+				if l > 0 {
+					obj, err := c.getItem(vars.events, rand.Intn(l))
+					if err != nil {
+						return nil, createError(m, err)
+					}
+					return obj.self, nil
+				}
+				return nil, nil
+			},
+			instanceMethod,
+		},
+		"last": {
+			mruby.ArgsNone(), func(m *mruby.Mrb, self *mruby.MrbValue) (mruby.Value, mruby.Value) {
+				vars, err := c.LookupVars(self)
+				if err != nil {
+					return nil, createError(m, err)
+				}
+
+				l := len(vars.events.Items)
+
+				if l > 0 {
+					obj, err := c.getItem(vars.events, l-1)
+					if err != nil {
+						return nil, createError(m, err)
+					}
+					return obj.self, nil
+				}
+				return nil, nil
+			},
+			instanceMethod,
+		},
+	})
+}
@@ -1,8 +1,6 @@
 package rubykube
 
 import (
-	"fmt"
-
 	mruby "github.com/mitchellh/go-mruby"
 )
 
@@ -10,7 +8,7 @@ import (
 
 type fieldCollectorClass struct {
 	class   *mruby.Class
-	objects []fieldCollectorClassInstance
+	objects *instanceRegistry
 	rk      *RubyKube
 }
 
@@ -20,7 +18,7 @@ type fieldCollectorClassInstance struct {
 }
 
 func newFieldCollectorClass(rk *RubyKube) *fieldCollectorClass {
-	c := &fieldCollectorClass{objects: []fieldCollectorClassInstance{}, rk: rk}
+	c := &fieldCollectorClass{objects: rk.newInstanceRegistry("FieldCollector"), rk: rk}
 	c.class = defineFieldCollectorClass(rk, c)
 	return c
 }
@@ -28,11 +26,11 @@ func newFieldCollectorClass(rk *RubyKube) *fieldCollectorClass {
 func defineFieldCollectorClass(rk *RubyKube, c *fieldCollectorClass) *mruby.Class {
 	// common methods
 	return rk.defineClass("FieldCollector", map[string]methodDefintion{
-		"object_count": {
-			mruby.ArgsNone(), func(m *mruby.Mrb, self *mruby.MrbValue) (mruby.Value, mruby.Value) {
-				return m.FixnumValue(len(c.objects)), nil
+		"inspect": {
+			mruby.ArgsReq(0), func(m *mruby.Mrb, self *mruby.MrbValue) (mruby.Value, mruby.Value) {
+				return m.StringValue("#<" + "FieldCollector" + ">"), nil
 			},
-			classMethod,
+			instanceMethod,
 		},
 	})
 }
@@ -48,19 +46,18 @@ func (c *fieldCollectorClass) New(args ...mruby.Value) (*fieldCollectorClassInst
 		return nil, err
 	}
 
-	o := fieldCollectorClassInstance{
+	o := &fieldCollectorClassInstance{
 		self: s,
 		vars: v,
 	}
-	c.objects = append(c.objects, o)
-	return &o, nil
+	c.objects.add(s, o)
+	return o, nil
 }
 
 func (c *fieldCollectorClass) LookupVars(this *mruby.MrbValue) (*fieldCollectorClassInstanceVars, error) {
-	for _, that := range c.objects {
-		if *this == *that.self {
-			return that.vars, nil
-		}
+	o, err := c.objects.lookup(this)
+	if err != nil {
+		return nil, err
 	}
-	return nil, fmt.Errorf("%s: could not find class instance", "FieldCollector")
+	return o.(*fieldCollectorClassInstance).vars, nil
 }
@@ -1,8 +1,6 @@
 package rubykube
 
 import (
-	"fmt"
-
 	mruby "github.com/mitchellh/go-mruby"
 )
 
@@ -10,7 +8,7 @@ import (
 
 type fieldKeyClass struct {
 	class   *mruby.Class
-	objects []fieldKeyClassInstance
+	objects *instanceRegistry
 	rk      *RubyKube
 }
 
@@ -20,7 +18,7 @@ type fieldKeyClassInstance struct {
 }
 
 func newFieldKeyClass(rk *RubyKube) *fieldKeyClass {
-	c := &fieldKeyClass{objects: []fieldKeyClassInstance{}, rk: rk}
+	c := &fieldKeyClass{objects: rk.newInstanceRegistry("FieldKey"), rk: rk}
 	c.class = defineFieldKeyClass(rk, c)
 	return c
 }
@@ -28,11 +26,11 @@ func newFieldKeyClass(rk *RubyKube) *fieldKeyClass {
 func defineFieldKeyClass(rk *RubyKube, c *fieldKeyClass) *mruby.Class {
 	// common methods
 	return rk.defineClass("FieldKey", map[string]methodDefintion{
-		"object_count": {
-			mruby.ArgsNone(), func(m *mruby.Mrb, self *mruby.MrbValue) (mruby.Value, mruby.Value) {
-				return m.FixnumValue(len(c.objects)), nil
+		"inspect": {
+			mruby.ArgsReq(0), func(m *mruby.Mrb, self *mruby.MrbValue) (mruby.Value, mruby.Value) {
+				return m.StringValue("#<" + "FieldKey" + ">"), nil
 			},
-			classMethod,
+			instanceMethod,
 		},
 	})
 }
@@ -48,19 +46,18 @@ func (c *fieldKeyClass) New(args ...mruby.Value) (*fieldKeyClassInstance, error)
 		return nil, err
 	}
 
-	o := fieldKeyClassInstance{
+	o := &fieldKeyClassInstance{
 		self: s,
 		vars: v,
 	}
-	c.objects = append(c.objects, o)
-	return &o, nil
+	c.objects.add(s, o)
+	return o, nil
 }
 
 func (c *fieldKeyClass) LookupVars(this *mruby.MrbValue) (*fieldKeyClassInstanceVars, error) {
-	for _, that := range c.objects {
-		if *this == *that.self {
-			return that.vars, nil
-		}
+	o, err := c.objects.lookup(this)
+	if err != nil {
+		return nil, err
 	}
-	return nil, fmt.Errorf("%s: could not find class instance", "FieldKey")
+	return o.(*fieldKeyClassInstance).vars, nil
 }
@@ -1,8 +1,6 @@
 package rubykube
 
 import (
-	"fmt"
-
 	mruby "github.com/mitchellh/go-mruby"
 )
 
@@ -10,7 +8,7 @@ import (
 
 type fieldSelectorClass struct {
 	class   *mruby.Class
-	objects []fieldSelectorClassInstance
+	objects *instanceRegistry
 	rk      *RubyKube
 }
 
@@ -20,7 +18,7 @@ type fieldSelectorClassInstance struct {
 }
 
 func newFieldSelectorClass(rk *RubyKube) *fieldSelectorClass {
-	c := &fieldSelectorClass{objects: []fieldSelectorClassInstance{}, rk: rk}
+	c := &fieldSelectorClass{objects: rk.newInstanceRegistry("FieldSelector"), rk: rk}
 	c.class = defineFieldSelectorClass(rk, c)
 	return c
 }
@@ -28,11 +26,11 @@ func newFieldSelectorClass(rk *RubyKube) *fieldSelectorClass {
 func defineFieldSelectorClass(rk *RubyKube, c *fieldSelectorClass) *mruby.Class {
 	// common methods
 	return rk.defineClass("FieldSelector", map[string]methodDefintion{
-		"object_count": {
-			mruby.ArgsNone(), func(m *mruby.Mrb, self *mruby.MrbValue) (mruby.Value, mruby.Value) {
-				return m.FixnumValue(len(c.objects)), nil
+		"inspect": {
+			mruby.ArgsReq(0), func(m *mruby.Mrb, self *mruby.MrbValue) (mruby.Value, mruby.Value) {
+				return m.StringValue("#<" + "FieldSelector" + ">"), nil
 			},
-			classMethod,
+			instanceMethod,
 		},
 	})
 }
@@ -48,19 +46,18 @@ func (c *fieldSelectorClass) New(args ...mruby.Value) (*fieldSelectorClassInstan
 		return nil, err
 	}
 
-	o := fieldSelectorClassInstance{
+	o := &fieldSelectorClassInstance{
 		self: s,
 		vars: v,
 	}
-	c.objects = append(c.objects, o)
-	return &o, nil
+	c.objects.add(s, o)
+	return o, nil
 }
 
 func (c *fieldSelectorClass) LookupVars(this *mruby.MrbValue) (*fieldSelectorClassInstanceVars, error) {
-	for _, that := range c.objects {
-		if *this == *that.self {
-			return that.vars, nil
-		}
+	o, err := c.objects.lookup(this)
+	if err != nil {
+		return nil, err
 	}
-	return nil, fmt.Errorf("%s: could not find class instance", "FieldSelector")
+	return o.(*fieldSelectorClassInstance).vars, nil
 }
@@ -0,0 +1,63 @@
+package rubykube
+
+import (
+	mruby "github.com/mitchellh/go-mruby"
+)
+
+// template type RubyKubeClass(classNameString, newClassInstanceVars, classInstanceVarsType)
+
+type imageInventoryClass struct {
+	class   *mruby.Class
+	objects *instanceRegistry
+	rk      *RubyKube
+}
+
+type imageInventoryClassInstance struct {
+	self *mruby.MrbValue
+	vars *imageInventoryClassInstanceVars
+}
+
+func newImageInventoryClass(rk *RubyKube) *imageInventoryClass {
+	c := &imageInventoryClass{objects: rk.newInstanceRegistry("ImageInventory"), rk: rk}
+	c.class = defineImageInventoryClass(rk, c)
+	return c
+}
+
+func defineImageInventoryClass(rk *RubyKube, c *imageInventoryClass) *mruby.Class {
+	// common methods
+	return rk.defineClass("ImageInventory", map[string]methodDefintion{
+		"inspect": {
+			mruby.ArgsReq(0), func(m *mruby.Mrb, self *mruby.MrbValue) (mruby.Value, mruby.Value) {
+				return m.StringValue("#<" + "ImageInventory" + ">"), nil
+			},
+			instanceMethod,
+		},
+	})
+}
+
+func (c *imageInventoryClass) New(args ...mruby.Value) (*imageInventoryClassInstance, error) {
+	s, err := c.class.New()
+	if err != nil {
+		return nil, err
+	}
+
+	v, err := newImageInventoryClassInstanceVars(c, s, args...)
+	if err != nil {
+		return nil, err
+	}
+
+	o := &imageInventoryClassInstance{
+		self: s,
+		vars: v,
+	}
+	c.objects.add(s, o)
+	return o, nil
+}
+
+func (c *imageInventoryClass) LookupVars(this *mruby.MrbValue) (*imageInventoryClassInstanceVars, error) {
+	o, err := c.objects.lookup(this)
+	if err != nil {
+		return nil, err
+	}
+	return o.(*imageInventoryClassInstance).vars, nil
+}
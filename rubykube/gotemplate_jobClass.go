@@ -0,0 +1,101 @@
+package rubykube
+
+import (
+	"github.com/errordeveloper/kubeplay/rubykube/converter"
+	mruby "github.com/mitchellh/go-mruby"
+)
+
+// template type RubyKubeClass(classNameString, instanceVariableName, instanceVariableType)
+
+type jobClass struct {
+	class   *mruby.Class
+	objects *instanceRegistry
+	rk      *RubyKube
+}
+
+type jobClassInstance struct {
+	self *mruby.MrbValue
+	vars *jobClassInstanceVars
+}
+
+type jobClassInstanceVars struct {
+	job jobTypeAlias
+	// query is what a list was fetched with by `get!`, it's nil for singletons and lists made in other ways
+	query *listQuery
+}
+
+func newJobClass(rk *RubyKube) *jobClass {
+	c := &jobClass{objects: rk.newInstanceRegistry("Job"), rk: rk}
+	c.class = defineJobClass(rk, c)
+	return c
+}
+
+func defineJobClass(rk *RubyKube, c *jobClass) *mruby.Class {
+	// common methods
+	return rk.defineClass("Job", map[string]methodDefintion{
+		"to_ruby": {
+			mruby.ArgsReq(0), func(m *mruby.Mrb, self *mruby.MrbValue) (mruby.Value, mruby.Value) {
+				vars, err := c.LookupVars(self)
+				if err != nil {
+					return nil, createError(m, err)
+				}
+
+				rbconv := converter.New(m)
+				if err := rbconv.Convert(vars.job); err != nil {
+					return nil, createError(m, err)
+				}
+
+				return rbconv.Value(), nil
+			},
+			instanceMethod,
+		},
+		"to_json": {
+			mruby.ArgsReq(0), func(m *mruby.Mrb, self *mruby.MrbValue) (mruby.Value, mruby.Value) {
+				vars, err := c.LookupVars(self)
+				if err != nil {
+					return nil, createError(m, err)
+				}
+				return marshalToJSON(vars.job, m)
+			},
+			instanceMethod,
+		},
+		"jsonpath": {
+			mruby.ArgsReq(1), func(m *mruby.Mrb, self *mruby.MrbValue) (mruby.Value, mruby.Value) {
+				vars, err := c.LookupVars(self)
+				if err != nil {
+					return nil, createError(m, err)
+				}
+				return jsonPathMethod(m, &vars.job)
+			},
+			instanceMethod,
+		},
+	})
+}
+
+func (c *jobClass) New() (*jobClassInstance, error) {
+	s, err := c.class.New()
+	if err != nil {
+		return nil, err
+	}
+	o := &jobClassInstance{
+		self: s,
+		vars: &jobClassInstanceVars{
+			job: jobTypeAlias{},
+		},
+	}
+	c.objects.add(s, o)
+	return o, nil
+}
+
+func (c *jobClass) LookupVars(this *mruby.MrbValue) (*jobClassInstanceVars, error) {
+	o, err := c.objects.lookup(this)
+	if err != nil {
+		return nil, err
+	}
+	return o.(*jobClassInstance).vars, nil
+}
+
+// object returns the resource object, so that it can be looked up without knowing the class
+func (o *jobClassInstance) object() interface{} {
+	return &o.vars.job
+}
@@ -0,0 +1,107 @@
+package rubykube
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	mruby "github.com/mitchellh/go-mruby"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/watch"
+)
+
+// template type RubyKubeClass(parentClass, classNameString, instanceVariableName, instanceVariableType)
+
+type jobSingletonModule struct{}
+
+func (c *jobClass) defineSingletonMethods() {
+	c.rk.appendMethods(c.class, map[string]methodDefintion{
+		"get!": {
+			mruby.ArgsReq(0), func(m *mruby.Mrb, self *mruby.MrbValue) (mruby.Value, mruby.Value) {
+				vars, err := c.LookupVars(self)
+				if err != nil {
+					return nil, createError(m, err)
+				}
+
+				meta := vars.job.ObjectMeta
+				job, err := c.getSingleton(meta.Namespace, meta.Name)
+				if err != nil {
+					return nil, createError(m, err)
+				}
+				vars.job = jobTypeAlias(*job)
+				return self, nil
+			},
+			instanceMethod,
+		},
+		"to_s": {
+			mruby.ArgsReq(0), func(m *mruby.Mrb, self *mruby.MrbValue) (mruby.Value, mruby.Value) {
+				vars, err := c.LookupVars(self)
+				if err != nil {
+					return nil, createError(m, err)
+				}
+
+				return m.StringValue(objectName(vars.job.ObjectMeta)), nil
+			},
+			instanceMethod,
+		},
+		"inspect": {
+			mruby.ArgsReq(0), func(m *mruby.Mrb, self *mruby.MrbValue) (mruby.Value, mruby.Value) {
+				vars, err := c.LookupVars(self)
+				if err != nil {
+					return nil, createError(m, err)
+				}
+
+				return m.StringValue(fmt.Sprintf("#<%s %s>", "Job", objectName(vars.job.ObjectMeta))), nil
+			},
+			instanceMethod,
+		},
+		"events": {
+			mruby.ArgsNone(), func(m *mruby.Mrb, self *mruby.MrbValue) (mruby.Value, mruby.Value) {
+				vars, err := c.LookupVars(self)
+				if err != nil {
+					return nil, createError(m, err)
+				}
+
+				newEventsObj, err := c.rk.objectEvents("Job", vars.job.ObjectMeta)
+				if err != nil {
+					return nil, createError(m, err)
+				}
+				return newEventsObj.self, nil
+			},
+			instanceMethod,
+		},
+		"wait_deleted": {
+			mruby.ArgsOpt(1), func(m *mruby.Mrb, self *mruby.MrbValue) (mruby.Value, mruby.Value) {
+				vars, err := c.LookupVars(self)
+				if err != nil {
+					return nil, createError(m, err)
+				}
+
+				timeout, err := waitTimeout(m.GetArgs())
+				if err != nil {
+					return nil, createError(m, err)
+				}
+
+				if _, err := c.waitFor(vars.job.ObjectMeta, "to be deleted", timeout, objectDeleted); err != nil {
+					return nil, createError(m, err)
+				}
+				return self, nil
+			},
+			instanceMethod,
+		},
+	})
+}
+
+// waitFor watches the object until condition is met, see `waitForObject`
+func (c *jobClass) waitFor(meta metav1.ObjectMeta, what string, timeout time.Duration, condition waitCondition) (runtime.Object, error) {
+	ns := c.rk.GetDefaultNamespace(meta.Namespace)
+	what = fmt.Sprintf("%s %s/%s %s", strings.ToLower("Job"), ns, meta.Name, what)
+
+	return c.rk.waitForObject(what, meta.Name, timeout,
+		func() (runtime.Object, error) { return c.getSingleton(ns, meta.Name) },
+		func(listOptions metav1.ListOptions) (watch.Interface, error) {
+			return c.watchSingleton(ns, listOptions)
+		},
+		condition)
+}
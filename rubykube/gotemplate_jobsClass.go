@@ -0,0 +1,101 @@
+package rubykube
+
+import (
+	"github.com/errordeveloper/kubeplay/rubykube/converter"
+	mruby "github.com/mitchellh/go-mruby"
+)
+
+// template type RubyKubeClass(classNameString, instanceVariableName, instanceVariableType)
+
+type jobsClass struct {
+	class   *mruby.Class
+	objects *instanceRegistry
+	rk      *RubyKube
+}
+
+type jobsClassInstance struct {
+	self *mruby.MrbValue
+	vars *jobsClassInstanceVars
+}
+
+type jobsClassInstanceVars struct {
+	jobs jobListTypeAlias
+	// query is what a list was fetched with by `get!`, it's nil for singletons and lists made in other ways
+	query *listQuery
+}
+
+func newJobsClass(rk *RubyKube) *jobsClass {
+	c := &jobsClass{objects: rk.newInstanceRegistry("Jobs"), rk: rk}
+	c.class = defineJobsClass(rk, c)
+	return c
+}
+
+func defineJobsClass(rk *RubyKube, c *jobsClass) *mruby.Class {
+	// common methods
+	return rk.defineClass("Jobs", map[string]methodDefintion{
+		"to_ruby": {
+			mruby.ArgsReq(0), func(m *mruby.Mrb, self *mruby.MrbValue) (mruby.Value, mruby.Value) {
+				vars, err := c.LookupVars(self)
+				if err != nil {
+					return nil, createError(m, err)
+				}
+
+				rbconv := converter.New(m)
+				if err := rbconv.Convert(vars.jobs); err != nil {
+					return nil, createError(m, err)
+				}
+
+				return rbconv.Value(), nil
+			},
+			instanceMethod,
+		},
+		"to_json": {
+			mruby.ArgsReq(0), func(m *mruby.Mrb, self *mruby.MrbValue) (mruby.Value, mruby.Value) {
+				vars, err := c.LookupVars(self)
+				if err != nil {
+					return nil, createError(m, err)
+				}
+				return marshalToJSON(vars.jobs, m)
+			},
+			instanceMethod,
+		},
+		"jsonpath": {
+			mruby.ArgsReq(1), func(m *mruby.Mrb, self *mruby.MrbValue) (mruby.Value, mruby.Value) {
+				vars, err := c.LookupVars(self)
+				if err != nil {
+					return nil, createError(m, err)
+				}
+				return jsonPathMethod(m, &vars.jobs)
+			},
+			instanceMethod,
+		},
+	})
+}
+
+func (c *jobsClass) New() (*jobsClassInstance, error) {
+	s, err := c.class.New()
+	if err != nil {
+		return nil, err
+	}
+	o := &jobsClassInstance{
+		self: s,
+		vars: &jobsClassInstanceVars{
+			jobs: jobListTypeAlias{},
+		},
+	}
+	c.objects.add(s, o)
+	return o, nil
+}
+
+func (c *jobsClass) LookupVars(this *mruby.MrbValue) (*jobsClassInstanceVars, error) {
+	o, err := c.objects.lookup(this)
+	if err != nil {
+		return nil, err
+	}
+	return o.(*jobsClassInstance).vars, nil
+}
+
+// object returns the resource object, so that it can be looked up without knowing the class
+func (o *jobsClassInstance) object() interface{} {
+	return &o.vars.jobs
+}
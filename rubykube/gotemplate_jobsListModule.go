@@ -0,0 +1,321 @@
+package rubykube
+
+import (
+	"fmt"
+	"math/rand"
+	"strings"
+
+	mruby "github.com/mitchellh/go-mruby"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// template type RubyKubeClass(parentClass, classNameString, instanceVariableName, instanceVariableType)
+
+type jobsListModule struct{}
+
+// listNames returns `<namespace>/<name>` of all objects in given namespace, it is used for tab completion
+func (c *jobsClass) listNames(ns string) ([]string, error) {
+	jobs, err := c.getList(ns, metav1.ListOptions{})
+	if err != nil {
+		return nil, err
+	}
+
+	names := []string{}
+	for _, item := range jobs.Items {
+		names = append(names, item.ObjectMeta.Namespace+"/"+item.ObjectMeta.Name)
+	}
+	return names, nil
+}
+
+// getQuery lists objects that match any of the queries and the selectors, it is used by `get!`,
+// and to list current state of objects in a snapshot
+func (c *jobsClass) getQuery(queries []resourceQuery, selectors *resourceSelectors) (*jobListTypeAlias, error) {
+	// set-valued field selectors expand into multiple queries, and those
+	// not supported by API server are evaluated on the client
+	listOptions, clientFields := selectors.listOptions("Jobs")
+
+	// each namespace is listed only once, as multiple globs may refer to the same one,
+	// and lists are fetched concurrently
+	type listRequest struct {
+		ns          string
+		listOptions metav1.ListOptions
+	}
+	requests := []listRequest{}
+	requested := map[string]int{}
+	for _, q := range queries {
+		ns := c.rk.GetNamespace(q.namespace)
+		for _, lo := range listOptions {
+			if _, ok := requested[ns+"?"+lo.FieldSelector]; !ok {
+				requested[ns+"?"+lo.FieldSelector] = len(requests)
+				requests = append(requests, listRequest{ns, lo})
+			}
+		}
+	}
+
+	lists := make([]*jobListTypeAlias, len(requests))
+	if err := c.rk.parallel(len(requests), func(i int) error {
+		list, err := c.getList(requests[i].ns, requests[i].listOptions)
+		if err != nil {
+			return err
+		}
+		lists[i] = (*jobListTypeAlias)(list)
+		return nil
+	}); err != nil {
+		return nil, err
+	}
+
+	seen := map[string]bool{}
+
+	result := &jobListTypeAlias{}
+	for _, q := range queries {
+		ns := c.rk.GetNamespace(q.namespace)
+		for _, lo := range listOptions {
+			jobs := lists[requested[ns+"?"+lo.FieldSelector]]
+			result.TypeMeta = jobs.TypeMeta
+			result.ListMeta = jobs.ListMeta
+
+			for i, item := range jobs.Items {
+				key := item.ObjectMeta.Namespace + "/" + item.ObjectMeta.Name
+				if seen[key] || !q.match(item.ObjectMeta.Namespace, item.ObjectMeta.Name) {
+					continue
+				}
+				ok, err := matchFieldExpressions(&jobs.Items[i], clientFields)
+				if err != nil {
+					return nil, err
+				}
+				if !ok {
+					continue
+				}
+				seen[key] = true
+				result.Items = append(result.Items, item)
+			}
+		}
+	}
+	return result, nil
+}
+
+func (c *jobsClass) defineListMethods() {
+	c.rk.appendMethods(c.class, map[string]methodDefintion{
+		"get!": {
+			mruby.ArgsReq(0) | mruby.ArgsOpt(2), func(m *mruby.Mrb, self *mruby.MrbValue) (mruby.Value, mruby.Value) {
+				vars, err := c.LookupVars(self)
+				if err != nil {
+					return nil, createError(m, err)
+				}
+
+				queries, selectors, err := c.rk.resourceArgs(m.GetArgs())
+				if err != nil {
+					return nil, createError(m, err)
+				}
+
+				jobs, err := c.getQuery(queries, selectors)
+				if err != nil {
+					return nil, createError(m, err)
+				}
+				vars.jobs = *jobs
+				vars.query = c.rk.newListQuery(queries, selectors)
+				return self, nil
+			},
+			instanceMethod,
+		},
+		"to_s": {
+			mruby.ArgsReq(0), func(m *mruby.Mrb, self *mruby.MrbValue) (mruby.Value, mruby.Value) {
+				vars, err := c.LookupVars(self)
+				if err != nil {
+					return nil, createError(m, err)
+				}
+
+				names := []string{}
+				for _, item := range vars.jobs.Items {
+					names = append(names, objectName(item.ObjectMeta))
+				}
+				return m.StringValue(strings.Join(names, "\n")), nil
+			},
+			instanceMethod,
+		},
+		"inspect": {
+			mruby.ArgsReq(0), func(m *mruby.Mrb, self *mruby.MrbValue) (mruby.Value, mruby.Value) {
+				vars, err := c.LookupVars(self)
+				if err != nil {
+					return nil, createError(m, err)
+				}
+
+				lines := []string{fmt.Sprintf("#<%s count=%d>", "Jobs", len(vars.jobs.Items))}
+				for n, item := range vars.jobs.Items {
+					lines = append(lines, fmt.Sprintf("%d: %s", n, objectName(item.ObjectMeta)))
+				}
+				return m.StringValue(strings.Join(lines, "\n")), nil
+			},
+			instanceMethod,
+		},
+		"count": {
+			mruby.ArgsReq(0), func(m *mruby.Mrb, self *mruby.MrbValue) (mruby.Value, mruby.Value) {
+				vars, err := c.LookupVars(self)
+				if err != nil {
+					return nil, createError(m, err)
+				}
+
+				return m.FixnumValue(len(vars.jobs.Items)), nil
+			},
+			instanceMethod,
+		},
+		"pluck": {
+			mruby.ArgsReq(1), func(m *mruby.Mrb, self *mruby.MrbValue) (mruby.Value, mruby.Value) {
+				vars, err := c.LookupVars(self)
+				if err != nil {
+					return nil, createError(m, err)
+				}
+
+				args := m.GetArgs()
+				if err := standardCheck(c.rk, args, 1); err != nil {
+					return nil, createError(m, err)
+				}
+
+				array, err := newArrayOf(m, len(vars.jobs.Items), func(i int) (mruby.Value, error) {
+					return pluckValue(m, &vars.jobs.Items[i], args[0].String())
+				})
+				if err != nil {
+					return nil, createError(m, err)
+				}
+				return array, nil
+			},
+			instanceMethod,
+		},
+		"where": {
+			mruby.ArgsReq(1), func(m *mruby.Mrb, self *mruby.MrbValue) (mruby.Value, mruby.Value) {
+				vars, err := c.LookupVars(self)
+				if err != nil {
+					return nil, createError(m, err)
+				}
+
+				args := m.GetArgs()
+				if err := standardCheck(c.rk, args, 1); err != nil {
+					return nil, createError(m, err)
+				}
+
+				conditions, err := newWhereConditions(args[0])
+				if err != nil {
+					return nil, createError(m, err)
+				}
+
+				newListObj, err := c.New()
+				if err != nil {
+					return nil, createError(m, err)
+				}
+
+				newListObj.vars.jobs = vars.jobs
+				newListObj.vars.jobs.Items = nil
+				for i, item := range vars.jobs.Items {
+					ok, err := matchWhereConditions(&vars.jobs.Items[i], conditions)
+					if err != nil {
+						return nil, createError(m, err)
+					}
+					if ok {
+						newListObj.vars.jobs.Items = append(newListObj.vars.jobs.Items, item)
+					}
+				}
+				return newListObj.self, nil
+			},
+			instanceMethod,
+		},
+		"[]": {
+			mruby.ArgsReq(1), func(m *mruby.Mrb, self *mruby.MrbValue) (mruby.Value, mruby.Value) {
+				vars, err := c.LookupVars(self)
+				if err != nil {
+					return nil, createError(m, err)
+				}
+
+				args := m.GetArgs()
+				err = standardCheck(c.rk, args, 1)
+				if err != nil {
+					return nil, createError(m, err)
+				}
+				n := args[0]
+				if n.Type() != mruby.TypeFixnum {
+					return nil, createException(m, "Argument must be an integer")
+				}
+
+				l := len(vars.jobs.Items)
+				i := n.Fixnum()
+
+				if i >= l {
+					return nil, nil
+				}
+
+				if i < 0 {
+					// handle negative index in the way Ruby does it, i.e. no infinit wrapping
+					if -i <= l {
+						i %= l
+						i *= -1 // in Go, unlike Ruby this needs to be converted to positive value
+					} else {
+						return nil, nil
+					}
+				}
+
+				obj, err := c.getItem(vars.jobs, i)
+				if err != nil {
+					return nil, createError(m, err)
+				}
+				return obj.self, nil
+			},
+			instanceMethod,
+		},
+		"first": {
+			mruby.ArgsNone(), func(m *mruby.Mrb, self *mruby.MrbValue) (mruby.Value, mruby.Value) {
+				vars, err := c.LookupVars(self)
+				if err != nil {
+					return nil, createError(m, err)
+				}
+
+				if len(vars.jobs.Items) > 0 {
+					obj, err := c.getItem(vars.jobs, 0)
+					if err != nil {
+						return nil, createError(m, err)
+					}
+					return obj.self, nil
+				}
+				return nil, nil
+			},
+			instanceMethod,
+		},
+		"any": {
+			mruby.ArgsNone(), func(m *mruby.Mrb, self *mruby.MrbValue) (mruby.Value, mruby.Value) {
+				vars, err := c.LookupVars(self)
+				if err != nil {
+					return nil, createError(m, err)
+				}
+
+				l := len(vars.jobs.Items)
+				if l > 0 {
+					obj, err := c.getItem(vars.jobs, rand.Intn(l))
+					if err != nil {
+						return nil, createError(m, err)
+					}
+					return obj.self, nil
+				}
+				return nil, nil
+			},
+			instanceMethod,
+		},
+		"last": {
+			mruby.ArgsNone(), func(m *mruby.Mrb, self *mruby.MrbValue) (mruby.Value, mruby.Value) {
+				vars, err := c.LookupVars(self)
+				if err != nil {
+					return nil, createError(m, err)
+				}
+
+				l := len(vars.jobs.Items)
+
+				if l > 0 {
+					obj, err := c.getItem(vars.jobs, l-1)
+					if err != nil {
+						return nil, createError(m, err)
+					}
+					return obj.self, nil
+				}
+				return nil, nil
+			},
+			instanceMethod,
+		},
+	})
+}
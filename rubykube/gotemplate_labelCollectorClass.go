@@ -1,8 +1,6 @@
 package rubykube
 
 import (
-	"fmt"
-
 	mruby "github.com/mitchellh/go-mruby"
 )
 
@@ -10,7 +8,7 @@ import (
 
 type labelCollectorClass struct {
 	class   *mruby.Class
-	objects []labelCollectorClassInstance
+	objects *instanceRegistry
 	rk      *RubyKube
 }
 
@@ -20,7 +18,7 @@ type labelCollectorClassInstance struct {
 }
 
 func newLabelCollectorClass(rk *RubyKube) *labelCollectorClass {
-	c := &labelCollectorClass{objects: []labelCollectorClassInstance{}, rk: rk}
+	c := &labelCollectorClass{objects: rk.newInstanceRegistry("LabelCollector"), rk: rk}
 	c.class = defineLabelCollectorClass(rk, c)
 	return c
 }
@@ -28,11 +26,11 @@ func newLabelCollectorClass(rk *RubyKube) *labelCollectorClass {
 func defineLabelCollectorClass(rk *RubyKube, c *labelCollectorClass) *mruby.Class {
 	// common methods
 	return rk.defineClass("LabelCollector", map[string]methodDefintion{
-		"object_count": {
-			mruby.ArgsNone(), func(m *mruby.Mrb, self *mruby.MrbValue) (mruby.Value, mruby.Value) {
-				return m.FixnumValue(len(c.objects)), nil
+		"inspect": {
+			mruby.ArgsReq(0), func(m *mruby.Mrb, self *mruby.MrbValue) (mruby.Value, mruby.Value) {
+				return m.StringValue("#<" + "LabelCollector" + ">"), nil
 			},
-			classMethod,
+			instanceMethod,
 		},
 	})
 }
@@ -48,19 +46,18 @@ func (c *labelCollectorClass) New(args ...mruby.Value) (*labelCollectorClassInst
 		return nil, err
 	}
 
-	o := labelCollectorClassInstance{
+	o := &labelCollectorClassInstance{
 		self: s,
 		vars: v,
 	}
-	c.objects = append(c.objects, o)
-	return &o, nil
+	c.objects.add(s, o)
+	return o, nil
 }
 
 func (c *labelCollectorClass) LookupVars(this *mruby.MrbValue) (*labelCollectorClassInstanceVars, error) {
-	for _, that := range c.objects {
-		if *this == *that.self {
-			return that.vars, nil
-		}
+	o, err := c.objects.lookup(this)
+	if err != nil {
+		return nil, err
 	}
-	return nil, fmt.Errorf("%s: could not find class instance", "LabelCollector")
+	return o.(*labelCollectorClassInstance).vars, nil
 }
@@ -1,8 +1,6 @@
 package rubykube
 
 import (
-	"fmt"
-
 	mruby "github.com/mitchellh/go-mruby"
 )
 
@@ -10,7 +8,7 @@ import (
 
 type labelKeyClass struct {
 	class   *mruby.Class
-	objects []labelKeyClassInstance
+	objects *instanceRegistry
 	rk      *RubyKube
 }
 
@@ -20,7 +18,7 @@ type labelKeyClassInstance struct {
 }
 
 func newLabelKeyClass(rk *RubyKube) *labelKeyClass {
-	c := &labelKeyClass{objects: []labelKeyClassInstance{}, rk: rk}
+	c := &labelKeyClass{objects: rk.newInstanceRegistry("LabelKey"), rk: rk}
 	c.class = defineLabelKeyClass(rk, c)
 	return c
 }
@@ -28,11 +26,11 @@ func newLabelKeyClass(rk *RubyKube) *labelKeyClass {
 func defineLabelKeyClass(rk *RubyKube, c *labelKeyClass) *mruby.Class {
 	// common methods
 	return rk.defineClass("LabelKey", map[string]methodDefintion{
-		"object_count": {
-			mruby.ArgsNone(), func(m *mruby.Mrb, self *mruby.MrbValue) (mruby.Value, mruby.Value) {
-				return m.FixnumValue(len(c.objects)), nil
+		"inspect": {
+			mruby.ArgsReq(0), func(m *mruby.Mrb, self *mruby.MrbValue) (mruby.Value, mruby.Value) {
+				return m.StringValue("#<" + "LabelKey" + ">"), nil
 			},
-			classMethod,
+			instanceMethod,
 		},
 	})
 }
@@ -48,19 +46,18 @@ func (c *labelKeyClass) New(args ...mruby.Value) (*labelKeyClassInstance, error)
 		return nil, err
 	}
 
-	o := labelKeyClassInstance{
+	o := &labelKeyClassInstance{
 		self: s,
 		vars: v,
 	}
-	c.objects = append(c.objects, o)
-	return &o, nil
+	c.objects.add(s, o)
+	return o, nil
 }
 
 func (c *labelKeyClass) LookupVars(this *mruby.MrbValue) (*labelKeyClassInstanceVars, error) {
-	for _, that := range c.objects {
-		if *this == *that.self {
-			return that.vars, nil
-		}
+	o, err := c.objects.lookup(this)
+	if err != nil {
+		return nil, err
 	}
-	return nil, fmt.Errorf("%s: could not find class instance", "LabelKey")
+	return o.(*labelKeyClassInstance).vars, nil
 }
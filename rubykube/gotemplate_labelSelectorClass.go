@@ -1,8 +1,6 @@
 package rubykube
 
 import (
-	"fmt"
-
 	mruby "github.com/mitchellh/go-mruby"
 )
 
@@ -10,7 +8,7 @@ import (
 
 type labelSelectorClass struct {
 	class   *mruby.Class
-	objects []labelSelectorClassInstance
+	objects *instanceRegistry
 	rk      *RubyKube
 }
 
@@ -20,7 +18,7 @@ type labelSelectorClassInstance struct {
 }
 
 func newLabelSelectorClass(rk *RubyKube) *labelSelectorClass {
-	c := &labelSelectorClass{objects: []labelSelectorClassInstance{}, rk: rk}
+	c := &labelSelectorClass{objects: rk.newInstanceRegistry("LabelSelector"), rk: rk}
 	c.class = defineLabelSelectorClass(rk, c)
 	return c
 }
@@ -28,11 +26,11 @@ func newLabelSelectorClass(rk *RubyKube) *labelSelectorClass {
 func defineLabelSelectorClass(rk *RubyKube, c *labelSelectorClass) *mruby.Class {
 	// common methods
 	return rk.defineClass("LabelSelector", map[string]methodDefintion{
-		"object_count": {
-			mruby.ArgsNone(), func(m *mruby.Mrb, self *mruby.MrbValue) (mruby.Value, mruby.Value) {
-				return m.FixnumValue(len(c.objects)), nil
+		"inspect": {
+			mruby.ArgsReq(0), func(m *mruby.Mrb, self *mruby.MrbValue) (mruby.Value, mruby.Value) {
+				return m.StringValue("#<" + "LabelSelector" + ">"), nil
 			},
-			classMethod,
+			instanceMethod,
 		},
 	})
 }
@@ -48,19 +46,18 @@ func (c *labelSelectorClass) New(args ...mruby.Value) (*labelSelectorClassInstan
 		return nil, err
 	}
 
-	o := labelSelectorClassInstance{
+	o := &labelSelectorClassInstance{
 		self: s,
 		vars: v,
 	}
-	c.objects = append(c.objects, o)
-	return &o, nil
+	c.objects.add(s, o)
+	return o, nil
 }
 
 func (c *labelSelectorClass) LookupVars(this *mruby.MrbValue) (*labelSelectorClassInstanceVars, error) {
-	for _, that := range c.objects {
-		if *this == *that.self {
-			return that.vars, nil
-		}
+	o, err := c.objects.lookup(this)
+	if err != nil {
+		return nil, err
 	}
-	return nil, fmt.Errorf("%s: could not find class instance", "LabelSelector")
+	return o.(*labelSelectorClassInstance).vars, nil
 }
@@ -0,0 +1,63 @@
+package rubykube
+
+import (
+	mruby "github.com/mitchellh/go-mruby"
+)
+
+// template type RubyKubeClass(classNameString, newClassInstanceVars, classInstanceVarsType)
+
+type netpolMatrixClass struct {
+	class   *mruby.Class
+	objects *instanceRegistry
+	rk      *RubyKube
+}
+
+type netpolMatrixClassInstance struct {
+	self *mruby.MrbValue
+	vars *netpolMatrixClassInstanceVars
+}
+
+func newNetpolMatrixClass(rk *RubyKube) *netpolMatrixClass {
+	c := &netpolMatrixClass{objects: rk.newInstanceRegistry("NetpolMatrix"), rk: rk}
+	c.class = defineNetpolMatrixClass(rk, c)
+	return c
+}
+
+func defineNetpolMatrixClass(rk *RubyKube, c *netpolMatrixClass) *mruby.Class {
+	// common methods
+	return rk.defineClass("NetpolMatrix", map[string]methodDefintion{
+		"inspect": {
+			mruby.ArgsReq(0), func(m *mruby.Mrb, self *mruby.MrbValue) (mruby.Value, mruby.Value) {
+				return m.StringValue("#<" + "NetpolMatrix" + ">"), nil
+			},
+			instanceMethod,
+		},
+	})
+}
+
+func (c *netpolMatrixClass) New(args ...mruby.Value) (*netpolMatrixClassInstance, error) {
+	s, err := c.class.New()
+	if err != nil {
+		return nil, err
+	}
+
+	v, err := newNetpolMatrixClassInstanceVars(c, s, args...)
+	if err != nil {
+		return nil, err
+	}
+
+	o := &netpolMatrixClassInstance{
+		self: s,
+		vars: v,
+	}
+	c.objects.add(s, o)
+	return o, nil
+}
+
+func (c *netpolMatrixClass) LookupVars(this *mruby.MrbValue) (*netpolMatrixClassInstanceVars, error) {
+	o, err := c.objects.lookup(this)
+	if err != nil {
+		return nil, err
+	}
+	return o.(*netpolMatrixClassInstance).vars, nil
+}
@@ -1,8 +1,6 @@
 package rubykube
 
 import (
-	"fmt"
-
 	"github.com/errordeveloper/kubeplay/rubykube/converter"
 	mruby "github.com/mitchellh/go-mruby"
 )
@@ -11,7 +9,7 @@ import (
 
 type podClass struct {
 	class   *mruby.Class
-	objects []podClassInstance
+	objects *instanceRegistry
 	rk      *RubyKube
 }
 
@@ -22,10 +20,12 @@ type podClassInstance struct {
 
 type podClassInstanceVars struct {
 	pod podTypeAlias
+	// query is what a list was fetched with by `get!`, it's nil for singletons and lists made in other ways
+	query *listQuery
 }
 
 func newPodClass(rk *RubyKube) *podClass {
-	c := &podClass{objects: []podClassInstance{}, rk: rk}
+	c := &podClass{objects: rk.newInstanceRegistry("Pod"), rk: rk}
 	c.class = definePodClass(rk, c)
 	return c
 }
@@ -37,12 +37,12 @@ func definePodClass(rk *RubyKube, c *podClass) *mruby.Class {
 			mruby.ArgsReq(0), func(m *mruby.Mrb, self *mruby.MrbValue) (mruby.Value, mruby.Value) {
 				vars, err := c.LookupVars(self)
 				if err != nil {
-					return nil, createException(m, err.Error())
+					return nil, createError(m, err)
 				}
 
 				rbconv := converter.New(m)
 				if err := rbconv.Convert(vars.pod); err != nil {
-					return nil, createException(m, err.Error())
+					return nil, createError(m, err)
 				}
 
 				return rbconv.Value(), nil
@@ -53,17 +53,21 @@ func definePodClass(rk *RubyKube, c *podClass) *mruby.Class {
 			mruby.ArgsReq(0), func(m *mruby.Mrb, self *mruby.MrbValue) (mruby.Value, mruby.Value) {
 				vars, err := c.LookupVars(self)
 				if err != nil {
-					return nil, createException(m, err.Error())
+					return nil, createError(m, err)
 				}
 				return marshalToJSON(vars.pod, m)
 			},
 			instanceMethod,
 		},
-		"object_count": {
-			mruby.ArgsNone(), func(m *mruby.Mrb, self *mruby.MrbValue) (mruby.Value, mruby.Value) {
-				return m.FixnumValue(len(c.objects)), nil
+		"jsonpath": {
+			mruby.ArgsReq(1), func(m *mruby.Mrb, self *mruby.MrbValue) (mruby.Value, mruby.Value) {
+				vars, err := c.LookupVars(self)
+				if err != nil {
+					return nil, createError(m, err)
+				}
+				return jsonPathMethod(m, &vars.pod)
 			},
-			classMethod,
+			instanceMethod,
 		},
 	})
 }
@@ -73,21 +77,25 @@ func (c *podClass) New() (*podClassInstance, error) {
 	if err != nil {
 		return nil, err
 	}
-	o := podClassInstance{
+	o := &podClassInstance{
 		self: s,
 		vars: &podClassInstanceVars{
-			podTypeAlias{},
+			pod: podTypeAlias{},
 		},
 	}
-	c.objects = append(c.objects, o)
-	return &o, nil
+	c.objects.add(s, o)
+	return o, nil
 }
 
 func (c *podClass) LookupVars(this *mruby.MrbValue) (*podClassInstanceVars, error) {
-	for _, that := range c.objects {
-		if *this == *that.self {
-			return that.vars, nil
-		}
+	o, err := c.objects.lookup(this)
+	if err != nil {
+		return nil, err
 	}
-	return nil, fmt.Errorf("%s: could not find class instance", "Pod")
+	return o.(*podClassInstance).vars, nil
+}
+
+// object returns the resource object, so that it can be looked up without knowing the class
+func (o *podClassInstance) object() interface{} {
+	return &o.vars.pod
 }
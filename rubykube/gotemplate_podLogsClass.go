@@ -1,8 +1,6 @@
 package rubykube
 
 import (
-	"fmt"
-
 	mruby "github.com/mitchellh/go-mruby"
 )
 
@@ -10,7 +8,7 @@ import (
 
 type podLogsClass struct {
 	class   *mruby.Class
-	objects []podLogsClassInstance
+	objects *instanceRegistry
 	rk      *RubyKube
 }
 
@@ -20,7 +18,7 @@ type podLogsClassInstance struct {
 }
 
 func newPodLogsClass(rk *RubyKube) *podLogsClass {
-	c := &podLogsClass{objects: []podLogsClassInstance{}, rk: rk}
+	c := &podLogsClass{objects: rk.newInstanceRegistry("PodLogs"), rk: rk}
 	c.class = definePodLogsClass(rk, c)
 	return c
 }
@@ -28,11 +26,11 @@ func newPodLogsClass(rk *RubyKube) *podLogsClass {
 func definePodLogsClass(rk *RubyKube, c *podLogsClass) *mruby.Class {
 	// common methods
 	return rk.defineClass("PodLogs", map[string]methodDefintion{
-		"object_count": {
-			mruby.ArgsNone(), func(m *mruby.Mrb, self *mruby.MrbValue) (mruby.Value, mruby.Value) {
-				return m.FixnumValue(len(c.objects)), nil
+		"inspect": {
+			mruby.ArgsReq(0), func(m *mruby.Mrb, self *mruby.MrbValue) (mruby.Value, mruby.Value) {
+				return m.StringValue("#<" + "PodLogs" + ">"), nil
 			},
-			classMethod,
+			instanceMethod,
 		},
 	})
 }
@@ -48,19 +46,18 @@ func (c *podLogsClass) New(args ...mruby.Value) (*podLogsClassInstance, error) {
 		return nil, err
 	}
 
-	o := podLogsClassInstance{
+	o := &podLogsClassInstance{
 		self: s,
 		vars: v,
 	}
-	c.objects = append(c.objects, o)
-	return &o, nil
+	c.objects.add(s, o)
+	return o, nil
 }
 
 func (c *podLogsClass) LookupVars(this *mruby.MrbValue) (*podLogsClassInstanceVars, error) {
-	for _, that := range c.objects {
-		if *this == *that.self {
-			return that.vars, nil
-		}
+	o, err := c.objects.lookup(this)
+	if err != nil {
+		return nil, err
 	}
-	return nil, fmt.Errorf("%s: could not find class instance", "PodLogs")
+	return o.(*podLogsClassInstance).vars, nil
 }
@@ -1,8 +1,6 @@
 package rubykube
 
 import (
-	"fmt"
-
 	mruby "github.com/mitchellh/go-mruby"
 )
 
@@ -10,7 +8,7 @@ import (
 
 type podMakerClass struct {
 	class   *mruby.Class
-	objects []podMakerClassInstance
+	objects *instanceRegistry
 	rk      *RubyKube
 }
 
@@ -20,7 +18,7 @@ type podMakerClassInstance struct {
 }
 
 func newPodMakerClass(rk *RubyKube) *podMakerClass {
-	c := &podMakerClass{objects: []podMakerClassInstance{}, rk: rk}
+	c := &podMakerClass{objects: rk.newInstanceRegistry("PodMaker"), rk: rk}
 	c.class = definePodMakerClass(rk, c)
 	return c
 }
@@ -28,11 +26,11 @@ func newPodMakerClass(rk *RubyKube) *podMakerClass {
 func definePodMakerClass(rk *RubyKube, c *podMakerClass) *mruby.Class {
 	// common methods
 	return rk.defineClass("PodMaker", map[string]methodDefintion{
-		"object_count": {
-			mruby.ArgsNone(), func(m *mruby.Mrb, self *mruby.MrbValue) (mruby.Value, mruby.Value) {
-				return m.FixnumValue(len(c.objects)), nil
+		"inspect": {
+			mruby.ArgsReq(0), func(m *mruby.Mrb, self *mruby.MrbValue) (mruby.Value, mruby.Value) {
+				return m.StringValue("#<" + "PodMaker" + ">"), nil
 			},
-			classMethod,
+			instanceMethod,
 		},
 	})
 }
@@ -48,19 +46,18 @@ func (c *podMakerClass) New(args ...mruby.Value) (*podMakerClassInstance, error)
 		return nil, err
 	}
 
-	o := podMakerClassInstance{
+	o := &podMakerClassInstance{
 		self: s,
 		vars: v,
 	}
-	c.objects = append(c.objects, o)
-	return &o, nil
+	c.objects.add(s, o)
+	return o, nil
 }
 
 func (c *podMakerClass) LookupVars(this *mruby.MrbValue) (*podMakerClassInstanceVars, error) {
-	for _, that := range c.objects {
-		if *this == *that.self {
-			return that.vars, nil
-		}
+	o, err := c.objects.lookup(this)
+	if err != nil {
+		return nil, err
 	}
-	return nil, fmt.Errorf("%s: could not find class instance", "PodMaker")
+	return o.(*podMakerClassInstance).vars, nil
 }
@@ -3,43 +3,133 @@ package rubykube
 import (
 	"fmt"
 	"math/rand"
+	"strings"
 
 	mruby "github.com/mitchellh/go-mruby"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 )
 
 // template type RubyKubeClass(parentClass, classNameString, instanceVariableName, instanceVariableType)
 
 type podsListModule struct{}
 
+// listNames returns `<namespace>/<name>` of all objects in given namespace, it is used for tab completion
+func (c *podsClass) listNames(ns string) ([]string, error) {
+	pods, err := c.getList(ns, metav1.ListOptions{})
+	if err != nil {
+		return nil, err
+	}
+
+	names := []string{}
+	for _, item := range pods.Items {
+		names = append(names, item.ObjectMeta.Namespace+"/"+item.ObjectMeta.Name)
+	}
+	return names, nil
+}
+
+// getQuery lists objects that match any of the queries and the selectors, it is used by `get!`,
+// and to list current state of objects in a snapshot
+func (c *podsClass) getQuery(queries []resourceQuery, selectors *resourceSelectors) (*podListTypeAlias, error) {
+	// set-valued field selectors expand into multiple queries, and those
+	// not supported by API server are evaluated on the client
+	listOptions, clientFields := selectors.listOptions("Pods")
+
+	// each namespace is listed only once, as multiple globs may refer to the same one,
+	// and lists are fetched concurrently
+	type listRequest struct {
+		ns          string
+		listOptions metav1.ListOptions
+	}
+	requests := []listRequest{}
+	requested := map[string]int{}
+	for _, q := range queries {
+		ns := c.rk.GetNamespace(q.namespace)
+		for _, lo := range listOptions {
+			if _, ok := requested[ns+"?"+lo.FieldSelector]; !ok {
+				requested[ns+"?"+lo.FieldSelector] = len(requests)
+				requests = append(requests, listRequest{ns, lo})
+			}
+		}
+	}
+
+	lists := make([]*podListTypeAlias, len(requests))
+	if err := c.rk.parallel(len(requests), func(i int) error {
+		list, err := c.getList(requests[i].ns, requests[i].listOptions)
+		if err != nil {
+			return err
+		}
+		lists[i] = (*podListTypeAlias)(list)
+		return nil
+	}); err != nil {
+		return nil, err
+	}
+
+	seen := map[string]bool{}
+
+	result := &podListTypeAlias{}
+	for _, q := range queries {
+		ns := c.rk.GetNamespace(q.namespace)
+		for _, lo := range listOptions {
+			pods := lists[requested[ns+"?"+lo.FieldSelector]]
+			result.TypeMeta = pods.TypeMeta
+			result.ListMeta = pods.ListMeta
+
+			for i, item := range pods.Items {
+				key := item.ObjectMeta.Namespace + "/" + item.ObjectMeta.Name
+				if seen[key] || !q.match(item.ObjectMeta.Namespace, item.ObjectMeta.Name) {
+					continue
+				}
+				ok, err := matchFieldExpressions(&pods.Items[i], clientFields)
+				if err != nil {
+					return nil, err
+				}
+				if !ok {
+					continue
+				}
+				seen[key] = true
+				result.Items = append(result.Items, item)
+			}
+		}
+	}
+	return result, nil
+}
+
 func (c *podsClass) defineListMethods() {
 	c.rk.appendMethods(c.class, map[string]methodDefintion{
 		"get!": {
 			mruby.ArgsReq(0) | mruby.ArgsOpt(2), func(m *mruby.Mrb, self *mruby.MrbValue) (mruby.Value, mruby.Value) {
 				vars, err := c.LookupVars(self)
 				if err != nil {
-					return nil, createException(m, err.Error())
+					return nil, createError(m, err)
 				}
 
-				ns, nameRegexp, listOptions, err := c.rk.resourceArgs(m.GetArgs())
+				queries, selectors, err := c.rk.resourceArgs(m.GetArgs())
 				if err != nil {
-					return nil, createException(m, err.Error())
+					return nil, createError(m, err)
 				}
 
-				pods, err := c.getList(c.rk.GetNamespace(ns), *listOptions)
+				pods, err := c.getQuery(queries, selectors)
+				if err != nil {
+					return nil, createError(m, err)
+				}
+				vars.pods = *pods
+				vars.query = c.rk.newListQuery(queries, selectors)
+				return self, nil
+			},
+			instanceMethod,
+		},
+		"to_s": {
+			mruby.ArgsReq(0), func(m *mruby.Mrb, self *mruby.MrbValue) (mruby.Value, mruby.Value) {
+				vars, err := c.LookupVars(self)
 				if err != nil {
-					return nil, createException(m, err.Error())
+					return nil, createError(m, err)
 				}
 
-				if nameRegexp != nil {
-					for _, item := range pods.Items {
-						if nameRegexp.MatchString(item.ObjectMeta.Name) {
-							vars.pods.Items = append(vars.pods.Items, item)
-						}
-					}
-				} else {
-					vars.pods = podListTypeAlias(*pods)
+				names := []string{}
+				for _, item := range vars.pods.Items {
+					names = append(names, objectName(item.ObjectMeta))
 				}
-				return self, nil
+				return m.StringValue(strings.Join(names, "\n")), nil
 			},
 			instanceMethod,
 		},
@@ -47,13 +137,14 @@ func (c *podsClass) defineListMethods() {
 			mruby.ArgsReq(0), func(m *mruby.Mrb, self *mruby.MrbValue) (mruby.Value, mruby.Value) {
 				vars, err := c.LookupVars(self)
 				if err != nil {
-					return nil, createException(m, err.Error())
+					return nil, createError(m, err)
 				}
 
+				lines := []string{fmt.Sprintf("#<%s count=%d>", "Pods", len(vars.pods.Items))}
 				for n, item := range vars.pods.Items {
-					fmt.Printf("%d: %s/%s\n", n, item.ObjectMeta.Namespace, item.ObjectMeta.Name)
+					lines = append(lines, fmt.Sprintf("%d: %s", n, objectName(item.ObjectMeta)))
 				}
-				return self, nil
+				return m.StringValue(strings.Join(lines, "\n")), nil
 			},
 			instanceMethod,
 		},
@@ -61,24 +152,83 @@ func (c *podsClass) defineListMethods() {
 			mruby.ArgsReq(0), func(m *mruby.Mrb, self *mruby.MrbValue) (mruby.Value, mruby.Value) {
 				vars, err := c.LookupVars(self)
 				if err != nil {
-					return nil, createException(m, err.Error())
+					return nil, createError(m, err)
 				}
 
 				return m.FixnumValue(len(vars.pods.Items)), nil
 			},
 			instanceMethod,
 		},
+		"pluck": {
+			mruby.ArgsReq(1), func(m *mruby.Mrb, self *mruby.MrbValue) (mruby.Value, mruby.Value) {
+				vars, err := c.LookupVars(self)
+				if err != nil {
+					return nil, createError(m, err)
+				}
+
+				args := m.GetArgs()
+				if err := standardCheck(c.rk, args, 1); err != nil {
+					return nil, createError(m, err)
+				}
+
+				array, err := newArrayOf(m, len(vars.pods.Items), func(i int) (mruby.Value, error) {
+					return pluckValue(m, &vars.pods.Items[i], args[0].String())
+				})
+				if err != nil {
+					return nil, createError(m, err)
+				}
+				return array, nil
+			},
+			instanceMethod,
+		},
+		"where": {
+			mruby.ArgsReq(1), func(m *mruby.Mrb, self *mruby.MrbValue) (mruby.Value, mruby.Value) {
+				vars, err := c.LookupVars(self)
+				if err != nil {
+					return nil, createError(m, err)
+				}
+
+				args := m.GetArgs()
+				if err := standardCheck(c.rk, args, 1); err != nil {
+					return nil, createError(m, err)
+				}
+
+				conditions, err := newWhereConditions(args[0])
+				if err != nil {
+					return nil, createError(m, err)
+				}
+
+				newListObj, err := c.New()
+				if err != nil {
+					return nil, createError(m, err)
+				}
+
+				newListObj.vars.pods = vars.pods
+				newListObj.vars.pods.Items = nil
+				for i, item := range vars.pods.Items {
+					ok, err := matchWhereConditions(&vars.pods.Items[i], conditions)
+					if err != nil {
+						return nil, createError(m, err)
+					}
+					if ok {
+						newListObj.vars.pods.Items = append(newListObj.vars.pods.Items, item)
+					}
+				}
+				return newListObj.self, nil
+			},
+			instanceMethod,
+		},
 		"[]": {
 			mruby.ArgsReq(1), func(m *mruby.Mrb, self *mruby.MrbValue) (mruby.Value, mruby.Value) {
 				vars, err := c.LookupVars(self)
 				if err != nil {
-					return nil, createException(m, err.Error())
+					return nil, createError(m, err)
 				}
 
 				args := m.GetArgs()
 				err = standardCheck(c.rk, args, 1)
 				if err != nil {
-					return nil, createException(m, err.Error())
+					return nil, createError(m, err)
 				}
 				n := args[0]
 				if n.Type() != mruby.TypeFixnum {
@@ -104,7 +254,7 @@ func (c *podsClass) defineListMethods() {
 
 				obj, err := c.getItem(vars.pods, i)
 				if err != nil {
-					return nil, createException(m, err.Error())
+					return nil, createError(m, err)
 				}
 				return obj.self, nil
 			},
@@ -114,13 +264,13 @@ func (c *podsClass) defineListMethods() {
 			mruby.ArgsNone(), func(m *mruby.Mrb, self *mruby.MrbValue) (mruby.Value, mruby.Value) {
 				vars, err := c.LookupVars(self)
 				if err != nil {
-					return nil, createException(m, err.Error())
+					return nil, createError(m, err)
 				}
 
 				if len(vars.pods.Items) > 0 {
 					obj, err := c.getItem(vars.pods, 0)
 					if err != nil {
-						return nil, createException(m, err.Error())
+						return nil, createError(m, err)
 					}
 					return obj.self, nil
 				}
@@ -132,14 +282,14 @@ func (c *podsClass) defineListMethods() {
 			mruby.ArgsNone(), func(m *mruby.Mrb, self *mruby.MrbValue) (mruby.Value, mruby.Value) {
 				vars, err := c.LookupVars(self)
 				if err != nil {
-					return nil, createException(m, err.Error())
+					return nil, createError(m, err)
 				}
 
 				l := len(vars.pods.Items)
 				if l > 0 {
 					obj, err := c.getItem(vars.pods, rand.Intn(l))
 					if err != nil {
-						return nil, createException(m, err.Error())
+						return nil, createError(m, err)
 					}
 					return obj.self, nil
 				}
@@ -151,7 +301,7 @@ func (c *podsClass) defineListMethods() {
 			mruby.ArgsNone(), func(m *mruby.Mrb, self *mruby.MrbValue) (mruby.Value, mruby.Value) {
 				vars, err := c.LookupVars(self)
 				if err != nil {
-					return nil, createException(m, err.Error())
+					return nil, createError(m, err)
 				}
 
 				l := len(vars.pods.Items)
@@ -159,7 +309,7 @@ func (c *podsClass) defineListMethods() {
 				if l > 0 {
 					obj, err := c.getItem(vars.pods, l-1)
 					if err != nil {
-						return nil, createException(m, err.Error())
+						return nil, createError(m, err)
 					}
 					return obj.self, nil
 				}
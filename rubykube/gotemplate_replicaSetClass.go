@@ -1,8 +1,6 @@
 package rubykube
 
 import (
-	"fmt"
-
 	"github.com/errordeveloper/kubeplay/rubykube/converter"
 	mruby "github.com/mitchellh/go-mruby"
 )
@@ -11,7 +9,7 @@ import (
 
 type replicaSetClass struct {
 	class   *mruby.Class
-	objects []replicaSetClassInstance
+	objects *instanceRegistry
 	rk      *RubyKube
 }
 
@@ -22,10 +20,12 @@ type replicaSetClassInstance struct {
 
 type replicaSetClassInstanceVars struct {
 	replicaSet replicaSetTypeAlias
+	// query is what a list was fetched with by `get!`, it's nil for singletons and lists made in other ways
+	query *listQuery
 }
 
 func newReplicaSetClass(rk *RubyKube) *replicaSetClass {
-	c := &replicaSetClass{objects: []replicaSetClassInstance{}, rk: rk}
+	c := &replicaSetClass{objects: rk.newInstanceRegistry("ReplicaSet"), rk: rk}
 	c.class = defineReplicaSetClass(rk, c)
 	return c
 }
@@ -37,12 +37,12 @@ func defineReplicaSetClass(rk *RubyKube, c *replicaSetClass) *mruby.Class {
 			mruby.ArgsReq(0), func(m *mruby.Mrb, self *mruby.MrbValue) (mruby.Value, mruby.Value) {
 				vars, err := c.LookupVars(self)
 				if err != nil {
-					return nil, createException(m, err.Error())
+					return nil, createError(m, err)
 				}
 
 				rbconv := converter.New(m)
 				if err := rbconv.Convert(vars.replicaSet); err != nil {
-					return nil, createException(m, err.Error())
+					return nil, createError(m, err)
 				}
 
 				return rbconv.Value(), nil
@@ -53,17 +53,21 @@ func defineReplicaSetClass(rk *RubyKube, c *replicaSetClass) *mruby.Class {
 			mruby.ArgsReq(0), func(m *mruby.Mrb, self *mruby.MrbValue) (mruby.Value, mruby.Value) {
 				vars, err := c.LookupVars(self)
 				if err != nil {
-					return nil, createException(m, err.Error())
+					return nil, createError(m, err)
 				}
 				return marshalToJSON(vars.replicaSet, m)
 			},
 			instanceMethod,
 		},
-		"object_count": {
-			mruby.ArgsNone(), func(m *mruby.Mrb, self *mruby.MrbValue) (mruby.Value, mruby.Value) {
-				return m.FixnumValue(len(c.objects)), nil
+		"jsonpath": {
+			mruby.ArgsReq(1), func(m *mruby.Mrb, self *mruby.MrbValue) (mruby.Value, mruby.Value) {
+				vars, err := c.LookupVars(self)
+				if err != nil {
+					return nil, createError(m, err)
+				}
+				return jsonPathMethod(m, &vars.replicaSet)
 			},
-			classMethod,
+			instanceMethod,
 		},
 	})
 }
@@ -73,21 +77,25 @@ func (c *replicaSetClass) New() (*replicaSetClassInstance, error) {
 	if err != nil {
 		return nil, err
 	}
-	o := replicaSetClassInstance{
+	o := &replicaSetClassInstance{
 		self: s,
 		vars: &replicaSetClassInstanceVars{
-			replicaSetTypeAlias{},
+			replicaSet: replicaSetTypeAlias{},
 		},
 	}
-	c.objects = append(c.objects, o)
-	return &o, nil
+	c.objects.add(s, o)
+	return o, nil
 }
 
 func (c *replicaSetClass) LookupVars(this *mruby.MrbValue) (*replicaSetClassInstanceVars, error) {
-	for _, that := range c.objects {
-		if *this == *that.self {
-			return that.vars, nil
-		}
+	o, err := c.objects.lookup(this)
+	if err != nil {
+		return nil, err
 	}
-	return nil, fmt.Errorf("%s: could not find class instance", "ReplicaSet")
+	return o.(*replicaSetClassInstance).vars, nil
+}
+
+// object returns the resource object, so that it can be looked up without knowing the class
+func (o *replicaSetClassInstance) object() interface{} {
+	return &o.vars.replicaSet
 }
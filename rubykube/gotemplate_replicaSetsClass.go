@@ -1,8 +1,6 @@
 package rubykube
 
 import (
-	"fmt"
-
 	"github.com/errordeveloper/kubeplay/rubykube/converter"
 	mruby "github.com/mitchellh/go-mruby"
 )
@@ -11,7 +9,7 @@ import (
 
 type replicaSetsClass struct {
 	class   *mruby.Class
-	objects []replicaSetsClassInstance
+	objects *instanceRegistry
 	rk      *RubyKube
 }
 
@@ -22,10 +20,12 @@ type replicaSetsClassInstance struct {
 
 type replicaSetsClassInstanceVars struct {
 	replicaSets replicaSetListTypeAlias
+	// query is what a list was fetched with by `get!`, it's nil for singletons and lists made in other ways
+	query *listQuery
 }
 
 func newReplicaSetsClass(rk *RubyKube) *replicaSetsClass {
-	c := &replicaSetsClass{objects: []replicaSetsClassInstance{}, rk: rk}
+	c := &replicaSetsClass{objects: rk.newInstanceRegistry("ReplicaSets"), rk: rk}
 	c.class = defineReplicaSetsClass(rk, c)
 	return c
 }
@@ -37,12 +37,12 @@ func defineReplicaSetsClass(rk *RubyKube, c *replicaSetsClass) *mruby.Class {
 			mruby.ArgsReq(0), func(m *mruby.Mrb, self *mruby.MrbValue) (mruby.Value, mruby.Value) {
 				vars, err := c.LookupVars(self)
 				if err != nil {
-					return nil, createException(m, err.Error())
+					return nil, createError(m, err)
 				}
 
 				rbconv := converter.New(m)
 				if err := rbconv.Convert(vars.replicaSets); err != nil {
-					return nil, createException(m, err.Error())
+					return nil, createError(m, err)
 				}
 
 				return rbconv.Value(), nil
@@ -53,17 +53,21 @@ func defineReplicaSetsClass(rk *RubyKube, c *replicaSetsClass) *mruby.Class {
 			mruby.ArgsReq(0), func(m *mruby.Mrb, self *mruby.MrbValue) (mruby.Value, mruby.Value) {
 				vars, err := c.LookupVars(self)
 				if err != nil {
-					return nil, createException(m, err.Error())
+					return nil, createError(m, err)
 				}
 				return marshalToJSON(vars.replicaSets, m)
 			},
 			instanceMethod,
 		},
-		"object_count": {
-			mruby.ArgsNone(), func(m *mruby.Mrb, self *mruby.MrbValue) (mruby.Value, mruby.Value) {
-				return m.FixnumValue(len(c.objects)), nil
+		"jsonpath": {
+			mruby.ArgsReq(1), func(m *mruby.Mrb, self *mruby.MrbValue) (mruby.Value, mruby.Value) {
+				vars, err := c.LookupVars(self)
+				if err != nil {
+					return nil, createError(m, err)
+				}
+				return jsonPathMethod(m, &vars.replicaSets)
 			},
-			classMethod,
+			instanceMethod,
 		},
 	})
 }
@@ -73,21 +77,25 @@ func (c *replicaSetsClass) New() (*replicaSetsClassInstance, error) {
 	if err != nil {
 		return nil, err
 	}
-	o := replicaSetsClassInstance{
+	o := &replicaSetsClassInstance{
 		self: s,
 		vars: &replicaSetsClassInstanceVars{
-			replicaSetListTypeAlias{},
+			replicaSets: replicaSetListTypeAlias{},
 		},
 	}
-	c.objects = append(c.objects, o)
-	return &o, nil
+	c.objects.add(s, o)
+	return o, nil
 }
 
 func (c *replicaSetsClass) LookupVars(this *mruby.MrbValue) (*replicaSetsClassInstanceVars, error) {
-	for _, that := range c.objects {
-		if *this == *that.self {
-			return that.vars, nil
-		}
+	o, err := c.objects.lookup(this)
+	if err != nil {
+		return nil, err
 	}
-	return nil, fmt.Errorf("%s: could not find class instance", "ReplicaSets")
+	return o.(*replicaSetsClassInstance).vars, nil
+}
+
+// object returns the resource object, so that it can be looked up without knowing the class
+func (o *replicaSetsClassInstance) object() interface{} {
+	return &o.vars.replicaSets
 }
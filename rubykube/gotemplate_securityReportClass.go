@@ -0,0 +1,63 @@
+package rubykube
+
+import (
+	mruby "github.com/mitchellh/go-mruby"
+)
+
+// template type RubyKubeClass(classNameString, newClassInstanceVars, classInstanceVarsType)
+
+type securityReportClass struct {
+	class   *mruby.Class
+	objects *instanceRegistry
+	rk      *RubyKube
+}
+
+type securityReportClassInstance struct {
+	self *mruby.MrbValue
+	vars *securityReportClassInstanceVars
+}
+
+func newSecurityReportClass(rk *RubyKube) *securityReportClass {
+	c := &securityReportClass{objects: rk.newInstanceRegistry("SecurityReport"), rk: rk}
+	c.class = defineSecurityReportClass(rk, c)
+	return c
+}
+
+func defineSecurityReportClass(rk *RubyKube, c *securityReportClass) *mruby.Class {
+	// common methods
+	return rk.defineClass("SecurityReport", map[string]methodDefintion{
+		"inspect": {
+			mruby.ArgsReq(0), func(m *mruby.Mrb, self *mruby.MrbValue) (mruby.Value, mruby.Value) {
+				return m.StringValue("#<" + "SecurityReport" + ">"), nil
+			},
+			instanceMethod,
+		},
+	})
+}
+
+func (c *securityReportClass) New(args ...mruby.Value) (*securityReportClassInstance, error) {
+	s, err := c.class.New()
+	if err != nil {
+		return nil, err
+	}
+
+	v, err := newSecurityReportClassInstanceVars(c, s, args...)
+	if err != nil {
+		return nil, err
+	}
+
+	o := &securityReportClassInstance{
+		self: s,
+		vars: v,
+	}
+	c.objects.add(s, o)
+	return o, nil
+}
+
+func (c *securityReportClass) LookupVars(this *mruby.MrbValue) (*securityReportClassInstanceVars, error) {
+	o, err := c.objects.lookup(this)
+	if err != nil {
+		return nil, err
+	}
+	return o.(*securityReportClassInstance).vars, nil
+}
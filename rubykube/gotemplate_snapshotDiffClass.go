@@ -0,0 +1,63 @@
+package rubykube
+
+import (
+	mruby "github.com/mitchellh/go-mruby"
+)
+
+// template type RubyKubeClass(classNameString, newClassInstanceVars, classInstanceVarsType)
+
+type snapshotDiffClass struct {
+	class   *mruby.Class
+	objects *instanceRegistry
+	rk      *RubyKube
+}
+
+type snapshotDiffClassInstance struct {
+	self *mruby.MrbValue
+	vars *snapshotDiffClassInstanceVars
+}
+
+func newSnapshotDiffClass(rk *RubyKube) *snapshotDiffClass {
+	c := &snapshotDiffClass{objects: rk.newInstanceRegistry("SnapshotDiff"), rk: rk}
+	c.class = defineSnapshotDiffClass(rk, c)
+	return c
+}
+
+func defineSnapshotDiffClass(rk *RubyKube, c *snapshotDiffClass) *mruby.Class {
+	// common methods
+	return rk.defineClass("SnapshotDiff", map[string]methodDefintion{
+		"inspect": {
+			mruby.ArgsReq(0), func(m *mruby.Mrb, self *mruby.MrbValue) (mruby.Value, mruby.Value) {
+				return m.StringValue("#<" + "SnapshotDiff" + ">"), nil
+			},
+			instanceMethod,
+		},
+	})
+}
+
+func (c *snapshotDiffClass) New(args ...mruby.Value) (*snapshotDiffClassInstance, error) {
+	s, err := c.class.New()
+	if err != nil {
+		return nil, err
+	}
+
+	v, err := newSnapshotDiffClassInstanceVars(c, s, args...)
+	if err != nil {
+		return nil, err
+	}
+
+	o := &snapshotDiffClassInstance{
+		self: s,
+		vars: v,
+	}
+	c.objects.add(s, o)
+	return o, nil
+}
+
+func (c *snapshotDiffClass) LookupVars(this *mruby.MrbValue) (*snapshotDiffClassInstanceVars, error) {
+	o, err := c.objects.lookup(this)
+	if err != nil {
+		return nil, err
+	}
+	return o.(*snapshotDiffClassInstance).vars, nil
+}
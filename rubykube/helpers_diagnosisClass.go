@@ -0,0 +1,118 @@
+package rubykube
+
+import (
+	"fmt"
+	"strings"
+
+	mruby "github.com/mitchellh/go-mruby"
+)
+
+type diagnosisClassInstanceVars struct {
+	diagnosis *diagnosis
+}
+
+func newDiagnosisClassInstanceVars(c *diagnosisClass, s *mruby.MrbValue, args ...mruby.Value) (*diagnosisClassInstanceVars, error) {
+	return &diagnosisClassInstanceVars{diagnosis: &diagnosis{}}, nil
+}
+
+//go:generate gotemplate "./templates/basic" "diagnosisClass(\"Diagnosis\", newDiagnosisClassInstanceVars, diagnosisClassInstanceVars)"
+
+func (c *diagnosisClass) defineOwnMethods() {
+	c.rk.appendMethods(c.class, map[string]methodDefintion{
+		"to_s": {
+			mruby.ArgsNone(), func(m *mruby.Mrb, self *mruby.MrbValue) (mruby.Value, mruby.Value) {
+				vars, err := c.LookupVars(self)
+				if err != nil {
+					return nil, createError(m, err)
+				}
+
+				return m.StringValue(vars.diagnosis.String()), nil
+			},
+			instanceMethod,
+		},
+		"inspect": {
+			mruby.ArgsNone(), func(m *mruby.Mrb, self *mruby.MrbValue) (mruby.Value, mruby.Value) {
+				vars, err := c.LookupVars(self)
+				if err != nil {
+					return nil, createError(m, err)
+				}
+
+				return m.StringValue(fmt.Sprintf("#<Diagnosis causes=%d>\n%s", len(vars.diagnosis.causes()), strings.TrimSuffix(vars.diagnosis.String(), "\n"))), nil
+			},
+			instanceMethod,
+		},
+		"pager": {
+			mruby.ArgsNone(), func(m *mruby.Mrb, self *mruby.MrbValue) (mruby.Value, mruby.Value) {
+				vars, err := c.LookupVars(self)
+				if err != nil {
+					return nil, createError(m, err)
+				}
+
+				if err := c.rk.page(vars.diagnosis.String()); err != nil {
+					return nil, createError(m, err)
+				}
+				return nil, nil
+			},
+			instanceMethod,
+		},
+		"causes": {
+			mruby.ArgsNone(), func(m *mruby.Mrb, self *mruby.MrbValue) (mruby.Value, mruby.Value) {
+				vars, err := c.LookupVars(self)
+				if err != nil {
+					return nil, createError(m, err)
+				}
+
+				causes := []mruby.Value{}
+				for _, cause := range vars.diagnosis.causes() {
+					causes = append(causes, m.StringValue(cause))
+				}
+				array, err := newArray(m, causes...)
+				if err != nil {
+					return nil, createError(m, err)
+				}
+				return array, nil
+			},
+			instanceMethod,
+		},
+		"ok?": {
+			mruby.ArgsNone(), func(m *mruby.Mrb, self *mruby.MrbValue) (mruby.Value, mruby.Value) {
+				vars, err := c.LookupVars(self)
+				if err != nil {
+					return nil, createError(m, err)
+				}
+
+				if len(vars.diagnosis.causes()) == 0 {
+					return m.TrueValue(), nil
+				}
+				return m.FalseValue(), nil
+			},
+			instanceMethod,
+		},
+		"to_json": {
+			mruby.ArgsNone(), func(m *mruby.Mrb, self *mruby.MrbValue) (mruby.Value, mruby.Value) {
+				vars, err := c.LookupVars(self)
+				if err != nil {
+					return nil, createError(m, err)
+				}
+
+				return marshalToJSON(vars.diagnosis, m)
+			},
+			instanceMethod,
+		},
+		"to_ruby": {
+			mruby.ArgsNone(), func(m *mruby.Mrb, self *mruby.MrbValue) (mruby.Value, mruby.Value) {
+				vars, err := c.LookupVars(self)
+				if err != nil {
+					return nil, createError(m, err)
+				}
+
+				value, err := nativeRubyValueOf(m, vars.diagnosis)
+				if err != nil {
+					return nil, createError(m, err)
+				}
+				return value, nil
+			},
+			instanceMethod,
+		},
+	})
+}
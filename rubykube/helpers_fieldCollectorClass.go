@@ -56,12 +56,12 @@ func (c *fieldCollectorClass) makeFieldMethod() methodDefintion {
 		mruby.ArgsReq(1), func(m *mruby.Mrb, self *mruby.MrbValue) (mruby.Value, mruby.Value) {
 			vars, err := c.LookupVars(self)
 			if err != nil {
-				return nil, createException(m, err.Error())
+				return nil, createError(m, err)
 			}
 
 			newFieldKeyObj, err := c.rk.classes.FieldKey.New(toValues(m.GetArgs())...)
 			if err != nil {
-				return nil, createException(m, err.Error())
+				return nil, createError(m, err)
 			}
 
 			// let it append to my fields
@@ -78,7 +78,7 @@ func (c *fieldCollectorClass) makeLableMethod() methodDefintion {
 		mruby.ArgsAny(), func(m *mruby.Mrb, self *mruby.MrbValue) (mruby.Value, mruby.Value) {
 			newLabelKeyObj, err := c.rk.classes.LabelKey.New(toValues(m.GetArgs())...)
 			if err != nil {
-				return nil, createException(m, err.Error())
+				return nil, createError(m, err)
 			}
 
 			newLabelKeyObj.vars.onMatch = func(_ labelExpression) { return }
@@ -44,13 +44,13 @@ func (c *fieldKeyClass) makeMatchMethod(operator string) methodDefintion {
 		mruby.ArgsReq(0) | mruby.ArgsOpt(1), func(m *mruby.Mrb, self *mruby.MrbValue) (mruby.Value, mruby.Value) {
 			vars, err := c.LookupVars(self)
 			if err != nil {
-				return nil, createException(m, err.Error())
+				return nil, createError(m, err)
 			}
 
 			e := fieldExpression{key: strings.Join(vars.name, "."), operator: operator, values: []string{}}
 
 			if err := c.appendSetExpression(&e.values, m.GetArgs()...); err != nil {
-				return nil, createException(m, err.Error())
+				return nil, createError(m, err)
 			}
 
 			vars.onMatch(e)
@@ -96,7 +96,7 @@ func (c *fieldKeyClass) defineOwnMethods() {
 			mruby.ArgsNone(), func(m *mruby.Mrb, self *mruby.MrbValue) (mruby.Value, mruby.Value) {
 				vars, err := c.LookupVars(self)
 				if err != nil {
-					return nil, createException(m, err.Error())
+					return nil, createError(m, err)
 				}
 
 				return m.StringValue(strings.Join(vars.name, ".")), nil
@@ -107,12 +107,12 @@ func (c *fieldKeyClass) defineOwnMethods() {
 			mruby.ArgsReq(1), func(m *mruby.Mrb, self *mruby.MrbValue) (mruby.Value, mruby.Value) {
 				vars, err := c.LookupVars(self)
 				if err != nil {
-					return nil, createException(m, err.Error())
+					return nil, createError(m, err)
 				}
 
 				newFieldKeyObj, err := c.New(toValues(m.GetArgs())...)
 				if err != nil {
-					return nil, createException(m, err.Error())
+					return nil, createError(m, err)
 				}
 
 				newFieldKeyObj.vars.name = append(vars.name, newFieldKeyObj.vars.name...)
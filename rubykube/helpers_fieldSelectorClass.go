@@ -36,19 +36,103 @@ func (c *fieldSelectorClass) defineOwnMethods() {
 			mruby.ArgsReq(0), func(m *mruby.Mrb, self *mruby.MrbValue) (mruby.Value, mruby.Value) {
 				vars, err := c.LookupVars(self)
 				if err != nil {
-					return nil, createException(m, err.Error())
+					return nil, createError(m, err)
 				}
 
-				fields := []string{}
-				for _, e := range vars.collector.vars.fields {
-					for _, v := range e.values {
-						fields = append(fields, fmt.Sprintf("%s%s%s", e.key, e.operator, v))
+				selectors := fieldSelectorStrings(vars.collector.vars.fields)
+				if len(selectors) > 1 {
+					return nil, createException(m, fmt.Sprintf("this field selector expands into %d queries and cannot be represented as a single string – use `queries`", len(selectors)))
+				}
+
+				return m.StringValue(selectors[0]), nil
+			},
+			instanceMethod,
+		},
+		"inspect": {
+			mruby.ArgsReq(0), func(m *mruby.Mrb, self *mruby.MrbValue) (mruby.Value, mruby.Value) {
+				vars, err := c.LookupVars(self)
+				if err != nil {
+					return nil, createError(m, err)
+				}
+
+				// a set-valued selector is shown as all of the queries it expands into
+				selectors := fieldSelectorStrings(vars.collector.vars.fields)
+				return m.StringValue(fmt.Sprintf("#<FieldSelector %q>", strings.Join(selectors, " | "))), nil
+			},
+			instanceMethod,
+		},
+		"queries": {
+			mruby.ArgsReq(0), func(m *mruby.Mrb, self *mruby.MrbValue) (mruby.Value, mruby.Value) {
+				vars, err := c.LookupVars(self)
+				if err != nil {
+					return nil, createError(m, err)
+				}
+
+				values := []mruby.Value{}
+				for _, s := range fieldSelectorStrings(vars.collector.vars.fields) {
+					values = append(values, m.StringValue(s))
+				}
+
+				array, err := newArray(m, values...)
+				if err != nil {
+					return nil, createError(m, err)
+				}
+				return array, nil
+			},
+			instanceMethod,
+		},
+		"matches?": {
+			mruby.ArgsReq(1), func(m *mruby.Mrb, self *mruby.MrbValue) (mruby.Value, mruby.Value) {
+				vars, err := c.LookupVars(self)
+				if err != nil {
+					return nil, createError(m, err)
+				}
+
+				args := m.GetArgs()
+				if err := standardCheck(c.rk, args, 1); err != nil {
+					return nil, createError(m, err)
+				}
+
+				// fields of resource objects are looked up the same way as for client-side selectors,
+				// where a missing field is an empty string
+				if obj, ok := c.rk.resourceObjectOf(args[0]); ok {
+					matches, err := matchFieldExpressions(obj, vars.collector.vars.fields)
+					if err != nil {
+						return nil, createError(m, err)
+					}
+					if matches {
+						return m.TrueValue(), nil
 					}
+					return m.FalseValue(), nil
 				}
 
-				return m.StringValue(strings.Join(fields, ",")), nil
+				if args[0].Type() != mruby.TypeHash {
+					return nil, createException(m, "argument must be a hash of fields or a resource object")
+				}
+				for _, e := range vars.collector.vars.fields {
+					found, err := fieldValuesOf(args[0], e.key)
+					if err != nil {
+						return nil, createError(m, err)
+					}
+					if !matchFieldValues(e, found) {
+						return m.FalseValue(), nil
+					}
+				}
+				return m.TrueValue(), nil
 			},
 			instanceMethod,
 		},
 	})
 }
+
+// fieldValuesOf looks up a field in a flat hash (e.g. `{"status.phase" => "Running"}`)
+func fieldValuesOf(hash *mruby.MrbValue, key string) ([]string, error) {
+	v, err := hash.Hash().Get(hash.Mrb().StringValue(key))
+	if err != nil {
+		return nil, err
+	}
+	if v.Type() == mruby.TypeNil {
+		return []string{}, nil
+	}
+	return []string{v.String()}, nil
+}
@@ -0,0 +1,114 @@
+package rubykube
+
+import (
+	"fmt"
+	"strings"
+
+	mruby "github.com/mitchellh/go-mruby"
+)
+
+type imageInventoryClassInstanceVars struct {
+	inventory *imageInventory
+}
+
+func newImageInventoryClassInstanceVars(c *imageInventoryClass, s *mruby.MrbValue, args ...mruby.Value) (*imageInventoryClassInstanceVars, error) {
+	return &imageInventoryClassInstanceVars{inventory: &imageInventory{}}, nil
+}
+
+//go:generate gotemplate "./templates/basic" "imageInventoryClass(\"ImageInventory\", newImageInventoryClassInstanceVars, imageInventoryClassInstanceVars)"
+
+func (c *imageInventoryClass) defineOwnMethods() {
+	c.rk.appendMethods(c.class, map[string]methodDefintion{
+		"to_s": {
+			mruby.ArgsNone(), func(m *mruby.Mrb, self *mruby.MrbValue) (mruby.Value, mruby.Value) {
+				vars, err := c.LookupVars(self)
+				if err != nil {
+					return nil, createError(m, err)
+				}
+
+				return m.StringValue(vars.inventory.String()), nil
+			},
+			instanceMethod,
+		},
+		"inspect": {
+			mruby.ArgsNone(), func(m *mruby.Mrb, self *mruby.MrbValue) (mruby.Value, mruby.Value) {
+				vars, err := c.LookupVars(self)
+				if err != nil {
+					return nil, createError(m, err)
+				}
+
+				return m.StringValue(fmt.Sprintf("#<ImageInventory images=%d findings=%d>\n%s", len(vars.inventory.Images), len(vars.inventory.Findings), strings.TrimSuffix(vars.inventory.String(), "\n"))), nil
+			},
+			instanceMethod,
+		},
+		"pager": {
+			mruby.ArgsNone(), func(m *mruby.Mrb, self *mruby.MrbValue) (mruby.Value, mruby.Value) {
+				vars, err := c.LookupVars(self)
+				if err != nil {
+					return nil, createError(m, err)
+				}
+
+				if err := c.rk.page(vars.inventory.String()); err != nil {
+					return nil, createError(m, err)
+				}
+				return nil, nil
+			},
+			instanceMethod,
+		},
+		"findings": {
+			mruby.ArgsAny(), func(m *mruby.Mrb, self *mruby.MrbValue) (mruby.Value, mruby.Value) {
+				vars, err := c.LookupVars(self)
+				if err != nil {
+					return nil, createError(m, err)
+				}
+
+				value, err := nativeRubyValueOf(m, vars.inventory.findings(extractStringArgs(m.GetArgs())...))
+				if err != nil {
+					return nil, createError(m, err)
+				}
+				return value, nil
+			},
+			instanceMethod,
+		},
+		"ok?": {
+			mruby.ArgsAny(), func(m *mruby.Mrb, self *mruby.MrbValue) (mruby.Value, mruby.Value) {
+				vars, err := c.LookupVars(self)
+				if err != nil {
+					return nil, createError(m, err)
+				}
+
+				if len(vars.inventory.findings(extractStringArgs(m.GetArgs())...)) == 0 {
+					return m.TrueValue(), nil
+				}
+				return m.FalseValue(), nil
+			},
+			instanceMethod,
+		},
+		"to_json": {
+			mruby.ArgsNone(), func(m *mruby.Mrb, self *mruby.MrbValue) (mruby.Value, mruby.Value) {
+				vars, err := c.LookupVars(self)
+				if err != nil {
+					return nil, createError(m, err)
+				}
+
+				return marshalToJSON(vars.inventory, m)
+			},
+			instanceMethod,
+		},
+		"to_ruby": {
+			mruby.ArgsNone(), func(m *mruby.Mrb, self *mruby.MrbValue) (mruby.Value, mruby.Value) {
+				vars, err := c.LookupVars(self)
+				if err != nil {
+					return nil, createError(m, err)
+				}
+
+				value, err := nativeRubyValueOf(m, vars.inventory)
+				if err != nil {
+					return nil, createError(m, err)
+				}
+				return value, nil
+			},
+			instanceMethod,
+		},
+	})
+}
@@ -73,12 +73,12 @@ func (c *labelCollectorClass) makeLableMethod() methodDefintion {
 		mruby.ArgsReq(1), func(m *mruby.Mrb, self *mruby.MrbValue) (mruby.Value, mruby.Value) {
 			vars, err := c.LookupVars(self)
 			if err != nil {
-				return nil, createException(m, err.Error())
+				return nil, createError(m, err)
 			}
 
 			newLabelKeyObj, err := c.rk.classes.LabelKey.New(toValues(m.GetArgs())...)
 			if err != nil {
-				return nil, createException(m, err.Error())
+				return nil, createError(m, err)
 			}
 
 			// let it append to my labels
@@ -97,7 +97,7 @@ func (c *labelCollectorClass) makeFieldMethod() methodDefintion {
 		mruby.ArgsReq(1), func(m *mruby.Mrb, self *mruby.MrbValue) (mruby.Value, mruby.Value) {
 			newFieldKeyObj, err := c.rk.classes.FieldKey.New(toValues(m.GetArgs())...)
 			if err != nil {
-				return nil, createException(m, err.Error())
+				return nil, createError(m, err)
 			}
 
 			newFieldKeyObj.vars.onMatch = func(_ fieldExpression) { return }
@@ -41,13 +41,47 @@ func (c *labelKeyClass) makeMatchMethod(operator string) methodDefintion {
 		mruby.ArgsReq(0) | mruby.ArgsOpt(1), func(m *mruby.Mrb, self *mruby.MrbValue) (mruby.Value, mruby.Value) {
 			vars, err := c.LookupVars(self)
 			if err != nil {
-				return nil, createException(m, err.Error())
+				return nil, createError(m, err)
 			}
 
 			e := labelExpression{key: vars.name, operator: operator, values: []string{}}
 
 			if err := c.appendSetExpression(&e.values, m.GetArgs()...); err != nil {
-				return nil, createException(m, err.Error())
+				return nil, createError(m, err)
+			}
+
+			vars.onMatch(e)
+
+			return nil, nil
+		},
+		instanceMethod,
+	}
+}
+
+// makeEqualityMethod uses the equality operator when a single value is given,
+// and falls back to set operator for arrays, e.g. `@app == "foo"` compiles to
+// `app=foo`, while `@app == %w(foo bar)` compiles to `app in (foo, bar)`
+func (c *labelKeyClass) makeEqualityMethod(equalityOperator, setOperator string) methodDefintion {
+	return methodDefintion{
+		mruby.ArgsReq(1), func(m *mruby.Mrb, self *mruby.MrbValue) (mruby.Value, mruby.Value) {
+			vars, err := c.LookupVars(self)
+			if err != nil {
+				return nil, createError(m, err)
+			}
+
+			args := m.GetArgs()
+			if err := checkArgs(args, 1); err != nil {
+				return nil, createError(m, err)
+			}
+
+			e := labelExpression{key: vars.name, operator: setOperator, values: []string{}}
+
+			if err := c.appendSetExpression(&e.values, args...); err != nil {
+				return nil, createError(m, err)
+			}
+
+			if args[0].Type() != mruby.TypeArray && len(e.values) == 1 {
+				e.operator = equalityOperator
 			}
 
 			vars.onMatch(e)
@@ -86,13 +120,17 @@ func (c *labelKeyClass) appendSetExpression(values *[]string, args ...*mruby.Mrb
 func (c *labelKeyClass) defineOwnMethods() {
 	c.rk.appendMethods(c.class, map[string]methodDefintion{
 		"=~":             c.makeMatchMethod("in"),
-		"==":             c.makeMatchMethod("in"),
+		"==":             c.makeEqualityMethod("=", "in"),
+		"eq":             c.makeEqualityMethod("=", "in"),
+		"is":             c.makeEqualityMethod("=", "in"),
 		"in":             c.makeMatchMethod("in"),
 		"in?":            c.makeMatchMethod("in"),
 		"is_in":          c.makeMatchMethod("in"),
 		"is_in?":         c.makeMatchMethod("in"),
 		"!~":             c.makeMatchMethod("notin"),
-		"!=":             c.makeMatchMethod("notin"),
+		"!=":             c.makeEqualityMethod("!=", "notin"),
+		"ne":             c.makeEqualityMethod("!=", "notin"),
+		"is_not":         c.makeEqualityMethod("!=", "notin"),
 		"notin":          c.makeMatchMethod("notin"),
 		"notin?":         c.makeMatchMethod("notin"),
 		"not_in":         c.makeMatchMethod("notin"),
@@ -105,6 +143,12 @@ func (c *labelKeyClass) defineOwnMethods() {
 		"present?":       c.makeMatchMethod(""),
 		"anything?":      c.makeMatchMethod(""),
 		"is_present?":    c.makeMatchMethod(""),
+		"!":              c.makeMatchMethod("!"),
+		"undefined?":     c.makeMatchMethod("!"),
+		"absent?":        c.makeMatchMethod("!"),
+		"missing?":       c.makeMatchMethod("!"),
+		"not_set?":       c.makeMatchMethod("!"),
+		"is_not_set?":    c.makeMatchMethod("!"),
 		"method_missing": emptyMethod(),
 	})
 }
@@ -4,31 +4,221 @@ import (
 	"fmt"
 	"strings"
 
+	"github.com/errordeveloper/kubeplay/rubykube/converter"
 	mruby "github.com/mitchellh/go-mruby"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/selection"
 )
 
 type labelSelectorClassInstanceVars struct {
-	collector *labelCollectorClassInstance
+	labels []labelExpression
 }
 
+// newLabelSelectorClassInstanceVars takes either a block or a selector string, or no arguments at all,
+// in which case the selector is empty and it's up to the caller to set the expressions
 func newLabelSelectorClassInstanceVars(c *labelSelectorClass, s *mruby.MrbValue, args ...mruby.Value) (*labelSelectorClassInstanceVars, error) {
-	if len(args) != 1 {
+	if len(args) > 1 {
 		return nil, fmt.Errorf("Exactly one argument must supplied")
 	}
 
-	newLabelCollectorObj, err := c.rk.classes.LabelCollector.New(args...)
+	o := &labelSelectorClassInstanceVars{labels: []labelExpression{}}
+
+	if len(args) == 0 {
+		return o, nil
+	}
+
+	switch arg := args[0].MrbValue(c.rk.mrb); arg.Type() {
+	case mruby.TypeProc:
+		newLabelCollectorObj, err := c.rk.classes.LabelCollector.New(args...)
+		if err != nil {
+			return nil, err
+		}
+
+		if err := newLabelCollectorObj.vars.eval(); err != nil {
+			return nil, err
+		}
+
+		o.labels = newLabelCollectorObj.vars.labels
+	case mruby.TypeString:
+		exprs, err := parseLabelExpressions(arg.String())
+		if err != nil {
+			return nil, err
+		}
+		o.labels = exprs
+	default:
+		return nil, fmt.Errorf("Argument must be a block or a string")
+	}
+
+	if _, err := o.selector(); err != nil {
+		return nil, err
+	}
+
+	return o, nil
+}
+
+//go:generate gotemplate "./templates/basic" "labelSelectorClass(\"LabelSelector\", newLabelSelectorClassInstanceVars, labelSelectorClassInstanceVars)"
+
+func (e labelExpression) String() string {
+	switch e.operator {
+	case "":
+		return e.key
+	case "!":
+		return "!" + e.key
+	case "=", "!=":
+		return fmt.Sprintf("%s%s%s", e.key, e.operator, strings.Join(e.values, ""))
+	case "gt":
+		return fmt.Sprintf("%s>%s", e.key, strings.Join(e.values, ""))
+	case "lt":
+		return fmt.Sprintf("%s<%s", e.key, strings.Join(e.values, ""))
+	default:
+		return fmt.Sprintf("%s %s (%s)", e.key, e.operator, strings.Join(e.values, ", "))
+	}
+}
+
+func (o *labelSelectorClassInstanceVars) String() string {
+	labels := []string{}
+	for _, e := range o.labels {
+		labels = append(labels, e.String())
+	}
+	return strings.Join(labels, ",")
+}
+
+// selector validates the expressions with the same parser API server uses, so that
+// invalid selectors fail on the client
+func (o *labelSelectorClassInstanceVars) selector() (labels.Selector, error) {
+	s := o.String()
+	selector, err := labels.Parse(s)
+	if err != nil {
+		return nil, fmt.Errorf("invalid label selector %q – %v", s, err)
+	}
+	return selector, nil
+}
+
+// labelSelector converts the expressions to the API type, as used in `spec.selector` of most workloads
+func (o *labelSelectorClassInstanceVars) labelSelector() (*metav1.LabelSelector, error) {
+	if _, err := o.selector(); err != nil {
+		return nil, err
+	}
+
+	ls := &metav1.LabelSelector{}
+	for _, e := range o.labels {
+		switch e.operator {
+		case "=":
+			if ls.MatchLabels == nil {
+				ls.MatchLabels = map[string]string{}
+			}
+			ls.MatchLabels[e.key] = e.values[0]
+		case "":
+			ls.MatchExpressions = append(ls.MatchExpressions, metav1.LabelSelectorRequirement{
+				Key: e.key, Operator: metav1.LabelSelectorOpExists,
+			})
+		case "!":
+			ls.MatchExpressions = append(ls.MatchExpressions, metav1.LabelSelectorRequirement{
+				Key: e.key, Operator: metav1.LabelSelectorOpDoesNotExist,
+			})
+		case "in":
+			ls.MatchExpressions = append(ls.MatchExpressions, metav1.LabelSelectorRequirement{
+				Key: e.key, Operator: metav1.LabelSelectorOpIn, Values: e.values,
+			})
+		case "!=", "notin":
+			ls.MatchExpressions = append(ls.MatchExpressions, metav1.LabelSelectorRequirement{
+				Key: e.key, Operator: metav1.LabelSelectorOpNotIn, Values: e.values,
+			})
+		default:
+			return nil, fmt.Errorf("label selector operator %q cannot be represented in `matchExpressions`", e.operator)
+		}
+	}
+	return ls, nil
+}
+
+// parseLabelExpressions turns a selector string into expressions, so `make_label_selector` also accepts strings
+func parseLabelExpressions(s string) ([]labelExpression, error) {
+	selector, err := labels.Parse(s)
+	if err != nil {
+		return nil, fmt.Errorf("invalid label selector %q – %v", s, err)
+	}
+
+	requirements, _ := selector.Requirements()
+	exprs := []labelExpression{}
+	for _, r := range requirements {
+		e := labelExpression{key: r.Key(), values: r.Values().List()}
+		switch r.Operator() {
+		case selection.Exists:
+			e.operator = ""
+		case selection.DoesNotExist:
+			e.operator = "!"
+		case selection.Equals, selection.DoubleEquals:
+			e.operator = "="
+		case selection.NotEquals:
+			e.operator = "!="
+		case selection.In:
+			e.operator = "in"
+		case selection.NotIn:
+			e.operator = "notin"
+		case selection.GreaterThan:
+			e.operator = "gt"
+		case selection.LessThan:
+			e.operator = "lt"
+		default:
+			return nil, fmt.Errorf("unsupported label selector operator %q", r.Operator())
+		}
+		exprs = append(exprs, e)
+	}
+	return exprs, nil
+}
+
+// NewFromLabelSelector makes a `LabelSelector` object from `spec.selector` of a workload
+func (c *labelSelectorClass) NewFromLabelSelector(ls *metav1.LabelSelector) (*labelSelectorClassInstance, error) {
+	selector, err := metav1.LabelSelectorAsSelector(ls)
 	if err != nil {
 		return nil, err
 	}
 
-	if err := newLabelCollectorObj.vars.eval(); err != nil {
+	exprs, err := parseLabelExpressions(selector.String())
+	if err != nil {
+		return nil, err
+	}
+
+	newLabelSelectorObj, err := c.New()
+	if err != nil {
 		return nil, err
 	}
+	newLabelSelectorObj.vars.labels = exprs
 
-	return &labelSelectorClassInstanceVars{newLabelCollectorObj}, nil
+	return newLabelSelectorObj, nil
 }
 
-//go:generate gotemplate "./templates/basic" "labelSelectorClass(\"LabelSelector\", newLabelSelectorClassInstanceVars, labelSelectorClassInstanceVars)"
+// labelsOf returns labels of a resource object, or a plain hash of labels
+func labelsOf(arg *mruby.MrbValue) (labels.Set, error) {
+	set := labels.Set{}
+
+	hash := arg
+	if arg.Type() != mruby.TypeHash {
+		results, err := arg.Call("jsonpath", arg.Mrb().StringValue("{.metadata.labels}"))
+		if err != nil {
+			return nil, fmt.Errorf("argument must be a hash of labels or a resource object – %v", err)
+		}
+		if results.Array().Len() == 0 {
+			return set, nil
+		}
+		if hash, err = results.Array().Get(0); err != nil {
+			return nil, err
+		}
+		if hash.Type() == mruby.TypeNil {
+			return set, nil
+		}
+	}
+
+	if err := iterateHash(hash, func(key, value *mruby.MrbValue) error {
+		set[key.String()] = value.String()
+		return nil
+	}); err != nil {
+		return nil, err
+	}
+
+	return set, nil
+}
 
 func (c *labelSelectorClass) defineOwnMethods() {
 	c.rk.appendMethods(c.class, map[string]methodDefintion{
@@ -36,19 +226,87 @@ func (c *labelSelectorClass) defineOwnMethods() {
 			mruby.ArgsReq(0), func(m *mruby.Mrb, self *mruby.MrbValue) (mruby.Value, mruby.Value) {
 				vars, err := c.LookupVars(self)
 				if err != nil {
-					return nil, createException(m, err.Error())
+					return nil, createError(m, err)
+				}
+
+				return m.StringValue(vars.String()), nil
+			},
+			instanceMethod,
+		},
+		"inspect": {
+			mruby.ArgsReq(0), func(m *mruby.Mrb, self *mruby.MrbValue) (mruby.Value, mruby.Value) {
+				vars, err := c.LookupVars(self)
+				if err != nil {
+					return nil, createError(m, err)
+				}
+
+				return m.StringValue(fmt.Sprintf("#<LabelSelector %q>", vars.String())), nil
+			},
+			instanceMethod,
+		},
+		"to_ruby": {
+			mruby.ArgsReq(0), func(m *mruby.Mrb, self *mruby.MrbValue) (mruby.Value, mruby.Value) {
+				vars, err := c.LookupVars(self)
+				if err != nil {
+					return nil, createError(m, err)
+				}
+
+				ls, err := vars.labelSelector()
+				if err != nil {
+					return nil, createError(m, err)
 				}
 
-				labels := []string{}
-				for _, e := range vars.collector.vars.labels {
-					if e.operator != "" {
-						labels = append(labels, fmt.Sprintf("%s %s (%s)", e.key, e.operator, strings.Join(e.values, ", ")))
-					} else {
-						labels = append(labels, e.key)
-					}
+				rbconv := converter.New(m)
+				if err := rbconv.Convert(ls); err != nil {
+					return nil, createError(m, err)
 				}
 
-				return m.StringValue(strings.Join(labels, ",")), nil
+				return rbconv.Value(), nil
+			},
+			instanceMethod,
+		},
+		"to_json": {
+			mruby.ArgsReq(0), func(m *mruby.Mrb, self *mruby.MrbValue) (mruby.Value, mruby.Value) {
+				vars, err := c.LookupVars(self)
+				if err != nil {
+					return nil, createError(m, err)
+				}
+
+				ls, err := vars.labelSelector()
+				if err != nil {
+					return nil, createError(m, err)
+				}
+
+				return marshalToJSON(ls, m)
+			},
+			instanceMethod,
+		},
+		"matches?": {
+			mruby.ArgsReq(1), func(m *mruby.Mrb, self *mruby.MrbValue) (mruby.Value, mruby.Value) {
+				vars, err := c.LookupVars(self)
+				if err != nil {
+					return nil, createError(m, err)
+				}
+
+				args := m.GetArgs()
+				if err := standardCheck(c.rk, args, 1); err != nil {
+					return nil, createError(m, err)
+				}
+
+				selector, err := vars.selector()
+				if err != nil {
+					return nil, createError(m, err)
+				}
+
+				set, err := labelsOf(args[0])
+				if err != nil {
+					return nil, createError(m, err)
+				}
+
+				if selector.Matches(set) {
+					return m.TrueValue(), nil
+				}
+				return m.FalseValue(), nil
 			},
 			instanceMethod,
 		},
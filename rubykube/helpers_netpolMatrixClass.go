@@ -0,0 +1,134 @@
+package rubykube
+
+import (
+	"fmt"
+	"strings"
+
+	mruby "github.com/mitchellh/go-mruby"
+)
+
+type netpolMatrixClassInstanceVars struct {
+	matrix *netpolMatrix
+}
+
+func newNetpolMatrixClassInstanceVars(c *netpolMatrixClass, s *mruby.MrbValue, args ...mruby.Value) (*netpolMatrixClassInstanceVars, error) {
+	return &netpolMatrixClassInstanceVars{matrix: &netpolMatrix{}}, nil
+}
+
+//go:generate gotemplate "./templates/basic" "netpolMatrixClass(\"NetpolMatrix\", newNetpolMatrixClassInstanceVars, netpolMatrixClassInstanceVars)"
+
+func (c *netpolMatrixClass) defineOwnMethods() {
+	c.rk.appendMethods(c.class, map[string]methodDefintion{
+		"to_s": {
+			mruby.ArgsNone(), func(m *mruby.Mrb, self *mruby.MrbValue) (mruby.Value, mruby.Value) {
+				vars, err := c.LookupVars(self)
+				if err != nil {
+					return nil, createError(m, err)
+				}
+
+				return m.StringValue(vars.matrix.String()), nil
+			},
+			instanceMethod,
+		},
+		"inspect": {
+			mruby.ArgsNone(), func(m *mruby.Mrb, self *mruby.MrbValue) (mruby.Value, mruby.Value) {
+				vars, err := c.LookupVars(self)
+				if err != nil {
+					return nil, createError(m, err)
+				}
+
+				return m.StringValue(fmt.Sprintf("#<NetpolMatrix namespace=%s groups=%d policies=%d>\n%s",
+					vars.matrix.Namespace, len(vars.matrix.Groups), len(vars.matrix.Policies), strings.TrimSuffix(vars.matrix.String(), "\n"))), nil
+			},
+			instanceMethod,
+		},
+		"pager": {
+			mruby.ArgsNone(), func(m *mruby.Mrb, self *mruby.MrbValue) (mruby.Value, mruby.Value) {
+				vars, err := c.LookupVars(self)
+				if err != nil {
+					return nil, createError(m, err)
+				}
+
+				if err := c.rk.page(vars.matrix.String()); err != nil {
+					return nil, createError(m, err)
+				}
+				return nil, nil
+			},
+			instanceMethod,
+		},
+		"probe!": {
+			mruby.ArgsReq(0) | mruby.ArgsOpt(1), func(m *mruby.Mrb, self *mruby.MrbValue) (mruby.Value, mruby.Value) {
+				vars, err := c.LookupVars(self)
+				if err != nil {
+					return nil, createError(m, err)
+				}
+
+				options, err := parseProbeOptions(m.GetArgs())
+				if err != nil {
+					return nil, createError(m, err)
+				}
+
+				if err := c.rk.probe(vars.matrix, options); err != nil {
+					return nil, createError(m, err)
+				}
+				return self, nil
+			},
+			mutatingMethod,
+		},
+		"differences": {
+			mruby.ArgsNone(), func(m *mruby.Mrb, self *mruby.MrbValue) (mruby.Value, mruby.Value) {
+				vars, err := c.LookupVars(self)
+				if err != nil {
+					return nil, createError(m, err)
+				}
+
+				value, err := nativeRubyValueOf(m, vars.matrix.differences())
+				if err != nil {
+					return nil, createError(m, err)
+				}
+				return value, nil
+			},
+			instanceMethod,
+		},
+		"ok?": {
+			mruby.ArgsNone(), func(m *mruby.Mrb, self *mruby.MrbValue) (mruby.Value, mruby.Value) {
+				vars, err := c.LookupVars(self)
+				if err != nil {
+					return nil, createError(m, err)
+				}
+
+				if vars.matrix.Probed && len(vars.matrix.differences()) == 0 {
+					return m.TrueValue(), nil
+				}
+				return m.FalseValue(), nil
+			},
+			instanceMethod,
+		},
+		"to_json": {
+			mruby.ArgsNone(), func(m *mruby.Mrb, self *mruby.MrbValue) (mruby.Value, mruby.Value) {
+				vars, err := c.LookupVars(self)
+				if err != nil {
+					return nil, createError(m, err)
+				}
+
+				return marshalToJSON(vars.matrix, m)
+			},
+			instanceMethod,
+		},
+		"to_ruby": {
+			mruby.ArgsNone(), func(m *mruby.Mrb, self *mruby.MrbValue) (mruby.Value, mruby.Value) {
+				vars, err := c.LookupVars(self)
+				if err != nil {
+					return nil, createError(m, err)
+				}
+
+				value, err := nativeRubyValueOf(m, vars.matrix)
+				if err != nil {
+					return nil, createError(m, err)
+				}
+				return value, nil
+			},
+			instanceMethod,
+		},
+	})
+}
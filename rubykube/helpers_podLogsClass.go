@@ -6,6 +6,8 @@ import (
 	"fmt"
 	"io"
 	"regexp"
+	"sort"
+	"strings"
 
 	mruby "github.com/mitchellh/go-mruby"
 	corev1 "k8s.io/api/core/v1"
@@ -20,6 +22,54 @@ func newPodLogsClassInstanceVars(c *podLogsClass, s *mruby.MrbValue, args ...mru
 	return &podLogsClassInstanceVars{logs: make(map[string]*bytes.Buffer)}, nil
 }
 
+// eachLine calls fn for every line of the logs, sorted by container name; buffers are not consumed,
+// so logs can be read more than once
+func (vars *podLogsClassInstanceVars) eachLine(fn func(name, line string)) error {
+	names := []string{}
+	for name := range vars.logs {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		scanner := bufio.NewScanner(bytes.NewReader(vars.logs[name].Bytes()))
+		for scanner.Scan() {
+			fn(name, scanner.Text())
+		}
+		if err := scanner.Err(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// String returns all lines prefixed with container names
+func (vars *podLogsClassInstanceVars) String() (string, error) {
+	text := bytes.Buffer{}
+	err := vars.eachLine(func(name, line string) {
+		fmt.Fprintf(&text, "[%s] %s\n", name, line)
+	})
+	return text.String(), err
+}
+
+// fetchLogs reads logs of a container into a buffer, options select the container and
+// which part of the logs to read
+func (rk *RubyKube) fetchLogs(pod *corev1.Pod, options *corev1.PodLogOptions) (*bytes.Buffer, error) {
+	if rk.offline != nil {
+		return rk.offline.logs(pod, options)
+	}
+
+	stream, err := rk.clientset.Core().Pods(pod.ObjectMeta.Namespace).GetLogs(pod.ObjectMeta.Name, options).Stream()
+	if err != nil {
+		return nil, err
+	}
+	defer stream.Close()
+
+	buffer := &bytes.Buffer{}
+	_, err = io.Copy(buffer, stream)
+	return buffer, err
+}
+
 //go:generate gotemplate "./templates/basic" "podLogsClass(\"PodLogs\", newPodLogsClassInstanceVars, podLogsClassInstanceVars)"
 
 func (c *podLogsClass) defineOwnMethods() {
@@ -28,35 +78,66 @@ func (c *podLogsClass) defineOwnMethods() {
 			mruby.ArgsNone(), func(m *mruby.Mrb, self *mruby.MrbValue) (mruby.Value, mruby.Value) {
 				vars, err := c.LookupVars(self)
 				if err != nil {
-					return nil, createException(m, err.Error())
+					return nil, createError(m, err)
 				}
 
-				for _, pod := range vars.pods {
-					logBuffer := bytes.Buffer{}
+				type logRequest struct {
+					pod       *corev1.Pod
+					container string
+				}
 
-					for _, container := range pod.Spec.Containers {
-						name := fmt.Sprintf("%s/%s:%s", pod.ObjectMeta.Namespace, pod.ObjectMeta.Name, container.Name)
-						stream, err := c.rk.clientset.Core().Pods(pod.ObjectMeta.Namespace).GetLogs(pod.ObjectMeta.Name, &corev1.PodLogOptions{Container: container.Name}).Stream()
-						if err != nil {
-							return nil, createException(m, err.Error())
-						}
-						defer stream.Close()
-						io.Copy(&logBuffer, stream)
-						vars.logs[name] = &logBuffer
+				requests := []logRequest{}
+				for i := range vars.pods {
+					for _, container := range vars.pods[i].Spec.Containers {
+						requests = append(requests, logRequest{&vars.pods[i], container.Name})
 					}
 				}
+
+				// logs are fetched concurrently, each request writes only to its own buffer
+				buffers := make([]*bytes.Buffer, len(requests))
+				if err := c.rk.parallel(len(requests), func(i int) error {
+					buffer, err := c.rk.fetchLogs(requests[i].pod, &corev1.PodLogOptions{Container: requests[i].container})
+					buffers[i] = buffer
+					return err
+				}); err != nil {
+					return nil, createError(m, err)
+				}
+
+				for i, r := range requests {
+					name := fmt.Sprintf("%s/%s:%s", r.pod.ObjectMeta.Namespace, r.pod.ObjectMeta.Name, r.container)
+					vars.logs[name] = buffers[i]
+				}
 				return self, nil
 			},
 			instanceMethod,
 		},
 		"to_s": {
 			mruby.ArgsNone(), func(m *mruby.Mrb, self *mruby.MrbValue) (mruby.Value, mruby.Value) {
-				//vars, err := c.LookupVars(self)
-				//if err != nil {
-				//	return nil, createException(m, err.Error())
-				//}
+				vars, err := c.LookupVars(self)
+				if err != nil {
+					return nil, createError(m, err)
+				}
+
+				text, err := vars.String()
+				if err != nil {
+					return nil, createError(m, err)
+				}
+				return m.StringValue(text), nil
+			},
+			instanceMethod,
+		},
+		"inspect": {
+			mruby.ArgsNone(), func(m *mruby.Mrb, self *mruby.MrbValue) (mruby.Value, mruby.Value) {
+				vars, err := c.LookupVars(self)
+				if err != nil {
+					return nil, createError(m, err)
+				}
 
-				return self, nil
+				text, err := vars.String()
+				if err != nil {
+					return nil, createError(m, err)
+				}
+				return m.StringValue(fmt.Sprintf("#<PodLogs containers=%d>\n%s", len(vars.logs), strings.TrimSuffix(text, "\n"))), nil
 			},
 			instanceMethod,
 		},
@@ -64,20 +145,64 @@ func (c *podLogsClass) defineOwnMethods() {
 			mruby.ArgsNone(), func(m *mruby.Mrb, self *mruby.MrbValue) (mruby.Value, mruby.Value) {
 				vars, err := c.LookupVars(self)
 				if err != nil {
-					return nil, createException(m, err.Error())
+					return nil, createError(m, err)
+				}
+
+				text, err := vars.String()
+				if err != nil {
+					return nil, createError(m, err)
+				}
+				fmt.Print(text)
+				return nil, nil
+			},
+			instanceMethod,
+		},
+		"pager": {
+			mruby.ArgsNone(), func(m *mruby.Mrb, self *mruby.MrbValue) (mruby.Value, mruby.Value) {
+				vars, err := c.LookupVars(self)
+				if err != nil {
+					return nil, createError(m, err)
+				}
+
+				text, err := vars.String()
+				if err != nil {
+					return nil, createError(m, err)
+				}
+				if err := c.rk.page(text); err != nil {
+					return nil, createError(m, err)
+				}
+				return nil, nil
+			},
+			instanceMethod,
+		},
+		"match?": {
+			mruby.ArgsReq(1), func(m *mruby.Mrb, self *mruby.MrbValue) (mruby.Value, mruby.Value) {
+				vars, err := c.LookupVars(self)
+				if err != nil {
+					return nil, createError(m, err)
+				}
+
+				args := m.GetArgs()
+				if len(args) != 1 || args[0].Type() != mruby.TypeString {
+					return nil, createException(m, "First argument must be a string")
+				}
+
+				re, err := regexp.Compile(args[0].String())
+				if err != nil {
+					return nil, createError(m, err)
 				}
 
-				for name, logBuffer := range vars.logs {
-					scanner := bufio.NewScanner(logBuffer)
+				// read a copy of each buffer, so that logs can be matched more than once
+				for _, logBuffer := range vars.logs {
+					scanner := bufio.NewScanner(bytes.NewReader(logBuffer.Bytes()))
 					for scanner.Scan() {
-						fmt.Printf("[%s] %s\n", name, scanner.Text())
-					}
-					if err := scanner.Err(); err != nil {
-						return nil, createException(m, err.Error())
+						if re.MatchString(scanner.Text()) {
+							return m.TrueValue(), nil
+						}
 					}
 				}
 
-				return self, nil
+				return m.FalseValue(), nil
 			},
 			instanceMethod,
 		},
@@ -85,7 +210,7 @@ func (c *podLogsClass) defineOwnMethods() {
 			mruby.ArgsNone(), func(m *mruby.Mrb, self *mruby.MrbValue) (mruby.Value, mruby.Value) {
 				vars, err := c.LookupVars(self)
 				if err != nil {
-					return nil, createException(m, err.Error())
+					return nil, createError(m, err)
 				}
 
 				args := m.GetArgs()
@@ -99,36 +224,34 @@ func (c *podLogsClass) defineOwnMethods() {
 					if arg.Type() == mruby.TypeString {
 						re, err := regexp.Compile(arg.String())
 						if err != nil {
-							return nil, createException(m, err.Error())
+							return nil, createError(m, err)
 						}
 						matchAgainst = append(matchAgainst, re)
 					}
 				}
 
-				grep := func(name string, logBuffer *bytes.Buffer) error {
-					scanner := bufio.NewScanner(logBuffer)
-					for scanner.Scan() {
-						line := scanner.Text()
-						for _, re := range matchAgainst {
-							if re.MatchString(line) {
-								fmt.Printf("[%s] %s\n", name, line)
-							}
-						}
-					}
-
-					if err := scanner.Err(); err != nil {
-						return err
-					}
-					return nil
+				// matching lines are kept in a new object, so the result can be printed, captured or grepped again
+				newPodLogsObj, err := c.New()
+				if err != nil {
+					return nil, createError(m, err)
 				}
+				newPodLogsObj.vars.pods = vars.pods
 
-				for name, logBuffer := range vars.logs {
-					if err := grep(name, logBuffer); err != nil {
-						return nil, createException(m, err.Error())
+				if err := vars.eachLine(func(name, line string) {
+					for _, re := range matchAgainst {
+						if re.MatchString(line) {
+							if _, ok := newPodLogsObj.vars.logs[name]; !ok {
+								newPodLogsObj.vars.logs[name] = &bytes.Buffer{}
+							}
+							fmt.Fprintln(newPodLogsObj.vars.logs[name], line)
+							return
+						}
 					}
+				}); err != nil {
+					return nil, createError(m, err)
 				}
 
-				return self, nil
+				return newPodLogsObj.self, nil
 			},
 			instanceMethod,
 		},
@@ -1,9 +1,6 @@
 package rubykube
 
 import (
-	"fmt"
-	"strings"
-
 	mruby "github.com/mitchellh/go-mruby"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	corev1 "k8s.io/api/core/v1"
@@ -25,12 +22,12 @@ func (c *podMakerClass) defineOwnMethods() {
 			mruby.ArgsReq(1), func(m *mruby.Mrb, self *mruby.MrbValue) (mruby.Value, mruby.Value) {
 				vars, err := c.LookupVars(self)
 				if err != nil {
-					return nil, createException(m, err.Error())
+					return nil, createError(m, err)
 				}
 
 				args := m.GetArgs()
 				if err := standardCheck(c.rk, args, 1); err != nil {
-					return nil, createException(m, err.Error())
+					return nil, createError(m, err)
 				}
 
 				if args[0].Type() != mruby.TypeHash {
@@ -48,12 +45,13 @@ func (c *podMakerClass) defineOwnMethods() {
 				)
 
 				if err != nil {
-					return nil, createException(m, err.Error())
+					return nil, createError(m, err)
 				}
 
 				stringParams := stringParamsCol.ToMapOfStrings()
 
-				hashParamsCol, err := NewParamsCollection(args[0],
+				// labels, env and command are only validated for now
+				_, err = NewParamsCollection(args[0],
 					params{
 						allowed:   []string{"labels", "env"},
 						required:  []string{},
@@ -63,10 +61,10 @@ func (c *podMakerClass) defineOwnMethods() {
 				)
 
 				if err != nil {
-					return nil, createException(m, err.Error())
+					return nil, createError(m, err)
 				}
 
-				arrayParamsCol, err := NewParamsCollection(args[0],
+				_, err = NewParamsCollection(args[0],
 					params{
 						allowed:   []string{"command"},
 						required:  []string{},
@@ -76,41 +74,20 @@ func (c *podMakerClass) defineOwnMethods() {
 				)
 
 				if err != nil {
-					return nil, createException(m, err.Error())
+					return nil, createError(m, err)
 				}
 
-				fmt.Printf("stringParams=%+v\nhashParams=%+v\narrayParams=%+v\n", stringParams, hashParamsCol.ToMapOfMapsOfStrings(), arrayParamsCol.ToMapOfSlicesOfStrings())
-
-				container := corev1.Container{}
-				var name string
-
-				// `hashArgsToSimpleMap` will validate that "image" key was given, so we don't need to
-				// check for it; we try to split it into parts to determine the name automatically
-				container.Image = stringParams["image"]
-				imageParts := strings.Split(strings.Split(container.Image, ":")[0], "/")
-				name = imageParts[len(imageParts)-1]
-
-				// if name was given, use it to override automatic name we determined from the image
-				if v, ok := stringParams["name"]; ok {
-					name = v
+				if _, err := parseImageRef(stringParams["image"]); err != nil {
+					return nil, createError(m, newArgumentError("%v", err))
 				}
 
-				container.Name = name
-				labels := map[string]string{"name": name}
+				// `hashArgsToSimpleMap` will validate that "image" key was given, so we don't need to
+				// check for it; if name was given, it overrides automatic name determined from the image
+				pod := newPod(stringParams["image"], stringParams["name"])
 
 				newPodObj, err := c.rk.classes.Pod.New()
 				if err != nil {
-					return nil, createException(m, err.Error())
-				}
-
-				pod := corev1.Pod{
-					ObjectMeta: metav1.ObjectMeta{
-						Name:   name,
-						Labels: labels,
-					},
-					Spec: corev1.PodSpec{
-						Containers: []corev1.Container{container},
-					},
+					return nil, createError(m, err)
 				}
 
 				if v, ok := stringParams["namespace"]; ok {
@@ -130,3 +107,23 @@ func (c *podMakerClass) defineOwnMethods() {
 func (o *podMakerClassInstance) Update(args ...mruby.Value) (mruby.Value, error) {
 	return nil, nil
 }
+
+// newPod makes a pod with a single container, which is what `make_pod` does; the name is determined
+// from the repository of the image (e.g. `foo` for `errordeveloper/foo:latest`), unless it's given
+func newPod(image, name string) corev1.Pod {
+	if name == "" {
+		if ref, err := parseImageRef(image); err == nil {
+			name = ref.shortName()
+		}
+	}
+
+	return corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:   name,
+			Labels: map[string]string{"name": name},
+		},
+		Spec: corev1.PodSpec{
+			Containers: []corev1.Container{{Name: name, Image: image}},
+		},
+	}
+}
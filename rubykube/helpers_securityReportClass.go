@@ -0,0 +1,132 @@
+package rubykube
+
+import (
+	"fmt"
+	"strings"
+
+	mruby "github.com/mitchellh/go-mruby"
+)
+
+type securityReportClassInstanceVars struct {
+	report *securityReport
+}
+
+func newSecurityReportClassInstanceVars(c *securityReportClass, s *mruby.MrbValue, args ...mruby.Value) (*securityReportClassInstanceVars, error) {
+	return &securityReportClassInstanceVars{report: &securityReport{}}, nil
+}
+
+// minimumSeverity takes an optional severity, findings of lower severities are ignored
+func minimumSeverity(args []*mruby.MrbValue) (string, error) {
+	if len(args) == 0 {
+		return "", nil
+	}
+	return severityArg(args[0])
+}
+
+//go:generate gotemplate "./templates/basic" "securityReportClass(\"SecurityReport\", newSecurityReportClassInstanceVars, securityReportClassInstanceVars)"
+
+func (c *securityReportClass) defineOwnMethods() {
+	c.rk.appendMethods(c.class, map[string]methodDefintion{
+		"to_s": {
+			mruby.ArgsNone(), func(m *mruby.Mrb, self *mruby.MrbValue) (mruby.Value, mruby.Value) {
+				vars, err := c.LookupVars(self)
+				if err != nil {
+					return nil, createError(m, err)
+				}
+
+				return m.StringValue(vars.report.String()), nil
+			},
+			instanceMethod,
+		},
+		"inspect": {
+			mruby.ArgsNone(), func(m *mruby.Mrb, self *mruby.MrbValue) (mruby.Value, mruby.Value) {
+				vars, err := c.LookupVars(self)
+				if err != nil {
+					return nil, createError(m, err)
+				}
+
+				return m.StringValue(fmt.Sprintf("#<SecurityReport objects=%d findings=%d>\n%s", len(vars.report.Objects), len(vars.report.Findings), strings.TrimSuffix(vars.report.String(), "\n"))), nil
+			},
+			instanceMethod,
+		},
+		"pager": {
+			mruby.ArgsNone(), func(m *mruby.Mrb, self *mruby.MrbValue) (mruby.Value, mruby.Value) {
+				vars, err := c.LookupVars(self)
+				if err != nil {
+					return nil, createError(m, err)
+				}
+
+				if err := c.rk.page(vars.report.String()); err != nil {
+					return nil, createError(m, err)
+				}
+				return nil, nil
+			},
+			instanceMethod,
+		},
+		"findings": {
+			mruby.ArgsReq(0) | mruby.ArgsOpt(1), func(m *mruby.Mrb, self *mruby.MrbValue) (mruby.Value, mruby.Value) {
+				vars, err := c.LookupVars(self)
+				if err != nil {
+					return nil, createError(m, err)
+				}
+
+				severity, err := minimumSeverity(m.GetArgs())
+				if err != nil {
+					return nil, createError(m, err)
+				}
+
+				value, err := nativeRubyValueOf(m, vars.report.findings(severity))
+				if err != nil {
+					return nil, createError(m, err)
+				}
+				return value, nil
+			},
+			instanceMethod,
+		},
+		"ok?": {
+			mruby.ArgsReq(0) | mruby.ArgsOpt(1), func(m *mruby.Mrb, self *mruby.MrbValue) (mruby.Value, mruby.Value) {
+				vars, err := c.LookupVars(self)
+				if err != nil {
+					return nil, createError(m, err)
+				}
+
+				severity, err := minimumSeverity(m.GetArgs())
+				if err != nil {
+					return nil, createError(m, err)
+				}
+
+				if len(vars.report.findings(severity)) == 0 {
+					return m.TrueValue(), nil
+				}
+				return m.FalseValue(), nil
+			},
+			instanceMethod,
+		},
+		"to_json": {
+			mruby.ArgsNone(), func(m *mruby.Mrb, self *mruby.MrbValue) (mruby.Value, mruby.Value) {
+				vars, err := c.LookupVars(self)
+				if err != nil {
+					return nil, createError(m, err)
+				}
+
+				return marshalToJSON(vars.report, m)
+			},
+			instanceMethod,
+		},
+		"to_ruby": {
+			mruby.ArgsNone(), func(m *mruby.Mrb, self *mruby.MrbValue) (mruby.Value, mruby.Value) {
+				vars, err := c.LookupVars(self)
+				if err != nil {
+					return nil, createError(m, err)
+				}
+
+				value, err := nativeRubyValueOf(m, vars.report)
+				if err != nil {
+					return nil, createError(m, err)
+				}
+				return value, nil
+			},
+			instanceMethod,
+		},
+	})
+}
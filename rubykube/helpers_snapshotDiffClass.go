@@ -0,0 +1,99 @@
+package rubykube
+
+import (
+	"fmt"
+	"strings"
+
+	mruby "github.com/mitchellh/go-mruby"
+)
+
+type snapshotDiffClassInstanceVars struct {
+	diff *snapshotDiff
+}
+
+func newSnapshotDiffClassInstanceVars(c *snapshotDiffClass, s *mruby.MrbValue, args ...mruby.Value) (*snapshotDiffClassInstanceVars, error) {
+	return &snapshotDiffClassInstanceVars{diff: &snapshotDiff{}}, nil
+}
+
+//go:generate gotemplate "./templates/basic" "snapshotDiffClass(\"SnapshotDiff\", newSnapshotDiffClassInstanceVars, snapshotDiffClassInstanceVars)"
+
+func (c *snapshotDiffClass) defineOwnMethods() {
+	c.rk.appendMethods(c.class, map[string]methodDefintion{
+		"to_s": {
+			mruby.ArgsNone(), func(m *mruby.Mrb, self *mruby.MrbValue) (mruby.Value, mruby.Value) {
+				vars, err := c.LookupVars(self)
+				if err != nil {
+					return nil, createError(m, err)
+				}
+
+				return m.StringValue(vars.diff.String()), nil
+			},
+			instanceMethod,
+		},
+		"inspect": {
+			mruby.ArgsNone(), func(m *mruby.Mrb, self *mruby.MrbValue) (mruby.Value, mruby.Value) {
+				vars, err := c.LookupVars(self)
+				if err != nil {
+					return nil, createError(m, err)
+				}
+
+				return m.StringValue(fmt.Sprintf("#<SnapshotDiff added=%d removed=%d changed=%d>\n%s", len(vars.diff.Added), len(vars.diff.Removed), len(vars.diff.Changed), strings.TrimSuffix(vars.diff.String(), "\n"))), nil
+			},
+			instanceMethod,
+		},
+		"pager": {
+			mruby.ArgsNone(), func(m *mruby.Mrb, self *mruby.MrbValue) (mruby.Value, mruby.Value) {
+				vars, err := c.LookupVars(self)
+				if err != nil {
+					return nil, createError(m, err)
+				}
+
+				if err := c.rk.page(vars.diff.String()); err != nil {
+					return nil, createError(m, err)
+				}
+				return nil, nil
+			},
+			instanceMethod,
+		},
+		"empty?": {
+			mruby.ArgsNone(), func(m *mruby.Mrb, self *mruby.MrbValue) (mruby.Value, mruby.Value) {
+				vars, err := c.LookupVars(self)
+				if err != nil {
+					return nil, createError(m, err)
+				}
+
+				if vars.diff.empty() {
+					return m.TrueValue(), nil
+				}
+				return m.FalseValue(), nil
+			},
+			instanceMethod,
+		},
+		"to_json": {
+			mruby.ArgsNone(), func(m *mruby.Mrb, self *mruby.MrbValue) (mruby.Value, mruby.Value) {
+				vars, err := c.LookupVars(self)
+				if err != nil {
+					return nil, createError(m, err)
+				}
+
+				return marshalToJSON(vars.diff, m)
+			},
+			instanceMethod,
+		},
+		"to_ruby": {
+			mruby.ArgsNone(), func(m *mruby.Mrb, self *mruby.MrbValue) (mruby.Value, mruby.Value) {
+				vars, err := c.LookupVars(self)
+				if err != nil {
+					return nil, createError(m, err)
+				}
+
+				value, err := nativeRubyValueOf(m, vars.diff)
+				if err != nil {
+					return nil, createError(m, err)
+				}
+				return value, nil
+			},
+			instanceMethod,
+		},
+	})
+}
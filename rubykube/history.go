@@ -0,0 +1,243 @@
+package rubykube
+
+import (
+	"bufio"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	mruby "github.com/mitchellh/go-mruby"
+)
+
+// kubeplayDir holds files kubeplay keeps for the user, e.g. history
+var kubeplayDir = os.ExpandEnv("${HOME}/.kubeplay")
+
+var (
+	historyFile = flag.String("history", filepath.Join(kubeplayDir, "history"), "path to the history file, set to empty string to disable history")
+	recordFile  = flag.String("record", "", "write successfully evaluated code to `file`, so the session can be replayed with 'kubeplay <file>'")
+)
+
+// historySize is how many entries are kept in the history file
+const historySize = 1000
+
+// historyEntry is a chunk of code entered in the REPL along with the context it was evaluated in,
+// entries are stored in the history file as JSON, one per line
+type historyEntry struct {
+	Time      time.Time `json:"time"`
+	Context   string    `json:"context,omitempty"`
+	Namespace string    `json:"namespace"`
+	Code      string    `json:"code"`
+}
+
+type history struct {
+	entries []historyEntry
+	file    *os.File
+	record  *os.File
+	// rerun holds code of entries re-run with `history <n>` during current evaluation,
+	// it's recorded instead of the call to `history`, so that transcripts can be replayed
+	rerun []string
+	// current is the number of the entry being evaluated, so it cannot re-run itself
+	current int
+}
+
+func newHistory(path, record, context string) (*history, error) {
+	h := &history{entries: []historyEntry{}}
+
+	if path != "" {
+		if err := h.load(path); err != nil {
+			return nil, fmt.Errorf("cannot load history from %q – %v", path, err)
+		}
+	}
+
+	if record != "" {
+		f, err := os.Create(record)
+		if err != nil {
+			return nil, fmt.Errorf("cannot record session to %q – %v", record, err)
+		}
+		h.record = f
+		fmt.Fprintf(h.record, "# recorded by kubeplay on %s (context=%q)\n", time.Now().Format(time.RFC1123), context)
+	}
+
+	return h, nil
+}
+
+func (h *history) load(path string) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+		return err
+	}
+
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_RDWR|os.O_APPEND, 0600)
+	if err != nil {
+		return err
+	}
+
+	scanner := bufio.NewScanner(f)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		entry := historyEntry{}
+		// skip anything we cannot parse, it's only history after all
+		if err := json.Unmarshal(scanner.Bytes(), &entry); err != nil || entry.Code == "" {
+			continue
+		}
+		h.entries = append(h.entries, entry)
+	}
+	if err := scanner.Err(); err != nil {
+		f.Close()
+		return err
+	}
+
+	if len(h.entries) <= historySize {
+		h.file = f
+		return nil
+	}
+
+	f.Close()
+	h.entries = h.entries[len(h.entries)-historySize:]
+	return h.rewrite(path)
+}
+
+// rewrite truncates the history file to the entries we've kept
+func (h *history) rewrite(path string) error {
+	tmp := path + ".tmp"
+	f, err := os.OpenFile(tmp, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0600)
+	if err != nil {
+		return err
+	}
+
+	for _, entry := range h.entries {
+		if err := writeHistoryEntry(f, entry); err != nil {
+			f.Close()
+			return err
+		}
+	}
+	if err := f.Close(); err != nil {
+		return err
+	}
+
+	if err := os.Rename(tmp, path); err != nil {
+		return err
+	}
+
+	h.file, err = os.OpenFile(path, os.O_WRONLY|os.O_APPEND, 0600)
+	return err
+}
+
+func writeHistoryEntry(f *os.File, entry historyEntry) error {
+	data, err := json.Marshal(entry)
+	if err != nil {
+		return err
+	}
+	_, err = f.Write(append(data, '\n'))
+	return err
+}
+
+func (h *history) add(entry historyEntry) error {
+	h.entries = append(h.entries, entry)
+	if h.file == nil {
+		return nil
+	}
+	return writeHistoryEntry(h.file, entry)
+}
+
+// AddHistory stores a chunk of code along with current context and namespace,
+// it should be called before the code is evaluated
+func (rk *RubyKube) AddHistory(code string) error {
+	code = strings.TrimSpace(code)
+	if rk.history == nil || code == "" {
+		return nil
+	}
+
+	rk.history.rerun = nil
+	rk.history.current = len(rk.history.entries) + 1
+
+	return rk.history.add(historyEntry{
+		Time:      time.Now(),
+		Context:   rk.state.Context,
+		Namespace: rk.state.Namespace,
+		Code:      code,
+	})
+}
+
+// RecordEvaluated writes a chunk of code that has been evaluated successfully to the session
+// transcript, if `-record` was given
+func (rk *RubyKube) RecordEvaluated(code string) error {
+	if rk.history == nil || rk.history.record == nil {
+		return nil
+	}
+
+	code = strings.TrimSpace(code)
+	if rerun := rk.history.rerun; len(rerun) > 0 {
+		code = "# " + strings.Replace(code, "\n", "\n# ", -1) + "\n" + strings.Join(rerun, "\n")
+		rk.history.rerun = nil
+	}
+
+	_, err := fmt.Fprintln(rk.history.record, code)
+	return err
+}
+
+// seedReadline makes entries from previous sessions available with up and down arrows,
+// readline's history is line-based, so multi-line chunks are added line by line
+func (rk *RubyKube) seedReadline() {
+	if rk.history == nil || rk.readline == nil {
+		return
+	}
+	for _, entry := range rk.history.entries {
+		for _, line := range strings.Split(entry.Code, "\n") {
+			rk.readline.SaveHistory(line)
+		}
+	}
+}
+
+func (e historyEntry) format(n int) string {
+	lines := strings.Split(e.Code, "\n")
+	for i := range lines[1:] {
+		lines[i+1] = "\t\t" + lines[i+1]
+	}
+	return fmt.Sprintf("%5d  %s (context=%q, namespace=%q)\n\t\t%s", n, e.Time.Format("2006-01-02 15:04:05"), e.Context, e.Namespace, strings.Join(lines, "\n"))
+}
+
+// historyVerb lists history entries, optionally filtered by a substring, or re-runs an entry given its number
+func historyVerb(rk *RubyKube, args []*mruby.MrbValue, m *mruby.Mrb, self *mruby.MrbValue) (mruby.Value, mruby.Value) {
+	if rk.history == nil {
+		return nil, createException(m, "History is disabled")
+	}
+
+	filter := ""
+	if len(args) == 1 {
+		switch args[0].Type() {
+		case mruby.TypeFixnum:
+			n := args[0].Fixnum()
+			if n < 1 || n > len(rk.history.entries) || n == rk.history.current {
+				return nil, createException(m, fmt.Sprintf("No history entry %d", n))
+			}
+
+			code := rk.history.entries[n-1].Code
+			fmt.Println(code)
+
+			rk.history.rerun = append(rk.history.rerun, code)
+
+			value, err := rk.Run(code)
+			if err != nil {
+				return nil, createError(m, err)
+			}
+			return value, nil
+		case mruby.TypeString:
+			filter = args[0].String()
+		default:
+			return nil, createException(m, "Argument must be an entry number or a string to search for")
+		}
+	}
+
+	for i, entry := range rk.history.entries {
+		if filter != "" && !strings.Contains(entry.Code, filter) {
+			continue
+		}
+		fmt.Println(entry.format(i + 1))
+	}
+
+	return nil, nil
+}
@@ -0,0 +1,436 @@
+package rubykube
+
+import (
+	"bytes"
+	"fmt"
+	"path"
+	"regexp"
+	"sort"
+	"strings"
+	"text/tabwriter"
+
+	mruby "github.com/mitchellh/go-mruby"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+)
+
+const (
+	defaultRegistry = "docker.io"
+	officialImages  = "library"
+	defaultImageTag = "latest"
+)
+
+var (
+	imagePathComponent = regexp.MustCompile(`^[a-z0-9]+(?:(?:[._]|__|[-]*)[a-z0-9]+)*$`)
+	imageTag           = regexp.MustCompile(`^[\w][\w.-]{0,127}$`)
+	imageDigest        = regexp.MustCompile(`^[a-z0-9]+(?:[.+_-][a-z0-9]+)*:[a-fA-F0-9]{32,}$`)
+)
+
+// imageRef is a parsed image reference, images from Docker Hub are normalised the same way
+// Docker does it, so `nginx` and `docker.io/library/nginx` are the same repository
+type imageRef struct {
+	Registry   string `json:"registry"`
+	Repository string `json:"repository"`
+	Tag        string `json:"tag,omitempty"`
+	Digest     string `json:"digest,omitempty"`
+}
+
+// parseImageRef parses `[registry/]path[:tag][@digest]`, the first component of the path is a registry
+// only if it looks like a host name, i.e. has a dot or a port in it, or is `localhost`
+func parseImageRef(image string) (*imageRef, error) {
+	if image == "" {
+		return nil, fmt.Errorf("image reference is empty")
+	}
+
+	ref := &imageRef{Registry: defaultRegistry}
+	name := image
+
+	if i := strings.Index(name, "@"); i >= 0 {
+		name, ref.Digest = name[:i], name[i+1:]
+		if !imageDigest.MatchString(ref.Digest) {
+			return nil, fmt.Errorf("invalid digest %q in image reference %q", ref.Digest, image)
+		}
+	}
+
+	if i := strings.LastIndex(name, ":"); i > strings.LastIndex(name, "/") {
+		name, ref.Tag = name[:i], name[i+1:]
+		if !imageTag.MatchString(ref.Tag) {
+			return nil, fmt.Errorf("invalid tag %q in image reference %q", ref.Tag, image)
+		}
+	}
+
+	components := strings.Split(name, "/")
+	if len(components) > 1 && (strings.ContainsAny(components[0], ".:") || components[0] == "localhost") {
+		ref.Registry, components = components[0], components[1:]
+		if ref.Registry == "index.docker.io" {
+			ref.Registry = defaultRegistry
+		}
+	}
+	if ref.Registry == defaultRegistry && len(components) == 1 {
+		components = append([]string{officialImages}, components...)
+	}
+
+	for _, component := range components {
+		if !imagePathComponent.MatchString(component) {
+			return nil, fmt.Errorf("invalid repository name %q in image reference %q", name, image)
+		}
+	}
+	ref.Repository = strings.Join(components, "/")
+	return ref, nil
+}
+
+// Name returns the repository with the registry, e.g. `docker.io/library/nginx`
+func (r *imageRef) Name() string {
+	return r.Registry + "/" + r.Repository
+}
+
+// shortName is the last component of the repository, e.g. `nginx`
+func (r *imageRef) shortName() string {
+	return path.Base(r.Repository)
+}
+
+// latest is true for references with the `latest` tag, or without either tag or digest, which
+// means the same thing
+func (r *imageRef) latest() bool {
+	return r.Tag == defaultImageTag || (r.Tag == "" && r.Digest == "")
+}
+
+func (r *imageRef) String() string {
+	s := r.Name()
+	if r.Tag != "" {
+		s += ":" + r.Tag
+	}
+	if r.Digest != "" {
+		s += "@" + r.Digest
+	}
+	return s
+}
+
+// imageUse is a container of a pod, or of a workload template, that uses an image
+type imageUse struct {
+	Kind      string `json:"kind"`
+	Name      string `json:"name"`
+	Container string `json:"container"`
+	Image     string `json:"image"`
+}
+
+func (u imageUse) object() string {
+	return fmt.Sprintf("%s %s", u.Kind, u.Name)
+}
+
+// imageGroup has all uses of the same tag of a repository
+type imageGroup struct {
+	Repository string     `json:"repository"`
+	Tag        string     `json:"tag"`
+	Digests    []string   `json:"digests,omitempty"`
+	Uses       []imageUse `json:"uses"`
+}
+
+// imageFinding is a problem with an image that `images` checks for, `check` is one of `invalid`, `latest`,
+// `digest`, `registry` and `drift`
+type imageFinding struct {
+	Check   string   `json:"check"`
+	Image   string   `json:"image"`
+	Objects []string `json:"objects"`
+	Message string   `json:"message"`
+}
+
+type imageInventory struct {
+	Namespace string         `json:"namespace,omitempty"`
+	Images    []imageGroup   `json:"images"`
+	Findings  []imageFinding `json:"findings"`
+}
+
+// workloadTemplate is a pod template with the workload it belongs to, it's used to find drift
+type workloadTemplate struct {
+	kind     string
+	meta     *metav1.ObjectMeta
+	template *corev1.PodTemplateSpec
+}
+
+func containerImages(kind string, meta *metav1.ObjectMeta, spec *corev1.PodSpec) []imageUse {
+	uses := []imageUse{}
+	for _, containers := range [][]corev1.Container{spec.InitContainers, spec.Containers} {
+		for _, container := range containers {
+			uses = append(uses, imageUse{Kind: kind, Name: objectName(*meta), Container: container.Name, Image: container.Image})
+		}
+	}
+	return uses
+}
+
+func templateImage(template *corev1.PodTemplateSpec, name string) (string, bool) {
+	for _, containers := range [][]corev1.Container{template.Spec.InitContainers, template.Spec.Containers} {
+		for _, container := range containers {
+			if container.Name == name {
+				return container.Image, true
+			}
+		}
+	}
+	return "", false
+}
+
+// registryAllowed checks the registry against `allowedRegistries` globs in the config file, a glob is matched
+// against the registry (e.g. `*.gcr.io`) and the repository with the registry (e.g. `docker.io/library/*`);
+// any registry is allowed if there are none
+func (rk *RubyKube) registryAllowed(ref *imageRef) bool {
+	if len(rk.settings.AllowedRegistries) == 0 {
+		return true
+	}
+	for _, glob := range rk.settings.AllowedRegistries {
+		if ok, _ := path.Match(glob, ref.Registry); ok {
+			return true
+		}
+		if ok, _ := path.Match(glob, ref.Name()); ok {
+			return true
+		}
+	}
+	return false
+}
+
+// imageInventoryOf lists pods and workloads in the namespace (all namespaces if it's empty), groups images
+// by repository and tag, and checks them
+func (rk *RubyKube) imageInventoryOf(ns string) (*imageInventory, error) {
+	var (
+		pods        *corev1.PodList
+		deployments *appsv1.DeploymentList
+		replicaSets *appsv1.ReplicaSetList
+		daemonSets  *appsv1.DaemonSetList
+	)
+
+	listers := []func() error{
+		func() (err error) {
+			pods, err = rk.clientset.Core().Pods(ns).List(metav1.ListOptions{})
+			return err
+		},
+		func() (err error) {
+			deployments, err = rk.clientset.Apps().Deployments(ns).List(metav1.ListOptions{})
+			return err
+		},
+		func() (err error) {
+			replicaSets, err = rk.clientset.Apps().ReplicaSets(ns).List(metav1.ListOptions{})
+			return err
+		},
+		func() (err error) {
+			daemonSets, err = rk.clientset.Apps().DaemonSets(ns).List(metav1.ListOptions{})
+			return err
+		},
+	}
+	if err := rk.parallel(len(listers), func(i int) error { return listers[i]() }); err != nil {
+		return nil, err
+	}
+
+	uses := []imageUse{}
+	templates := map[types.UID]workloadTemplate{}
+	for i := range deployments.Items {
+		d := &deployments.Items[i]
+		uses = append(uses, containerImages("deployment", &d.ObjectMeta, &d.Spec.Template.Spec)...)
+		templates[d.ObjectMeta.UID] = workloadTemplate{"deployment", &d.ObjectMeta, &d.Spec.Template}
+	}
+	for i := range replicaSets.Items {
+		rs := &replicaSets.Items[i]
+		uses = append(uses, containerImages("replicaset", &rs.ObjectMeta, &rs.Spec.Template.Spec)...)
+		templates[rs.ObjectMeta.UID] = workloadTemplate{"replicaset", &rs.ObjectMeta, &rs.Spec.Template}
+	}
+	for i := range daemonSets.Items {
+		ds := &daemonSets.Items[i]
+		uses = append(uses, containerImages("daemonset", &ds.ObjectMeta, &ds.Spec.Template.Spec)...)
+		templates[ds.ObjectMeta.UID] = workloadTemplate{"daemonset", &ds.ObjectMeta, &ds.Spec.Template}
+	}
+	for i := range pods.Items {
+		uses = append(uses, containerImages("pod", &pods.Items[i].ObjectMeta, &pods.Items[i].Spec)...)
+	}
+
+	inventory := &imageInventory{Namespace: ns, Images: []imageGroup{}, Findings: []imageFinding{}}
+	inventory.addImages(rk, uses)
+	for i := range pods.Items {
+		inventory.addDrift(&pods.Items[i], templates)
+	}
+	return inventory, nil
+}
+
+// addImages groups uses by repository and tag, and checks each image reference once
+func (inventory *imageInventory) addImages(rk *RubyKube, uses []imageUse) {
+	byImage := map[string][]imageUse{}
+	images := []string{}
+	for _, use := range uses {
+		if _, ok := byImage[use.Image]; !ok {
+			images = append(images, use.Image)
+		}
+		byImage[use.Image] = append(byImage[use.Image], use)
+	}
+	sort.Strings(images)
+
+	groups := map[string]int{}
+	for _, image := range images {
+		objects := []string{}
+		for _, use := range byImage[image] {
+			objects = append(objects, use.object())
+		}
+		finding := func(check, format string, a ...interface{}) {
+			inventory.Findings = append(inventory.Findings, imageFinding{
+				Check: check, Image: image, Objects: uniqueStrings(objects), Message: fmt.Sprintf(format, a...),
+			})
+		}
+
+		ref, err := parseImageRef(image)
+		if err != nil {
+			finding("invalid", "%v", err)
+			continue
+		}
+
+		key := ref.Name() + ":" + ref.Tag
+		i, ok := groups[key]
+		if !ok {
+			i = len(inventory.Images)
+			groups[key] = i
+			inventory.Images = append(inventory.Images, imageGroup{Repository: ref.Name(), Tag: ref.Tag, Uses: []imageUse{}})
+		}
+		if ref.Digest != "" && !contains(inventory.Images[i].Digests, ref.Digest) {
+			inventory.Images[i].Digests = append(inventory.Images[i].Digests, ref.Digest)
+		}
+		inventory.Images[i].Uses = append(inventory.Images[i].Uses, byImage[image]...)
+
+		if ref.latest() {
+			finding("latest", "%s tag may point to a different image each time it's pulled", defaultImageTag)
+		}
+		if ref.Digest == "" {
+			finding("digest", "image is not pinned to a digest")
+		}
+		if !rk.registryAllowed(ref) {
+			finding("registry", "registry %s is not one of allowed registries", ref.Registry)
+		}
+	}
+
+	sort.SliceStable(inventory.Images, func(i, j int) bool {
+		if inventory.Images[i].Repository != inventory.Images[j].Repository {
+			return inventory.Images[i].Repository < inventory.Images[j].Repository
+		}
+		return inventory.Images[i].Tag < inventory.Images[j].Tag
+	})
+}
+
+// addDrift compares images of a pod with the template of its replica set or daemon set, and of the deployment
+// that owns the replica set; pods of a replica set that is being replaced are reported too
+func (inventory *imageInventory) addDrift(pod *corev1.Pod, templates map[types.UID]workloadTemplate) {
+	owners := []workloadTemplate{}
+	for owner := metav1.GetControllerOf(pod); owner != nil; {
+		t, ok := templates[owner.UID]
+		if !ok {
+			break
+		}
+		owners = append(owners, t)
+		owner = metav1.GetControllerOf(t.meta)
+	}
+
+	for _, use := range containerImages("pod", &pod.ObjectMeta, &pod.Spec) {
+		for _, owner := range owners {
+			image, ok := templateImage(owner.template, use.Container)
+			if !ok || image == use.Image {
+				continue
+			}
+			inventory.Findings = append(inventory.Findings, imageFinding{
+				Check:   "drift",
+				Image:   use.Image,
+				Objects: []string{use.object(), fmt.Sprintf("%s %s", owner.kind, objectName(*owner.meta))},
+				Message: fmt.Sprintf("container %s runs %s, while template of %s %s has %s", use.Container, use.Image, owner.kind, objectName(*owner.meta), image),
+			})
+		}
+	}
+}
+
+func uniqueStrings(values []string) []string {
+	unique := []string{}
+	for _, v := range values {
+		if !contains(unique, v) {
+			unique = append(unique, v)
+		}
+	}
+	return unique
+}
+
+// findings returns findings of the given checks, or all of them
+func (inventory *imageInventory) findings(checks ...string) []imageFinding {
+	if len(checks) == 0 {
+		return inventory.Findings
+	}
+	findings := []imageFinding{}
+	for _, f := range inventory.Findings {
+		if contains(checks, f.Check) {
+			findings = append(findings, f)
+		}
+	}
+	return findings
+}
+
+func summariseObjects(objects []string) string {
+	if len(objects) == 1 {
+		return objects[0]
+	}
+	return fmt.Sprintf("%s (+%d)", objects[0], len(objects)-1)
+}
+
+func (inventory *imageInventory) String() string {
+	text := bytes.Buffer{}
+	w := tabwriter.NewWriter(&text, 0, 8, 2, ' ', 0)
+
+	fmt.Fprintln(w, "REPOSITORY\tTAG\tDIGESTS\tPODS\tWORKLOADS")
+	for _, g := range inventory.Images {
+		pods, workloads := 0, 0
+		for _, use := range g.Uses {
+			if use.Kind == "pod" {
+				pods++
+			} else {
+				workloads++
+			}
+		}
+		tag := g.Tag
+		if tag == "" {
+			tag = "-"
+		}
+		fmt.Fprintf(w, "%s\t%s\t%d\t%d\t%d\n", g.Repository, tag, len(g.Digests), pods, workloads)
+	}
+	w.Flush()
+
+	if len(inventory.Findings) == 0 {
+		fmt.Fprintln(&text, "\nNo findings")
+		return text.String()
+	}
+
+	fmt.Fprintln(&text, "\nFindings:")
+	w = tabwriter.NewWriter(&text, 0, 8, 2, ' ', 0)
+	fmt.Fprintln(w, "CHECK\tIMAGE\tOBJECTS\tMESSAGE")
+	for _, f := range inventory.Findings {
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\n", f.Check, f.Image, summariseObjects(f.Objects), f.Message)
+	}
+	w.Flush()
+	return text.String()
+}
+
+// images implements `images` verb, which takes a namespace, `"*"` for all namespaces; it defaults to
+// current namespace, or all namespaces in all-namespaces mode
+func images(rk *RubyKube, args []*mruby.MrbValue, m *mruby.Mrb, self *mruby.MrbValue) (mruby.Value, mruby.Value) {
+	ns := rk.state.Namespace
+	if len(args) > 0 {
+		if args[0].Type() != mruby.TypeString && args[0].Type() != mruby.TypeSymbol {
+			return nil, createException(m, "Namespace must be a string")
+		}
+		ns = args[0].String()
+	}
+	if ns == "*" {
+		ns = ""
+	}
+
+	inventory, err := rk.imageInventoryOf(ns)
+	if err != nil {
+		return nil, createError(m, err)
+	}
+
+	newInventoryObj, err := rk.classes.ImageInventory.New()
+	if err != nil {
+		return nil, createError(m, err)
+	}
+	newInventoryObj.vars.inventory = inventory
+	return newInventoryObj.self, nil
+}
@@ -0,0 +1,67 @@
+package rubykube
+
+import (
+	"testing"
+)
+
+func TestParseImageRef(t *testing.T) {
+	digest := "sha256:0123456789abcdef0123456789abcdef0123456789abcdef0123456789abcdef"
+
+	tests := []struct {
+		image  string
+		ref    imageRef
+		name   string
+		latest bool
+		err    bool
+	}{
+		{image: "nginx", ref: imageRef{Registry: "docker.io", Repository: "library/nginx"},
+			name: "docker.io/library/nginx", latest: true},
+		{image: "nginx:1.13", ref: imageRef{Registry: "docker.io", Repository: "library/nginx", Tag: "1.13"},
+			name: "docker.io/library/nginx:1.13"},
+		{image: "acme/web:latest", ref: imageRef{Registry: "docker.io", Repository: "acme/web", Tag: "latest"},
+			name: "docker.io/acme/web:latest", latest: true},
+		{image: "index.docker.io/acme/web:2.0", ref: imageRef{Registry: "docker.io", Repository: "acme/web", Tag: "2.0"},
+			name: "docker.io/acme/web:2.0"},
+		{image: "gcr.io/google_containers/pause-amd64:3.0", ref: imageRef{Registry: "gcr.io", Repository: "google_containers/pause-amd64", Tag: "3.0"},
+			name: "gcr.io/google_containers/pause-amd64:3.0"},
+		{image: "localhost:5000/web", ref: imageRef{Registry: "localhost:5000", Repository: "web"},
+			name: "localhost:5000/web", latest: true},
+		{image: "localhost/web", ref: imageRef{Registry: "localhost", Repository: "web"},
+			name: "localhost/web", latest: true},
+		{image: "registry.acme.com:443/team/web:1.2.3", ref: imageRef{Registry: "registry.acme.com:443", Repository: "team/web", Tag: "1.2.3"},
+			name: "registry.acme.com:443/team/web:1.2.3"},
+		{image: "acme/web@" + digest, ref: imageRef{Registry: "docker.io", Repository: "acme/web", Digest: digest},
+			name: "docker.io/acme/web@" + digest},
+		{image: "acme/web:1.0@" + digest, ref: imageRef{Registry: "docker.io", Repository: "acme/web", Tag: "1.0", Digest: digest},
+			name: "docker.io/acme/web:1.0@" + digest},
+		{image: "", err: true},
+		{image: "Acme/web", err: true},
+		{image: "acme/web:", err: true},
+		{image: "acme/web:-1", err: true},
+		{image: "acme/web@sha256:123", err: true},
+		{image: "acme//web", err: true},
+	}
+
+	for _, test := range tests {
+		ref, err := parseImageRef(test.image)
+		if test.err {
+			if err == nil {
+				t.Errorf("expected an error for %q, got %+v", test.image, *ref)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("unexpected error for %q: %v", test.image, err)
+			continue
+		}
+		if *ref != test.ref {
+			t.Errorf("expected %+v for %q, got %+v", test.ref, test.image, *ref)
+		}
+		if name := ref.String(); name != test.name {
+			t.Errorf("expected %q for %q, got %q", test.name, test.image, name)
+		}
+		if ref.latest() != test.latest {
+			t.Errorf("expected latest to be %v for %q", test.latest, test.image)
+		}
+	}
+}
@@ -0,0 +1,298 @@
+package rubykube
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"strings"
+
+	mruby "github.com/mitchellh/go-mruby"
+	"k8s.io/client-go/util/jsonpath"
+)
+
+// jsonPathTemplate accepts either a full JSONPath template (e.g. `{.spec.nodeName}`),
+// or a plain dotted path (e.g. `spec.nodeName`), which is what `pluck` and `where`
+// are normally given
+func jsonPathTemplate(path string) string {
+	path = strings.TrimSpace(path)
+	if strings.HasPrefix(path, "{") {
+		return path
+	}
+	if !strings.HasPrefix(path, ".") {
+		path = "." + path
+	}
+	return "{" + path + "}"
+}
+
+// evalJSONPath runs given expression against a Go object directly, results of all
+// template nodes are flattened into one slice
+func evalJSONPath(obj interface{}, path string, allowMissingKeys bool) ([]reflect.Value, error) {
+	jp := jsonpath.New("kubeplay")
+	jp.AllowMissingKeys(allowMissingKeys)
+	if err := jp.Parse(jsonPathTemplate(path)); err != nil {
+		return nil, err
+	}
+
+	results, err := jp.FindResults(obj)
+	if err != nil {
+		return nil, err
+	}
+
+	values := []reflect.Value{}
+	for _, r := range results {
+		values = append(values, r...)
+	}
+	return values, nil
+}
+
+// jsonPathResultToRuby converts a single result to a native Ruby value; it goes via
+// JSON, so that wire format is used, e.g. timestamps and quantities become strings
+func jsonPathResultToRuby(m *mruby.Mrb, v reflect.Value) (mruby.Value, error) {
+	if !v.IsValid() || !v.CanInterface() {
+		return m.NilValue(), nil
+	}
+
+	data, err := json.Marshal(v.Interface())
+	if err != nil {
+		return nil, err
+	}
+
+	var tree interface{}
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber()
+	if err := decoder.Decode(&tree); err != nil {
+		return nil, err
+	}
+
+	return nativeRubyValue(m, tree)
+}
+
+// jsonPathResultToString returns string form of a result as used by `where`
+func jsonPathResultToString(v reflect.Value) string {
+	if !v.IsValid() || !v.CanInterface() {
+		return ""
+	}
+	if v.Kind() == reflect.String {
+		return v.String()
+	}
+	data, err := json.Marshal(v.Interface())
+	if err != nil {
+		return fmt.Sprintf("%v", v.Interface())
+	}
+	return strings.Trim(string(data), `"`)
+}
+
+// nativeRubyValue converts a tree of decoded JSON into Ruby values, unlike
+// `converter.Convert` it makes proper arrays and numbers
+func nativeRubyValue(m *mruby.Mrb, tree interface{}) (*mruby.MrbValue, error) {
+	switch vv := tree.(type) {
+	case nil:
+		return m.NilValue(), nil
+	case bool:
+		if vv {
+			return m.TrueValue(), nil
+		}
+		return m.FalseValue(), nil
+	case string:
+		return m.StringValue(vv), nil
+	case json.Number:
+		if i, err := vv.Int64(); err == nil {
+			return m.FixnumValue(int(i)), nil
+		}
+		f, err := vv.Float64()
+		if err != nil {
+			return nil, err
+		}
+		return m.FloatValue(f), nil
+	case []interface{}:
+		return newArrayOf(m, len(vv), func(i int) (mruby.Value, error) {
+			return nativeRubyValue(m, vv[i])
+		})
+	case map[string]interface{}:
+		hash, err := m.LoadString("{}")
+		if err != nil {
+			return nil, err
+		}
+		if err := protectValue(m, hash); err != nil {
+			return nil, err
+		}
+		defer unprotectValue(m, hash)
+
+		for k, x := range vv {
+			// the key is made first, so that the value is set as soon as it's made
+			key := m.StringValue(k)
+			gcCheckpoint(m)
+			v, err := nativeRubyValue(m, x)
+			if err != nil {
+				return nil, err
+			}
+			hash.Hash().Set(key, v)
+		}
+		return hash, nil
+	default:
+		return nil, fmt.Errorf("nativeRubyValue: unknown type %T", vv)
+	}
+}
+
+// nativeRubyValueOf converts any Go value that can be encoded as JSON into Ruby values
+func nativeRubyValueOf(m *mruby.Mrb, obj interface{}) (*mruby.MrbValue, error) {
+	data, err := json.Marshal(obj)
+	if err != nil {
+		return nil, err
+	}
+
+	var tree interface{}
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber()
+	if err := decoder.Decode(&tree); err != nil {
+		return nil, err
+	}
+
+	return nativeRubyValue(m, tree)
+}
+
+// newArray constructs a Ruby array, as go-mruby has no direct way of doing it; values must be
+// made with C API (e.g. strings), as values from `LoadString` may be collected while in a slice
+func newArray(m *mruby.Mrb, values ...mruby.Value) (*mruby.MrbValue, error) {
+	return newArrayOf(m, len(values), func(i int) (mruby.Value, error) {
+		return values[i], nil
+	})
+}
+
+// newArrayOf constructs a Ruby array of n values, each value is pushed as soon as it's made,
+// while the array itself is protected from GC
+func newArrayOf(m *mruby.Mrb, n int, value func(i int) (mruby.Value, error)) (*mruby.MrbValue, error) {
+	array, err := m.LoadString("[]")
+	if err != nil {
+		return nil, err
+	}
+	if err := protectValue(m, array); err != nil {
+		return nil, err
+	}
+	defer unprotectValue(m, array)
+
+	for i := 0; i < n; i++ {
+		gcCheckpoint(m)
+		v, err := value(i)
+		if err != nil {
+			return nil, err
+		}
+		if _, err := array.Call("push", v); err != nil {
+			return nil, err
+		}
+	}
+	return array, nil
+}
+
+// jsonPathMethod implements `jsonpath` that is common to all resource classes
+func jsonPathMethod(m *mruby.Mrb, obj interface{}) (mruby.Value, mruby.Value) {
+	args := m.GetArgs()
+	if err := checkArgs(args, 1); err != nil {
+		return nil, createError(m, err)
+	}
+
+	results, err := evalJSONPath(obj, args[0].String(), false)
+	if err != nil {
+		return nil, createError(m, err)
+	}
+
+	array, err := newArrayOf(m, len(results), func(i int) (mruby.Value, error) {
+		return jsonPathResultToRuby(m, results[i])
+	})
+	if err != nil {
+		return nil, createError(m, err)
+	}
+	return array, nil
+}
+
+// pluckValue returns `nil` for missing keys, a single value when there is one result,
+// or an array otherwise
+func pluckValue(m *mruby.Mrb, obj interface{}, path string) (mruby.Value, error) {
+	results, err := evalJSONPath(obj, path, true)
+	if err != nil {
+		return nil, err
+	}
+
+	switch len(results) {
+	case 0:
+		return m.NilValue(), nil
+	case 1:
+		return jsonPathResultToRuby(m, results[0])
+	default:
+		return newArrayOf(m, len(results), func(i int) (mruby.Value, error) {
+			return jsonPathResultToRuby(m, results[i])
+		})
+	}
+}
+
+type whereCondition struct {
+	path   string
+	values []string
+	isNil  bool
+}
+
+// newWhereConditions parses `where("status.phase" => "Running", "spec.nodeName" => %w(a b))`
+func newWhereConditions(arg *mruby.MrbValue) ([]whereCondition, error) {
+	if arg.Type() != mruby.TypeHash {
+		return nil, fmt.Errorf("Argument must be a hash")
+	}
+
+	conditions := []whereCondition{}
+	if err := iterateHash(arg, func(key, value *mruby.MrbValue) error {
+		condition := whereCondition{path: key.String(), values: []string{}}
+		switch value.Type() {
+		case mruby.TypeNil:
+			condition.isNil = true
+		case mruby.TypeArray:
+			if err := iterateArray(value, func(_ int, v *mruby.MrbValue) error {
+				condition.values = append(condition.values, v.String())
+				return nil
+			}); err != nil {
+				return err
+			}
+		case mruby.TypeHash, mruby.TypeProc:
+			return fmt.Errorf("invalid value type for %q – should be a string, symbol, number or an array", condition.path)
+		default:
+			condition.values = append(condition.values, value.String())
+		}
+		conditions = append(conditions, condition)
+		return nil
+	}); err != nil {
+		return nil, err
+	}
+
+	return conditions, nil
+}
+
+// matchWhereConditions returns true if all of the conditions match, a condition
+// with multiple results (e.g. `[*]`) matches if any of the results match
+func matchWhereConditions(obj interface{}, conditions []whereCondition) (bool, error) {
+	for _, condition := range conditions {
+		results, err := evalJSONPath(obj, condition.path, true)
+		if err != nil {
+			return false, err
+		}
+
+		if condition.isNil {
+			if len(results) != 0 && jsonPathResultToString(results[0]) != "" {
+				return false, nil
+			}
+			continue
+		}
+
+		found := false
+		for _, r := range results {
+			s := jsonPathResultToString(r)
+			for _, v := range condition.values {
+				if s == v {
+					found = true
+				}
+			}
+		}
+		if !found {
+			return false, nil
+		}
+	}
+	return true, nil
+}
@@ -0,0 +1,117 @@
+package rubykube
+
+import (
+	"reflect"
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func testPod() *corev1.Pod {
+	gracePeriod := int64(30)
+	return &corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "web-a",
+			Namespace: "prod",
+			Labels:    map[string]string{"app": "web", "tier": "frontend"},
+		},
+		Spec: corev1.PodSpec{
+			NodeName:                      "node-1",
+			TerminationGracePeriodSeconds: &gracePeriod,
+			Containers: []corev1.Container{
+				{Name: "web", Image: "nginx:1.13"},
+				{Name: "sidecar", Image: "envoy:1.5"},
+			},
+		},
+		Status: corev1.PodStatus{Phase: corev1.PodRunning},
+	}
+}
+
+func TestJSONPathTemplate(t *testing.T) {
+	tests := []struct {
+		path, template string
+	}{
+		{"spec.nodeName", "{.spec.nodeName}"},
+		{".spec.nodeName", "{.spec.nodeName}"},
+		{"  status.phase ", "{.status.phase}"},
+		{"{.spec.containers[*].image}", "{.spec.containers[*].image}"},
+	}
+
+	for _, test := range tests {
+		if template := jsonPathTemplate(test.path); template != test.template {
+			t.Errorf("expected %q for %q, got %q", test.template, test.path, template)
+		}
+	}
+}
+
+func TestEvalJSONPath(t *testing.T) {
+	tests := []struct {
+		path   string
+		values []string
+	}{
+		{"spec.nodeName", []string{"node-1"}},
+		{"status.phase", []string{"Running"}},
+		{"metadata.labels.app", []string{"web"}},
+		{"spec.containers[*].image", []string{"nginx:1.13", "envoy:1.5"}},
+		{"spec.containers[1].name", []string{"sidecar"}},
+		{"spec.terminationGracePeriodSeconds", []string{"30"}},
+		{"spec.hostname", []string{""}},
+		{"metadata.labels.missing", []string{}},
+	}
+
+	for _, test := range tests {
+		results, err := evalJSONPath(testPod(), test.path, true)
+		if err != nil {
+			t.Errorf("unexpected error for %q: %v", test.path, err)
+			continue
+		}
+		values := []string{}
+		for _, r := range results {
+			values = append(values, jsonPathResultToString(r))
+		}
+		if !reflect.DeepEqual(values, test.values) {
+			t.Errorf("expected %q for %q, got %q", test.values, test.path, values)
+		}
+	}
+
+	if _, err := evalJSONPath(testPod(), "spec.containers[", true); err == nil {
+		t.Error("expected an error for an invalid path")
+	}
+}
+
+func TestMatchWhereConditions(t *testing.T) {
+	tests := []struct {
+		name       string
+		conditions []whereCondition
+		match      bool
+	}{
+		{"no conditions", []whereCondition{}, true},
+		{"equal", []whereCondition{{path: "status.phase", values: []string{"Running"}}}, true},
+		{"not equal", []whereCondition{{path: "status.phase", values: []string{"Pending"}}}, false},
+		{"one of", []whereCondition{{path: "spec.nodeName", values: []string{"node-2", "node-1"}}}, true},
+		{"none of", []whereCondition{{path: "spec.nodeName", values: []string{"node-2", "node-3"}}}, false},
+		{"any of many results", []whereCondition{{path: "spec.containers[*].name", values: []string{"sidecar"}}}, true},
+		{"number as string", []whereCondition{{path: "spec.terminationGracePeriodSeconds", values: []string{"30"}}}, true},
+		{"all conditions", []whereCondition{
+			{path: "status.phase", values: []string{"Running"}},
+			{path: "metadata.labels.tier", values: []string{"backend"}},
+		}, false},
+		{"nil of missing", []whereCondition{{path: "metadata.labels.missing", isNil: true}}, true},
+		{"nil of empty", []whereCondition{{path: "spec.hostname", isNil: true}}, true},
+		{"nil of present", []whereCondition{{path: "spec.nodeName", isNil: true}}, false},
+		{"missing is not equal", []whereCondition{{path: "metadata.labels.missing", values: []string{"x"}}}, false},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			match, err := matchWhereConditions(testPod(), test.conditions)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if match != test.match {
+				t.Errorf("expected %v, got %v", test.match, match)
+			}
+		})
+	}
+}
@@ -0,0 +1,270 @@
+package rubykube
+
+import (
+	"fmt"
+	"runtime"
+	"sort"
+
+	mruby "github.com/mitchellh/go-mruby"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+const (
+	// instanceIDVariable holds the key under which Go side of a class instance is registered
+	instanceIDVariable = "@__kubeplay_id"
+	// gcRootsVariable is a hash that keeps values which Go holds on to reachable, as mruby GC
+	// doesn't know about references from Go
+	gcRootsVariable = "$__kubeplay_gc_roots"
+	// gcRootCountsVariable counts how many times each value in GC roots has been protected
+	gcRootCountsVariable = "$__kubeplay_gc_root_counts"
+	// lastValueVariable keeps the value `_` returns reachable
+	lastValueVariable = "$__kubeplay_last_value"
+
+	// minSweepThreshold is how many instances a class must have before dead ones are swept
+	minSweepThreshold = 1000
+)
+
+// lastInstanceID is shared by all classes, so an ID cannot be mistaken for one of another class
+var lastInstanceID = 0
+
+// instanceRegistry maps Ruby objects to Go data of class instances, the ID is kept in an instance
+// variable of the object, so lookups don't depend on the number of live objects; once the GC has
+// collected an object, its Go data is dropped on the next sweep
+type instanceRegistry struct {
+	className      string
+	instances      map[int]interface{}
+	sweepThreshold int
+}
+
+func (rk *RubyKube) newInstanceRegistry(className string) *instanceRegistry {
+	r := &instanceRegistry{
+		className:      className,
+		instances:      map[int]interface{}{},
+		sweepThreshold: minSweepThreshold,
+	}
+	rk.registries = append(rk.registries, r)
+	return r
+}
+
+func (r *instanceRegistry) add(self *mruby.MrbValue, instance interface{}) {
+	if len(r.instances) >= r.sweepThreshold {
+		// instances are kept until the next sweep if live ones cannot be found
+		if live, err := liveInstanceIDs(self.Mrb()); err == nil {
+			r.sweep(live)
+		}
+		r.sweepThreshold = 2 * len(r.instances)
+		if r.sweepThreshold < minSweepThreshold {
+			r.sweepThreshold = minSweepThreshold
+		}
+	}
+
+	lastInstanceID++
+	self.SetInstanceVariable(instanceIDVariable, self.Mrb().FixnumValue(lastInstanceID))
+	r.instances[lastInstanceID] = instance
+}
+
+func (r *instanceRegistry) lookup(self *mruby.MrbValue) (interface{}, error) {
+	id := self.GetInstanceVariable(instanceIDVariable)
+	if id == nil || id.Type() != mruby.TypeFixnum {
+		return nil, fmt.Errorf("%s: could not find class instance", r.className)
+	}
+	instance, ok := r.instances[id.Fixnum()]
+	if !ok {
+		return nil, fmt.Errorf("%s: could not find class instance", r.className)
+	}
+	return instance, nil
+}
+
+// resourceObjectOf looks up the object of a resource class instance without knowing its class, IDs
+// are shared by all classes, so only the registry of its own class has it; lists are not objects
+func (rk *RubyKube) resourceObjectOf(v *mruby.MrbValue) (metav1.Object, bool) {
+	for _, r := range rk.registries {
+		instance, err := r.lookup(v)
+		if err != nil {
+			continue
+		}
+		resource, ok := instance.(interface{ object() interface{} })
+		if !ok {
+			return nil, false
+		}
+		obj, ok := resource.object().(metav1.Object)
+		return obj, ok
+	}
+	return nil, false
+}
+
+// liveInstanceIDs returns IDs of instances of all classes that haven't been collected, `ObjectSpace`
+// only visits live objects, so objects that have been collected (and their heap pages freed) are never
+// touched; all classes inherit from `RubyKube`
+var liveInstanceIDsCode = fmt.Sprintf(`
+ids = []
+ObjectSpace.each_object(RubyKube) do |o|
+  id = o.instance_variable_get(:%s)
+  ids << id if id
+end
+ids
+`, instanceIDVariable)
+
+func liveInstanceIDs(m *mruby.Mrb) (map[int]bool, error) {
+	ids, err := m.LoadString(liveInstanceIDsCode)
+	if err != nil {
+		return nil, err
+	}
+
+	live := map[int]bool{}
+	for i := 0; i < ids.Array().Len(); i++ {
+		id, err := ids.Array().Get(i)
+		if err != nil {
+			return nil, err
+		}
+		live[id.Fixnum()] = true
+	}
+	return live, nil
+}
+
+// sweep drops Go data of objects that are not live, and returns how many were dropped
+func (r *instanceRegistry) sweep(live map[int]bool) int {
+	swept := 0
+	for id := range r.instances {
+		if !live[id] {
+			delete(r.instances, id)
+			swept++
+		}
+	}
+	return swept
+}
+
+// initGCRoots must be called before GC is enabled
+func (rk *RubyKube) initGCRoots() error {
+	roots, err := rk.mrb.LoadString("{}")
+	if err != nil {
+		return err
+	}
+	rk.mrb.SetGlobalVariable(gcRootsVariable, roots)
+	counts, err := rk.mrb.LoadString("{}")
+	if err != nil {
+		return err
+	}
+	rk.mrb.SetGlobalVariable(gcRootCountsVariable, counts)
+	return nil
+}
+
+// protect keeps a value that is only referenced from Go from being collected, until unprotect is called
+func (rk *RubyKube) protect(v *mruby.MrbValue) error {
+	return protectValue(rk.mrb, v)
+}
+
+func (rk *RubyKube) unprotect(v *mruby.MrbValue) error {
+	return unprotectValue(rk.mrb, v)
+}
+
+// protectValue is used by helpers that only have mrb, values they get from `LoadString` are not in
+// GC arena like values made with C API are, so these have to be protected until Ruby refers to them;
+// protection is counted, so a value stays protected until each caller that protected it unprotects it
+func protectValue(m *mruby.Mrb, v *mruby.MrbValue) error {
+	id, err := v.Call("object_id")
+	if err != nil {
+		return err
+	}
+	count, err := gcRootCount(m, id)
+	if err != nil {
+		return err
+	}
+	if err := m.GetGlobalVariable(gcRootsVariable).Hash().Set(id, v); err != nil {
+		return err
+	}
+	return m.GetGlobalVariable(gcRootCountsVariable).Hash().Set(id, m.FixnumValue(count+1))
+}
+
+func unprotectValue(m *mruby.Mrb, v *mruby.MrbValue) error {
+	id, err := v.Call("object_id")
+	if err != nil {
+		return err
+	}
+	count, err := gcRootCount(m, id)
+	if err != nil {
+		return err
+	}
+	if count > 1 {
+		return m.GetGlobalVariable(gcRootCountsVariable).Hash().Set(id, m.FixnumValue(count-1))
+	}
+	if _, err := m.GetGlobalVariable(gcRootCountsVariable).Hash().Delete(id); err != nil {
+		return err
+	}
+	_, err = m.GetGlobalVariable(gcRootsVariable).Hash().Delete(id)
+	return err
+}
+
+func gcRootCount(m *mruby.Mrb, id *mruby.MrbValue) (int, error) {
+	count, err := m.GetGlobalVariable(gcRootCountsVariable).Hash().Get(id)
+	if err != nil {
+		return 0, err
+	}
+	if count.Type() != mruby.TypeFixnum {
+		return 0, nil
+	}
+	return count.Fixnum(), nil
+}
+
+// gcCheckpoint is called by helpers that build values from Go wherever mruby could run GC, it does
+// nothing, but tests make it run `GC.start` to check that values are protected in the meantime
+var gcCheckpoint = func(m *mruby.Mrb) {}
+
+// stats implements `stats`, which runs full GC and returns how many objects are live
+func stats(rk *RubyKube, args []*mruby.MrbValue, m *mruby.Mrb, self *mruby.MrbValue) (mruby.Value, mruby.Value) {
+	m.FullGC()
+
+	instances, err := m.LoadString("{}")
+	if err != nil {
+		return nil, createError(m, err)
+	}
+	if err := protectValue(m, instances); err != nil {
+		return nil, createError(m, err)
+	}
+	defer unprotectValue(m, instances)
+
+	live, err := liveInstanceIDs(m)
+	if err != nil {
+		return nil, createError(m, err)
+	}
+
+	registries := append([]*instanceRegistry{}, rk.registries...)
+	sort.Slice(registries, func(i, j int) bool { return registries[i].className < registries[j].className })
+
+	for _, r := range registries {
+		r.sweep(live)
+		if len(r.instances) == 0 {
+			continue
+		}
+		gcCheckpoint(m)
+		if err := instances.Hash().Set(m.StringValue(r.className), m.FixnumValue(len(r.instances))); err != nil {
+			return nil, createError(m, err)
+		}
+	}
+
+	memStats := runtime.MemStats{}
+	runtime.ReadMemStats(&memStats)
+
+	gcCheckpoint(m)
+	result, err := m.LoadString("{}")
+	if err != nil {
+		return nil, createError(m, err)
+	}
+	if err := protectValue(m, result); err != nil {
+		return nil, createError(m, err)
+	}
+	defer unprotectValue(m, result)
+
+	for k, v := range map[string]mruby.Value{
+		"instances":       instances,
+		"ruby_objects":    m.FixnumValue(m.LiveObjectCount()),
+		"go_heap_bytes":   m.FixnumValue(int(memStats.HeapAlloc)),
+		"go_heap_objects": m.FixnumValue(int(memStats.HeapObjects)),
+	} {
+		gcCheckpoint(m)
+		if err := result.Hash().Set(m.StringValue(k), v); err != nil {
+			return nil, createError(m, err)
+		}
+	}
+	return result, nil
+}
@@ -0,0 +1,301 @@
+package rubykube
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"syscall"
+	"testing"
+	"time"
+
+	mruby "github.com/mitchellh/go-mruby"
+)
+
+// gcStress makes mruby run GC as often as it can, and helpers run `GC.start` wherever mruby could run
+// GC, so that any value that is not protected in the meantime gets collected
+func gcStress(t *testing.T, m *mruby.Mrb) {
+	if _, err := m.LoadString("GC.interval_ratio = 1; GC.step_ratio = 10000"); err != nil {
+		t.Fatalf("could not configure GC: %v", err)
+	}
+	gcCheckpoint = func(m *mruby.Mrb) {
+		if _, err := m.LoadString("GC.start"); err != nil {
+			t.Fatalf("GC.start: %v", err)
+		}
+	}
+}
+
+func stopGCStress() {
+	gcCheckpoint = func(*mruby.Mrb) {}
+}
+
+// evalString evaluates code that refers to the value as `$value`, and returns the result as a string
+func evalString(t *testing.T, m *mruby.Mrb, value *mruby.MrbValue, code string) string {
+	m.SetGlobalVariable("$value", value)
+	result, err := m.LoadString(code)
+	if err != nil {
+		t.Fatalf("%s: %v", code, err)
+	}
+	return result.String()
+}
+
+func TestValuesSurviveGC(t *testing.T) {
+	rk := &RubyKube{mrb: mruby.NewMrb()}
+	defer rk.mrb.Close()
+	if err := rk.initGCRoots(); err != nil {
+		t.Fatal(err)
+	}
+	m := rk.mrb
+
+	gcStress(t, m)
+	defer stopGCStress()
+
+	items := []interface{}{}
+	for _, name := range []string{"web-a", "web-b", "web-c"} {
+		items = append(items, map[string]interface{}{
+			"name":   name,
+			"labels": map[string]string{"app": "web", "tier": "frontend"},
+			"ports":  []int{80, 443},
+		})
+	}
+	tree, err := nativeRubyValueOf(m, map[string]interface{}{"items": items, "count": 3})
+	if err != nil {
+		t.Fatal(err)
+	}
+	m.FullGC()
+
+	tests := []struct {
+		code, result string
+	}{
+		{`$value["items"].map { |i| i["name"] }.join(",")`, "web-a,web-b,web-c"},
+		{`$value["items"].map { |i| i["labels"]["tier"] }.join(",")`, "frontend,frontend,frontend"},
+		{`$value["items"].map { |i| i["ports"].inspect }.join(",")`, "[80, 443],[80, 443],[80, 443]"},
+		{`$value["count"].to_s`, "3"},
+	}
+	for _, test := range tests {
+		if result := evalString(t, m, tree, test.code); result != test.result {
+			t.Errorf("expected %q for %s, got %q", test.result, test.code, result)
+		}
+	}
+
+	plucked, err := newArrayOf(m, 3, func(i int) (mruby.Value, error) {
+		return pluckValue(m, testPod(), "spec.containers[*].image")
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	m.FullGC()
+	if result := evalString(t, m, plucked, `$value.inspect`); result != `[["nginx:1.13", "envoy:1.5"], ["nginx:1.13", "envoy:1.5"], ["nginx:1.13", "envoy:1.5"]]` {
+		t.Errorf("unexpected result of pluck: %s", result)
+	}
+
+	value, exc := stats(rk, nil, m, nil)
+	if exc != nil {
+		t.Fatalf("stats raised %v", exc)
+	}
+	result := value.MrbValue(m)
+	m.FullGC()
+	if keys := evalString(t, m, result, `$value.keys.sort.join(",")`); keys != "go_heap_bytes,go_heap_objects,instances,ruby_objects" {
+		t.Errorf("unexpected keys of stats: %s", keys)
+	}
+	if class := evalString(t, m, result, `$value["instances"].class.to_s`); class != "Hash" {
+		t.Errorf("expected instances to be a Hash, got %s", class)
+	}
+
+	// nothing is left protected once values are built
+	if size := evalString(t, m, m.NilValue(), `$__kubeplay_gc_roots.size.to_s`); size != "0" {
+		t.Errorf("expected no values to be left in GC roots, got %s", size)
+	}
+}
+
+func TestProtectionIsCounted(t *testing.T) {
+	rk := &RubyKube{mrb: mruby.NewMrb()}
+	defer rk.mrb.Close()
+	if err := rk.initGCRoots(); err != nil {
+		t.Fatal(err)
+	}
+	m := rk.mrb
+
+	block, err := m.LoadString(`Proc.new { |spec| ["privileged"] }`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	first := &securityRule{Name: "stress", block: block}
+	second := &securityRule{Name: "stress", block: block}
+	other := &securityRule{Name: "other", block: block}
+
+	// the same block is protected by a rule that replaces another one, and by a rule of another name
+	for _, rule := range []*securityRule{first, second, other} {
+		if err := rk.defineSecurityRule(rule); err != nil {
+			t.Fatal(err)
+		}
+	}
+	m.FullGC()
+	if result := evalString(t, m, m.NilValue(), `$__kubeplay_gc_roots.values.map { |b| b.call({}).first }.join(",")`); result != "privileged" {
+		t.Errorf("expected the block to be protected, got %q", result)
+	}
+
+	if err := rk.unprotect(block); err != nil {
+		t.Fatal(err)
+	}
+	if size := evalString(t, m, m.NilValue(), `$__kubeplay_gc_roots.size.to_s`); size != "1" {
+		t.Errorf("expected the block to stay protected for the other rule, got %s values", size)
+	}
+	if err := rk.unprotect(block); err != nil {
+		t.Fatal(err)
+	}
+	if size := evalString(t, m, m.NilValue(), `$__kubeplay_gc_roots.size.to_s + $__kubeplay_gc_root_counts.size.to_s`); size != "00" {
+		t.Errorf("expected nothing to be left in GC roots, got %s", size)
+	}
+}
+
+// newTestRubyKube makes a RubyKube backed by the offline test manifest, with everything
+// that would be written to ~/.kubeplay kept in a temporary directory
+func newTestRubyKube(t *testing.T, dir string) *RubyKube {
+	file := filepath.Join(dir, "manifest.yaml")
+	if err := ioutil.WriteFile(file, []byte(offlineManifest), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	flags := map[*string]string{
+		fromFile:     file,
+		fromDir:      "",
+		historyFile:  "",
+		recordFile:   "",
+		auditLogFile: "",
+		configFile:   filepath.Join(dir, "config.json"),
+		snapshotsDir: filepath.Join(dir, "snapshots"),
+	}
+	for f, value := range flags {
+		defer func(f *string, value string) { *f = value }(f, *f)
+		*f = value
+	}
+	defer func(value bool) { *noRC = value }(*noRC)
+	*noRC = true
+
+	rk, err := NewRubyKube([]string{}, nil)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	return rk
+}
+
+func TestVerbsUnderGCStress(t *testing.T) {
+	dir, err := ioutil.TempDir("", "kubeplay-gc")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	loadFile := filepath.Join(dir, "load.rb")
+	if err := ioutil.WriteFile(loadFile, []byte(`pods("*/").pluck("metadata.name")`), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	rk := newTestRubyKube(t, dir)
+	defer rk.Close()
+
+	gcStress(t, rk.mrb)
+	defer stopGCStress()
+
+	// the controller only stops on ^C, so it's the last one
+	tests := []struct {
+		verb, code string
+		err        bool
+	}{
+		{"pods", `pods("*/").where("spec.nodeName" => "node-1").pluck("metadata.name")`, false},
+		{"pods", `pods("prod/", fields: "spec.nodeName = node-2").pluck("spec.containers[*].image")`, false},
+		{"services", `services("*/")`, false},
+		{"deployments", `deployments("prod/web").first.pods`, false},
+		{"replicasets", `replicasets("*/", labels: "app=web")`, false},
+		{"daemonsets", `daemonsets("*/")`, false},
+		{"jobs", `jobs("*/")`, false},
+		{"events", `events("*/", since: "1h")`, false},
+		{"make_pod", `make_pod(image: "nginx")`, false},
+		{"make_label_selector", `make_label_selector("app in (foo, bar),!canary").matches?(pods("prod/web-a").first)`, false},
+		{"make_field_selector", `make_field_selector("spec.nodeName = node-1").matches?(pods("prod/web-a").first)`, false},
+		{"make_field_selector", `make_field_selector("spec.hostname != web").matches?(pods("prod/web-a").first)`, false},
+		{"using", `using(namespace: "prod")`, false},
+		{"namespace", `namespace "*"`, false},
+		{"def_alias", `def_alias :po, :pods ; po("dev/")`, false},
+		{"history", `history`, true},
+		{"audit", `audit "web"`, true},
+		{"wait_until", `wait_until(timeout: 5) { pods("prod/").pluck("metadata.name") }`, false},
+		{"describe", `describe("stress") { it("lists pods") { expect(pods("dev/").pluck("metadata.name")).to eq(["web-a"]) } }`, false},
+		{"it", `it("runs alone") { expect(1).to eq(1) }`, false},
+		{"after", `describe("cleanup") { after { pods("*/") } ; it("passes") { expect(1).to eq(1) } }`, false},
+		{"stats", `stats["instances"]`, false},
+		{"parallelism", `parallelism 2 ; parallelism`, false},
+		{"chaos", `chaos "prod/web-*", rounds: 2, interval: 0.01, probability: 1, dry_run: true`, false},
+		{"snapshot", `snapshot "stress", pods("*/"), deployments("*/")`, false},
+		{"compare_snapshot", `compare_snapshot "stress"`, false},
+		{"load_snapshot", `load_snapshot "stress"`, false},
+		{"can_i?", `can_i?(:delete, :pods, namespace: "prod")`, true},
+		{"who_can", `who_can(:get, :pods, namespace: "prod")`, false},
+		{"as", `as(user: "alice") { pods }`, true},
+		{"netpol", `netpol("prod").inspect`, false},
+		{"images", `images("*/").inspect`, false},
+		{"security_rule", `security_rule("stress", severity: :low, description: "stress") { |spec| [] }`, false},
+		{"security_rules", `security_rules`, false},
+		{"audit_security", `audit_security(pods("*/")).inspect`, false},
+		{"load", fmt.Sprintf(`load %q`, loadFile), false},
+		{"getenv", `getenv "HOME"`, false},
+		{"controller", `controller(:pods, "*/", resync: 0) { |event, pod| pod.to_ruby }`, false},
+	}
+
+	covered := map[string]bool{}
+	for _, test := range tests {
+		covered[test.verb] = true
+	}
+	for verb := range verbJumpTable {
+		if !covered[verb] {
+			t.Errorf("verb %q is not run under GC stress", verb)
+		}
+	}
+	for name := range funcJumpTable {
+		if !covered[name] {
+			t.Errorf("function %q is not run under GC stress", name)
+		}
+	}
+
+	for _, test := range tests {
+		stopped := make(chan struct{})
+		if test.verb == "controller" {
+			// ^C is sent until the controller stops, the test listens for it too, so it's not fatal
+			interrupt := make(chan os.Signal, 1)
+			signal.Notify(interrupt, os.Interrupt)
+			defer signal.Stop(interrupt)
+			go func() {
+				ticker := time.NewTicker(200 * time.Millisecond)
+				defer ticker.Stop()
+				for {
+					select {
+					case <-stopped:
+						return
+					case <-ticker.C:
+						syscall.Kill(os.Getpid(), syscall.SIGINT)
+					}
+				}
+			}()
+		}
+
+		_, err := rk.Run(test.code)
+		close(stopped)
+		if test.err && err == nil {
+			t.Errorf("expected an error for %s", test.code)
+		}
+		if !test.err && err != nil {
+			t.Errorf("unexpected error for %s: %s", test.code, FormatError(err))
+		}
+		rk.mrb.FullGC()
+	}
+
+	failed, err := rk.ReportTests(ioutil.Discard)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if failed > 0 {
+		t.Errorf("expected tests to pass, %d failed", failed)
+	}
+}
@@ -0,0 +1,738 @@
+package rubykube
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"net"
+	"os"
+	"os/signal"
+	"sort"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	mruby "github.com/mitchellh/go-mruby"
+	corev1 "k8s.io/api/core/v1"
+	networkingv1 "k8s.io/api/networking/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/util/intstr"
+	"k8s.io/apimachinery/pkg/watch"
+)
+
+const (
+	// netpolProbeLabel is set on probe pods to the name of the config map that owns them
+	netpolProbeLabel = "kubeplay/netpol-probe"
+	// netpolDefaultPort is tested for pod groups that don't declare any ports
+	netpolDefaultPort    = 80
+	netpolDefaultImage   = "busybox"
+	netpolConnectTimeout = 2
+)
+
+// netpolIgnoredLabels are set by controllers on each pod, so pods are grouped without them
+var netpolIgnoredLabels = map[string]bool{
+	"pod-template-hash":                  true,
+	"controller-revision-hash":           true,
+	"pod-template-generation":            true,
+	"controller-uid":                     true,
+	"job-name":                           true,
+	"statefulset.kubernetes.io/pod-name": true,
+}
+
+// netpolNameLabels are looked up in this order to name a group of pods
+var netpolNameLabels = []string{"app.kubernetes.io/name", "app", "k8s-app", "name", "run"}
+
+type netpolPort struct {
+	Name     string `json:"name,omitempty"`
+	Port     int32  `json:"port"`
+	Protocol string `json:"protocol"`
+}
+
+func (p netpolPort) String() string {
+	return fmt.Sprintf("%d/%s", p.Port, p.Protocol)
+}
+
+// podGroup is a set of pods with the same labels, all of these are selected by the same policies
+type podGroup struct {
+	Name   string            `json:"name"`
+	Labels map[string]string `json:"labels"`
+	Pods   []string          `json:"pods"`
+	Ports  []netpolPort      `json:"ports"`
+	IP     string            `json:"ip,omitempty"`
+}
+
+// netpolCell is expected, and once probed, actual connectivity from one group to a port of another
+type netpolCell struct {
+	From     string     `json:"from"`
+	To       string     `json:"to"`
+	Port     netpolPort `json:"port"`
+	Expected bool       `json:"expected"`
+	Actual   *bool      `json:"actual,omitempty"`
+	Reason   string     `json:"reason,omitempty"`
+}
+
+func (c *netpolCell) differs() bool {
+	return c.Actual != nil && *c.Actual != c.Expected
+}
+
+type netpolMatrix struct {
+	Namespace string       `json:"namespace"`
+	Policies  []string     `json:"policies"`
+	Groups    []podGroup   `json:"groups"`
+	Cells     []netpolCell `json:"cells"`
+	Probed    bool         `json:"probed"`
+
+	policies        []networkingv1.NetworkPolicy
+	namespaceLabels map[string]string
+}
+
+func allowedOrDenied(allowed bool) string {
+	if allowed {
+		return "allow"
+	}
+	return "deny"
+}
+
+// podGroups groups running and pending pods by their labels, pods with host network are left out,
+// as policies don't apply to these
+func podGroups(pods []corev1.Pod) []podGroup {
+	groups := []podGroup{}
+	index := map[string]int{}
+
+	for _, pod := range pods {
+		if pod.Spec.HostNetwork || (pod.Status.Phase != corev1.PodRunning && pod.Status.Phase != corev1.PodPending) {
+			continue
+		}
+
+		groupLabels := map[string]string{}
+		for k, v := range pod.ObjectMeta.Labels {
+			if !netpolIgnoredLabels[k] {
+				groupLabels[k] = v
+			}
+		}
+		key := labels.Set(groupLabels).String()
+
+		i, ok := index[key]
+		if !ok {
+			i = len(groups)
+			index[key] = i
+			groups = append(groups, podGroup{Labels: groupLabels, Ports: []netpolPort{}})
+			for _, container := range pod.Spec.Containers {
+				for _, port := range container.Ports {
+					protocol := port.Protocol
+					if protocol == "" {
+						protocol = corev1.ProtocolTCP
+					}
+					groups[i].Ports = append(groups[i].Ports, netpolPort{Name: port.Name, Port: port.ContainerPort, Protocol: string(protocol)})
+				}
+			}
+			groups[i].IP = pod.Status.PodIP
+		}
+		groups[i].Pods = append(groups[i].Pods, pod.ObjectMeta.Name)
+	}
+
+	sort.SliceStable(groups, func(i, j int) bool {
+		return labels.Set(groups[i].Labels).String() < labels.Set(groups[j].Labels).String()
+	})
+
+	names := map[string]int{}
+	for i := range groups {
+		name := groups[i].Pods[0]
+		for _, label := range netpolNameLabels {
+			if v, ok := groups[i].Labels[label]; ok {
+				name = v
+				break
+			}
+		}
+		if names[name]++; names[name] > 1 {
+			name = fmt.Sprintf("%s-%d", name, names[name])
+		}
+		groups[i].Name = name
+	}
+	return groups
+}
+
+func (g *podGroup) testedPorts() []netpolPort {
+	if len(g.Ports) == 0 {
+		return []netpolPort{{Port: netpolDefaultPort, Protocol: string(corev1.ProtocolTCP)}}
+	}
+	return g.Ports
+}
+
+// policyTypes returns whether the policy affects ingress and egress, policies without `policyTypes`
+// always affect ingress, and affect egress if they have egress rules
+func policyTypes(policy *networkingv1.NetworkPolicy) (ingress, egress bool) {
+	if len(policy.Spec.PolicyTypes) == 0 {
+		return true, len(policy.Spec.Egress) > 0
+	}
+	for _, t := range policy.Spec.PolicyTypes {
+		switch t {
+		case networkingv1.PolicyTypeIngress:
+			ingress = true
+		case networkingv1.PolicyTypeEgress:
+			egress = true
+		}
+	}
+	return ingress, egress
+}
+
+func selectorMatches(selector *metav1.LabelSelector, set map[string]string) (bool, error) {
+	s, err := metav1.LabelSelectorAsSelector(selector)
+	if err != nil {
+		return false, err
+	}
+	return s.Matches(labels.Set(set)), nil
+}
+
+// peerMatches checks whether a peer of a rule is the given group, all groups are in the namespace of the policy
+func (m *netpolMatrix) peerMatches(peer networkingv1.NetworkPolicyPeer, group *podGroup) (bool, error) {
+	if peer.IPBlock != nil {
+		_, cidr, err := net.ParseCIDR(peer.IPBlock.CIDR)
+		if err != nil {
+			return false, err
+		}
+		ip := net.ParseIP(group.IP)
+		if ip == nil || !cidr.Contains(ip) {
+			return false, nil
+		}
+		for _, except := range peer.IPBlock.Except {
+			if _, cidr, err := net.ParseCIDR(except); err == nil && cidr.Contains(ip) {
+				return false, nil
+			}
+		}
+		return true, nil
+	}
+
+	if peer.NamespaceSelector != nil {
+		ok, err := selectorMatches(peer.NamespaceSelector, m.namespaceLabels)
+		if !ok || err != nil {
+			return false, err
+		}
+	}
+	if peer.PodSelector != nil {
+		return selectorMatches(peer.PodSelector, group.Labels)
+	}
+	return true, nil
+}
+
+// peersMatch checks peers of a rule, a rule without peers matches everything
+func (m *netpolMatrix) peersMatch(peers []networkingv1.NetworkPolicyPeer, group *podGroup) (bool, error) {
+	if len(peers) == 0 {
+		return true, nil
+	}
+	for _, peer := range peers {
+		ok, err := m.peerMatches(peer, group)
+		if ok || err != nil {
+			return ok, err
+		}
+	}
+	return false, nil
+}
+
+// portsMatch checks ports of a rule, named ports are looked up in ports of the destination
+func portsMatch(ports []networkingv1.NetworkPolicyPort, port netpolPort) bool {
+	if len(ports) == 0 {
+		return true
+	}
+	for _, p := range ports {
+		protocol := corev1.ProtocolTCP
+		if p.Protocol != nil {
+			protocol = *p.Protocol
+		}
+		if string(protocol) != port.Protocol {
+			continue
+		}
+		if p.Port == nil {
+			return true
+		}
+		if p.Port.Type == intstr.Int && p.Port.IntVal == port.Port {
+			return true
+		}
+		if p.Port.Type == intstr.String && port.Name != "" && p.Port.StrVal == port.Name {
+			return true
+		}
+	}
+	return false
+}
+
+// allowed evaluates policies for a single connection, it has to be allowed on both ends: by egress
+// rules of policies that select the source, and by ingress rules of those that select the destination
+func (m *netpolMatrix) allowed(from, to *podGroup, port netpolPort) (bool, string, error) {
+	egressPolicies, ingressPolicies := []string{}, []string{}
+
+	for i := range m.policies {
+		policy := &m.policies[i]
+		ingress, egress := policyTypes(policy)
+
+		if egress {
+			selected, err := selectorMatches(&policy.Spec.PodSelector, from.Labels)
+			if err != nil {
+				return false, "", fmt.Errorf("invalid pod selector of %q – %v", policy.ObjectMeta.Name, err)
+			}
+			if selected {
+				egressPolicies = append(egressPolicies, policy.ObjectMeta.Name)
+			}
+		}
+		if ingress {
+			selected, err := selectorMatches(&policy.Spec.PodSelector, to.Labels)
+			if err != nil {
+				return false, "", fmt.Errorf("invalid pod selector of %q – %v", policy.ObjectMeta.Name, err)
+			}
+			if selected {
+				ingressPolicies = append(ingressPolicies, policy.ObjectMeta.Name)
+			}
+		}
+	}
+
+	// connections are only restricted by policies that select either end of it
+	egressAllowed := len(egressPolicies) == 0
+	ingressAllowed := len(ingressPolicies) == 0
+
+	for i := range m.policies {
+		policy := &m.policies[i]
+		ingress, egress := policyTypes(policy)
+
+		if egress && !egressAllowed && contains(egressPolicies, policy.ObjectMeta.Name) {
+			for _, rule := range policy.Spec.Egress {
+				ok, err := m.peersMatch(rule.To, to)
+				if err != nil {
+					return false, "", fmt.Errorf("invalid egress rule of %q – %v", policy.ObjectMeta.Name, err)
+				}
+				if ok && portsMatch(rule.Ports, port) {
+					egressAllowed = true
+					break
+				}
+			}
+		}
+		if ingress && !ingressAllowed && contains(ingressPolicies, policy.ObjectMeta.Name) {
+			for _, rule := range policy.Spec.Ingress {
+				ok, err := m.peersMatch(rule.From, from)
+				if err != nil {
+					return false, "", fmt.Errorf("invalid ingress rule of %q – %v", policy.ObjectMeta.Name, err)
+				}
+				if ok && portsMatch(rule.Ports, port) {
+					ingressAllowed = true
+					break
+				}
+			}
+		}
+	}
+
+	reasons := []string{}
+	if !egressAllowed {
+		reasons = append(reasons, fmt.Sprintf("egress from %s is not allowed by %s", from.Name, strings.Join(egressPolicies, ", ")))
+	}
+	if !ingressAllowed {
+		reasons = append(reasons, fmt.Sprintf("ingress to %s is not allowed by %s", to.Name, strings.Join(ingressPolicies, ", ")))
+	}
+	return egressAllowed && ingressAllowed, strings.Join(reasons, "; "), nil
+}
+
+// networkPolicyMatrix computes expected connectivity between groups of pods in the namespace
+func (rk *RubyKube) networkPolicyMatrix(ns string) (*netpolMatrix, error) {
+	var (
+		policies  *networkingv1.NetworkPolicyList
+		pods      *corev1.PodList
+		namespace *corev1.Namespace
+	)
+
+	listers := []func() error{
+		func() (err error) {
+			policies, err = rk.clientset.Networking().NetworkPolicies(ns).List(metav1.ListOptions{})
+			return err
+		},
+		func() (err error) {
+			pods, err = rk.clientset.Core().Pods(ns).List(metav1.ListOptions{LabelSelector: "!" + netpolProbeLabel})
+			return err
+		},
+		func() (err error) {
+			// a dump may not have namespaces in it, so labels are assumed to be empty
+			if namespace, err = rk.clientset.Core().Namespaces().Get(ns, metav1.GetOptions{}); apierrors.IsNotFound(err) {
+				namespace, err = &corev1.Namespace{}, nil
+			}
+			return err
+		},
+	}
+	if err := rk.parallel(len(listers), func(i int) error { return listers[i]() }); err != nil {
+		return nil, err
+	}
+
+	m := &netpolMatrix{
+		Namespace:       ns,
+		Policies:        []string{},
+		Groups:          podGroups(pods.Items),
+		Cells:           []netpolCell{},
+		policies:        policies.Items,
+		namespaceLabels: namespace.ObjectMeta.Labels,
+	}
+	sort.Slice(m.policies, func(i, j int) bool { return m.policies[i].ObjectMeta.Name < m.policies[j].ObjectMeta.Name })
+	for _, policy := range m.policies {
+		m.Policies = append(m.Policies, policy.ObjectMeta.Name)
+	}
+
+	for i := range m.Groups {
+		for j := range m.Groups {
+			for _, port := range m.Groups[j].testedPorts() {
+				allowed, reason, err := m.allowed(&m.Groups[i], &m.Groups[j], port)
+				if err != nil {
+					return nil, err
+				}
+				m.Cells = append(m.Cells, netpolCell{
+					From:     m.Groups[i].Name,
+					To:       m.Groups[j].Name,
+					Port:     port,
+					Expected: allowed,
+					Reason:   reason,
+				})
+			}
+		}
+	}
+	return m, nil
+}
+
+func (m *netpolMatrix) differences() []netpolCell {
+	differences := []netpolCell{}
+	for _, cell := range m.Cells {
+		if cell.differs() {
+			differences = append(differences, cell)
+		}
+	}
+	return differences
+}
+
+// Table returns the matrix with a row for each source group, and a column for each port of
+// each destination group; cells that differ from what the probes found are marked with `!`
+func (m *netpolMatrix) Table() string {
+	text := bytes.Buffer{}
+	w := tabwriter.NewWriter(&text, 0, 8, 2, ' ', 0)
+
+	columns := []string{}
+	for _, cell := range m.Cells {
+		if cell.From != m.Cells[0].From {
+			break
+		}
+		columns = append(columns, fmt.Sprintf("%s:%s", cell.To, cell.Port))
+	}
+	fmt.Fprintf(w, "FROM \\ TO\t%s\n", strings.Join(columns, "\t"))
+
+	for i := 0; i < len(m.Cells); i += len(columns) {
+		row := []string{}
+		for _, cell := range m.Cells[i : i+len(columns)] {
+			value := allowedOrDenied(cell.Expected)
+			if cell.differs() {
+				value = fmt.Sprintf("%s!%s", value, allowedOrDenied(*cell.Actual))
+			}
+			row = append(row, value)
+		}
+		fmt.Fprintf(w, "%s\t%s\n", m.Cells[i].From, strings.Join(row, "\t"))
+	}
+	w.Flush()
+	return text.String()
+}
+
+// DifferencesTable returns cells where probes found something other than expected
+func (m *netpolMatrix) DifferencesTable() string {
+	text := bytes.Buffer{}
+	w := tabwriter.NewWriter(&text, 0, 8, 2, ' ', 0)
+	fmt.Fprintln(w, "FROM\tTO\tPORT\tEXPECTED\tACTUAL\tREASON")
+	for _, cell := range m.differences() {
+		reason := cell.Reason
+		if reason == "" {
+			reason = "allowed by policies"
+		}
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\t%s\n", cell.From, cell.To, cell.Port,
+			allowedOrDenied(cell.Expected), allowedOrDenied(*cell.Actual), reason)
+	}
+	w.Flush()
+	return text.String()
+}
+
+func (m *netpolMatrix) String() string {
+	text := bytes.Buffer{}
+
+	policies := "none"
+	if len(m.Policies) > 0 {
+		policies = strings.Join(m.Policies, ", ")
+	}
+	fmt.Fprintf(&text, "Network policies in namespace %q: %s\n", m.Namespace, policies)
+	if len(m.Groups) == 0 {
+		fmt.Fprintln(&text, "No pods")
+		return text.String()
+	}
+
+	fmt.Fprintln(&text, "Pod groups:")
+	for _, g := range m.Groups {
+		fmt.Fprintf(&text, "  %s: %s (%d pods)\n", g.Name, labels.Set(g.Labels), len(g.Pods))
+	}
+	fmt.Fprintln(&text)
+	fmt.Fprint(&text, m.Table())
+
+	if !m.Probed {
+		return text.String()
+	}
+	fmt.Fprintln(&text)
+	if len(m.differences()) == 0 {
+		fmt.Fprintln(&text, "Probes found no differences")
+		return text.String()
+	}
+	fmt.Fprintln(&text, "Probes found differences:")
+	fmt.Fprint(&text, m.DifferencesTable())
+	return text.String()
+}
+
+// probeOptions holds arguments of `probe!`
+type probeOptions struct {
+	image   string
+	timeout time.Duration
+}
+
+func parseProbeOptions(args []*mruby.MrbValue) (*probeOptions, error) {
+	o := &probeOptions{image: netpolDefaultImage, timeout: defaultWaitTimeout}
+	for _, arg := range args {
+		if arg.Type() != mruby.TypeHash {
+			return nil, newArgumentError("Arguments must be a hash, e.g. `probe!(image: \"busybox\", timeout: 120)`")
+		}
+		if err := iterateHash(arg, func(key, value *mruby.MrbValue) error {
+			switch k := key.String(); k {
+			case "image":
+				o.image = value.String()
+			case "timeout":
+				d, err := durationValue(k, value)
+				if err != nil {
+					return err
+				}
+				o.timeout = d
+			default:
+				return newArgumentError("unknown parameter %q – not one of [image timeout]", k)
+			}
+			return nil
+		}); err != nil {
+			return nil, err
+		}
+	}
+	if o.timeout <= 0 {
+		return nil, newArgumentError("timeout must be positive")
+	}
+	return o, nil
+}
+
+// probeNamePrefix makes a valid pod name prefix of a group name, which comes from a label value, so it may
+// have uppercase letters, dots or underscores, and it may be too long for a pod name
+func probeNamePrefix(groupName, role string) string {
+	name := strings.Map(func(r rune) rune {
+		switch {
+		case r >= 'a' && r <= 'z', r >= '0' && r <= '9':
+			return r
+		case r >= 'A' && r <= 'Z':
+			return r - 'A' + 'a'
+		}
+		return '-'
+	}, groupName)
+	if len(name) > 40 {
+		name = name[:40]
+	}
+	if name = strings.Trim(name, "-"); name == "" {
+		name = "group"
+	}
+	return fmt.Sprintf("netpol-%s-%s-", name, role)
+}
+
+// probePod makes a pod with the labels of the group, so it's selected by the same policies; it's owned by
+// a config map, so controllers don't adopt it, and it's never ready, so services don't send traffic to it
+func probePod(group *podGroup, owner *corev1.ConfigMap, image, role, command string) *corev1.Pod {
+	pod := newPod(image, "probe")
+	pod.ObjectMeta.Name = ""
+	pod.ObjectMeta.GenerateName = probeNamePrefix(group.Name, role)
+	pod.ObjectMeta.Labels = map[string]string{netpolProbeLabel: owner.ObjectMeta.Name}
+	for k, v := range group.Labels {
+		pod.ObjectMeta.Labels[k] = v
+	}
+	pod.ObjectMeta.OwnerReferences = []metav1.OwnerReference{{
+		APIVersion: "v1",
+		Kind:       "ConfigMap",
+		Name:       owner.ObjectMeta.Name,
+		UID:        owner.ObjectMeta.UID,
+		Controller: func(b bool) *bool { return &b }(true),
+	}}
+
+	pod.Spec.RestartPolicy = corev1.RestartPolicyNever
+	pod.Spec.Containers[0].Command = []string{"sh", "-c", command}
+	pod.Spec.Containers[0].ReadinessProbe = &corev1.Probe{
+		Handler: corev1.Handler{Exec: &corev1.ExecAction{Command: []string{"false"}}},
+	}
+	return &pod
+}
+
+// waitForPod waits until the condition is met by a probe pod
+func (rk *RubyKube) waitForPod(pod *corev1.Pod, what string, timeout time.Duration, condition func(pod *corev1.Pod) bool) (*corev1.Pod, error) {
+	pods := rk.clientset.Core().Pods(pod.ObjectMeta.Namespace)
+	obj, err := rk.waitForObject(what, pod.ObjectMeta.Name, timeout,
+		func() (runtime.Object, error) { return pods.Get(pod.ObjectMeta.Name, metav1.GetOptions{}) },
+		func(listOptions metav1.ListOptions) (watch.Interface, error) { return pods.Watch(listOptions) },
+		func(obj runtime.Object) (bool, error) {
+			if obj == nil {
+				return false, fmt.Errorf("probe pod %s has been deleted", pod.ObjectMeta.Name)
+			}
+			return condition(obj.(*corev1.Pod)), nil
+		})
+	if err != nil {
+		return nil, err
+	}
+	return obj.(*corev1.Pod), nil
+}
+
+// probe launches a server pod and a client pod for each group, servers listen on ports of the group, and
+// clients try to connect to each of these; only TCP ports are probed, the results are read from logs
+// of the clients, and all probe pods are deleted afterwards
+func (rk *RubyKube) probe(m *netpolMatrix, options *probeOptions) error {
+	if rk.offline != nil {
+		return fmt.Errorf("probes need a cluster, these cannot be run in offline mode")
+	}
+	if len(m.Groups) == 0 {
+		return nil
+	}
+
+	// ^C must not kill kubeplay before probe pods are deleted, so it's handled until the cleanup
+	// has run; waits return when it's pressed, and it's checked before other steps
+	interrupt := make(chan os.Signal, 1)
+	signal.Notify(interrupt, os.Interrupt)
+	defer signal.Stop(interrupt)
+	interrupted := func() bool {
+		select {
+		case <-interrupt:
+			return true
+		default:
+			return false
+		}
+	}
+
+	ns := m.Namespace
+	owner, err := rk.clientset.Core().ConfigMaps(ns).Create(&corev1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{
+			GenerateName: "kubeplay-netpol-probe-",
+			Labels:       map[string]string{"kubeplay/temporary": "true"},
+		},
+	})
+	if err != nil {
+		return err
+	}
+	defer func() {
+		rk.clientset.Core().Pods(ns).DeleteCollection(&metav1.DeleteOptions{}, metav1.ListOptions{
+			LabelSelector: fmt.Sprintf("%s=%s", netpolProbeLabel, owner.ObjectMeta.Name),
+		})
+		rk.clientset.Core().ConfigMaps(ns).Delete(owner.ObjectMeta.Name, &metav1.DeleteOptions{})
+	}()
+
+	servers := make([]*corev1.Pod, len(m.Groups))
+	for i := range m.Groups {
+		listeners := []string{}
+		for _, port := range m.Groups[i].testedPorts() {
+			if port.Protocol == string(corev1.ProtocolTCP) {
+				listeners = append(listeners, fmt.Sprintf("nc -lk -p %d &", port.Port))
+			}
+		}
+		if interrupted() {
+			return newInterruptedError()
+		}
+		pod := probePod(&m.Groups[i], owner, options.image, "server", strings.Join(append(listeners, "wait"), " "))
+		for _, port := range m.Groups[i].Ports {
+			pod.Spec.Containers[0].Ports = append(pod.Spec.Containers[0].Ports, corev1.ContainerPort{
+				Name:          port.Name,
+				ContainerPort: port.Port,
+				Protocol:      corev1.Protocol(port.Protocol),
+			})
+		}
+		if servers[i], err = rk.clientset.Core().Pods(ns).Create(pod); err != nil {
+			return err
+		}
+	}
+
+	ips := map[string]string{}
+	for i, server := range servers {
+		pod, err := rk.waitForPod(server, fmt.Sprintf("probe server of %s", m.Groups[i].Name), options.timeout, func(pod *corev1.Pod) bool {
+			return pod.Status.Phase == corev1.PodRunning && pod.Status.PodIP != ""
+		})
+		if err != nil {
+			return err
+		}
+		ips[m.Groups[i].Name] = pod.Status.PodIP
+	}
+
+	// each client prints `<cell index> ok|fail`, a client runs checks of a single row of the matrix
+	clients := map[string]*corev1.Pod{}
+	for i := range m.Groups {
+		script := []string{"sleep 2"}
+		for j, cell := range m.Cells {
+			if cell.From != m.Groups[i].Name || cell.Port.Protocol != string(corev1.ProtocolTCP) {
+				continue
+			}
+			script = append(script, fmt.Sprintf("if nc -w %d %s %d </dev/null >/dev/null 2>&1; then echo '%d ok'; else echo '%d fail'; fi",
+				netpolConnectTimeout, ips[cell.To], cell.Port.Port, j, j))
+		}
+		if interrupted() {
+			return newInterruptedError()
+		}
+		pod := probePod(&m.Groups[i], owner, options.image, "client", strings.Join(script, "; "))
+		if clients[m.Groups[i].Name], err = rk.clientset.Core().Pods(ns).Create(pod); err != nil {
+			return err
+		}
+	}
+
+	for i := range m.Groups {
+		pod, err := rk.waitForPod(clients[m.Groups[i].Name], fmt.Sprintf("probes from %s", m.Groups[i].Name), options.timeout, func(pod *corev1.Pod) bool {
+			return pod.Status.Phase == corev1.PodSucceeded || pod.Status.Phase == corev1.PodFailed
+		})
+		if err != nil {
+			return err
+		}
+
+		logs, err := rk.fetchLogs(pod, &corev1.PodLogOptions{})
+		if err != nil {
+			return err
+		}
+		scanner := bufio.NewScanner(logs)
+		for scanner.Scan() {
+			fields := strings.Fields(scanner.Text())
+			if len(fields) != 2 {
+				continue
+			}
+			j, err := strconv.Atoi(fields[0])
+			if err != nil || j < 0 || j >= len(m.Cells) {
+				continue
+			}
+			actual := fields[1] == "ok"
+			m.Cells[j].Actual = &actual
+		}
+	}
+
+	m.Probed = true
+	return nil
+}
+
+// netpol implements `netpol` verb, which computes expected connectivity between groups of pods in the
+// namespace (current namespace by default) from its network policies, e.g. `netpol("prod").probe!`
+func netpol(rk *RubyKube, args []*mruby.MrbValue, m *mruby.Mrb, self *mruby.MrbValue) (mruby.Value, mruby.Value) {
+	ns := rk.GetDefaultNamespace("")
+	if len(args) > 0 {
+		if args[0].Type() != mruby.TypeString && args[0].Type() != mruby.TypeSymbol {
+			return nil, createException(m, "Namespace must be a string")
+		}
+		ns = args[0].String()
+	}
+
+	matrix, err := rk.networkPolicyMatrix(ns)
+	if err != nil {
+		return nil, createError(m, err)
+	}
+
+	newMatrixObj, err := rk.classes.NetpolMatrix.New()
+	if err != nil {
+		return nil, createError(m, err)
+	}
+	newMatrixObj.vars.matrix = matrix
+	return newMatrixObj.self, nil
+}
@@ -0,0 +1,216 @@
+package rubykube
+
+import (
+	"reflect"
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	networkingv1 "k8s.io/api/networking/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/intstr"
+	"k8s.io/apimachinery/pkg/util/validation"
+)
+
+func netpolTestPod(name string, phase corev1.PodPhase, ip string, podLabels map[string]string, ports ...corev1.ContainerPort) corev1.Pod {
+	return corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: "prod", Labels: podLabels},
+		Spec:       corev1.PodSpec{Containers: []corev1.Container{{Name: "main", Image: "nginx", Ports: ports}}},
+		Status:     corev1.PodStatus{Phase: phase, PodIP: ip},
+	}
+}
+
+func TestPodGroups(t *testing.T) {
+	hostNetwork := netpolTestPod("node-exporter-x1", corev1.PodRunning, "10.1.0.1", map[string]string{"app": "node-exporter"})
+	hostNetwork.Spec.HostNetwork = true
+
+	pods := []corev1.Pod{
+		netpolTestPod("web-1", corev1.PodRunning, "10.0.0.1", map[string]string{"app": "web", "pod-template-hash": "1234"},
+			corev1.ContainerPort{Name: "http", ContainerPort: 8080}, corev1.ContainerPort{ContainerPort: 53, Protocol: corev1.ProtocolUDP}),
+		netpolTestPod("web-2", corev1.PodPending, "", map[string]string{"app": "web", "pod-template-hash": "5678"}),
+		netpolTestPod("web-canary", corev1.PodRunning, "10.0.0.3", map[string]string{"app": "web", "track": "canary"}),
+		netpolTestPod("db-0", corev1.PodRunning, "10.0.0.4", map[string]string{"k8s-app": "db", "app.kubernetes.io/name": "postgres"}),
+		netpolTestPod("standalone", corev1.PodRunning, "10.0.0.5", map[string]string{"tier": "misc"}),
+		netpolTestPod("job-x", corev1.PodSucceeded, "10.0.0.6", map[string]string{"app": "job"}),
+		hostNetwork,
+	}
+
+	expected := []podGroup{
+		{
+			Name:   "postgres",
+			Labels: map[string]string{"k8s-app": "db", "app.kubernetes.io/name": "postgres"},
+			Pods:   []string{"db-0"},
+			Ports:  []netpolPort{},
+			IP:     "10.0.0.4",
+		},
+		{
+			Name:   "web",
+			Labels: map[string]string{"app": "web"},
+			Pods:   []string{"web-1", "web-2"},
+			Ports:  []netpolPort{{Name: "http", Port: 8080, Protocol: "TCP"}, {Port: 53, Protocol: "UDP"}},
+			IP:     "10.0.0.1",
+		},
+		{
+			Name:   "web-2",
+			Labels: map[string]string{"app": "web", "track": "canary"},
+			Pods:   []string{"web-canary"},
+			Ports:  []netpolPort{},
+			IP:     "10.0.0.3",
+		},
+		{
+			Name:   "standalone",
+			Labels: map[string]string{"tier": "misc"},
+			Pods:   []string{"standalone"},
+			Ports:  []netpolPort{},
+			IP:     "10.0.0.5",
+		},
+	}
+
+	groups := podGroups(pods)
+	if len(groups) != len(expected) {
+		t.Fatalf("expected %d groups, got %d: %+v", len(expected), len(groups), groups)
+	}
+	for i := range expected {
+		if !reflect.DeepEqual(groups[i], expected[i]) {
+			t.Errorf("expected group %d to be %+v, got %+v", i, expected[i], groups[i])
+		}
+	}
+}
+
+func TestProbeNamePrefix(t *testing.T) {
+	tests := []struct {
+		group, prefix string
+	}{
+		{"web", "netpol-web-client-"},
+		{"Web_Frontend.v2", "netpol-web-frontend-v2-client-"},
+		{"--api--", "netpol-api-client-"},
+		{"___", "netpol-group-client-"},
+		{"a-very-long-application-name-that-goes-on-and-on", "netpol-a-very-long-application-name-that-goes-o-client-"},
+	}
+
+	for _, test := range tests {
+		prefix := probeNamePrefix(test.group, "client")
+		if prefix != test.prefix {
+			t.Errorf("expected prefix of %q to be %q, got %q", test.group, test.prefix, prefix)
+		}
+		// the API server appends 5 random characters
+		if errs := validation.IsDNS1123Label(prefix + "x7k2q"); len(errs) > 0 {
+			t.Errorf("prefix %q of %q is not valid: %v", prefix, test.group, errs)
+		}
+	}
+}
+
+func TestNetpolAllowed(t *testing.T) {
+	tcp := corev1.ProtocolTCP
+	httpPort := intstr.FromString("http")
+	dbPort := intstr.FromInt(5432)
+
+	web := &podGroup{Name: "web", Labels: map[string]string{"app": "web"}, IP: "10.0.2.5"}
+	api := &podGroup{Name: "api", Labels: map[string]string{"app": "api"}, IP: "10.0.1.5"}
+	db := &podGroup{Name: "db", Labels: map[string]string{"app": "db"}, IP: "10.0.3.5"}
+
+	http := netpolPort{Name: "http", Port: 8080, Protocol: "TCP"}
+	metrics := netpolPort{Port: 9090, Protocol: "TCP"}
+	postgres := netpolPort{Port: 5432, Protocol: "TCP"}
+	dns := netpolPort{Port: 53, Protocol: "UDP"}
+
+	selects := func(app string) metav1.LabelSelector {
+		return metav1.LabelSelector{MatchLabels: map[string]string{"app": app}}
+	}
+	policy := func(name string, spec networkingv1.NetworkPolicySpec) networkingv1.NetworkPolicy {
+		return networkingv1.NetworkPolicy{ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: "prod"}, Spec: spec}
+	}
+
+	// no policyTypes, so it only affects ingress, as it has no egress rules
+	dbIngress := policy("db-ingress", networkingv1.NetworkPolicySpec{
+		PodSelector: selects("db"),
+		Ingress: []networkingv1.NetworkPolicyIngressRule{{
+			From:  []networkingv1.NetworkPolicyPeer{{PodSelector: &metav1.LabelSelector{MatchLabels: map[string]string{"app": "api"}}}},
+			Ports: []networkingv1.NetworkPolicyPort{{Protocol: &tcp, Port: &dbPort}},
+		}},
+	})
+	// no policyTypes, but it has egress rules, so it affects both
+	apiEgress := policy("api-egress", networkingv1.NetworkPolicySpec{
+		PodSelector: selects("api"),
+		Egress: []networkingv1.NetworkPolicyEgressRule{{
+			To: []networkingv1.NetworkPolicyPeer{{PodSelector: &metav1.LabelSelector{MatchLabels: map[string]string{"app": "db"}}}},
+		}},
+	})
+	// egress only, so ingress to web isn't restricted, even though there are no ingress rules
+	webEgressOnly := policy("web-egress-only", networkingv1.NetworkPolicySpec{
+		PodSelector: selects("web"),
+		PolicyTypes: []networkingv1.PolicyType{networkingv1.PolicyTypeEgress},
+	})
+	webNamedPort := policy("web-http", networkingv1.NetworkPolicySpec{
+		PodSelector: selects("web"),
+		PolicyTypes: []networkingv1.PolicyType{networkingv1.PolicyTypeIngress},
+		Ingress: []networkingv1.NetworkPolicyIngressRule{{
+			Ports: []networkingv1.NetworkPolicyPort{{Port: &httpPort}},
+		}},
+	})
+	webIPBlock := policy("web-ip-block", networkingv1.NetworkPolicySpec{
+		PodSelector: selects("web"),
+		PolicyTypes: []networkingv1.PolicyType{networkingv1.PolicyTypeIngress},
+		Ingress: []networkingv1.NetworkPolicyIngressRule{{
+			From: []networkingv1.NetworkPolicyPeer{{IPBlock: &networkingv1.IPBlock{CIDR: "10.0.0.0/16", Except: []string{"10.0.1.0/24"}}}},
+		}},
+	})
+	dbNamespace := policy("db-namespace", networkingv1.NetworkPolicySpec{
+		PodSelector: selects("db"),
+		PolicyTypes: []networkingv1.PolicyType{networkingv1.PolicyTypeIngress},
+		Ingress: []networkingv1.NetworkPolicyIngressRule{{
+			From: []networkingv1.NetworkPolicyPeer{{
+				NamespaceSelector: &metav1.LabelSelector{MatchLabels: map[string]string{"team": "payments"}},
+				PodSelector:       &metav1.LabelSelector{MatchLabels: map[string]string{"app": "api"}},
+			}},
+		}},
+	})
+
+	tests := []struct {
+		name            string
+		policies        []networkingv1.NetworkPolicy
+		namespaceLabels map[string]string
+		from, to        *podGroup
+		port            netpolPort
+		allowed         bool
+		reason          string
+	}{
+		{"no policies", nil, nil, web, db, postgres, true, ""},
+
+		{"ingress by default", []networkingv1.NetworkPolicy{dbIngress}, nil, api, db, postgres, true, ""},
+		{"ingress from another group", []networkingv1.NetworkPolicy{dbIngress}, nil, web, db, postgres, false, "ingress to db is not allowed by db-ingress"},
+		{"ingress to another port", []networkingv1.NetworkPolicy{dbIngress}, nil, api, db, metrics, false, "ingress to db is not allowed by db-ingress"},
+		{"no egress without egress rules", []networkingv1.NetworkPolicy{dbIngress}, nil, db, web, http, true, ""},
+
+		{"egress with egress rules", []networkingv1.NetworkPolicy{apiEgress}, nil, api, db, postgres, true, ""},
+		{"egress to another group", []networkingv1.NetworkPolicy{apiEgress}, nil, api, web, http, false, "egress from api is not allowed by api-egress"},
+		{"ingress with egress rules", []networkingv1.NetworkPolicy{apiEgress}, nil, web, api, http, false, "ingress to api is not allowed by api-egress"},
+		{"both ends", []networkingv1.NetworkPolicy{apiEgress, dbIngress}, nil, api, web, http, false, "egress from api is not allowed by api-egress"},
+
+		{"egress only", []networkingv1.NetworkPolicy{webEgressOnly}, nil, api, web, http, true, ""},
+		{"egress only without rules", []networkingv1.NetworkPolicy{webEgressOnly}, nil, web, api, http, false, "egress from web is not allowed by web-egress-only"},
+
+		{"named port", []networkingv1.NetworkPolicy{webNamedPort}, nil, api, web, http, true, ""},
+		{"unnamed port", []networkingv1.NetworkPolicy{webNamedPort}, nil, api, web, metrics, false, "ingress to web is not allowed by web-http"},
+		{"named port of another protocol", []networkingv1.NetworkPolicy{webNamedPort}, nil, api, web, netpolPort{Name: "http", Port: 8080, Protocol: "UDP"}, false, "ingress to web is not allowed by web-http"},
+
+		{"ip block", []networkingv1.NetworkPolicy{webIPBlock}, nil, db, web, http, true, ""},
+		{"ip block except", []networkingv1.NetworkPolicy{webIPBlock}, nil, api, web, http, false, "ingress to web is not allowed by web-ip-block"},
+		{"ip block any policy", []networkingv1.NetworkPolicy{webIPBlock, webNamedPort}, nil, api, web, http, true, ""},
+
+		{"namespace selector", []networkingv1.NetworkPolicy{dbNamespace}, map[string]string{"team": "payments"}, api, db, dns, true, ""},
+		{"namespace selector of another namespace", []networkingv1.NetworkPolicy{dbNamespace}, map[string]string{"team": "search"}, api, db, dns, false, "ingress to db is not allowed by db-namespace"},
+		{"namespace selector without labels", []networkingv1.NetworkPolicy{dbNamespace}, nil, api, db, dns, false, "ingress to db is not allowed by db-namespace"},
+	}
+
+	for _, test := range tests {
+		m := &netpolMatrix{policies: test.policies, namespaceLabels: test.namespaceLabels}
+		allowed, reason, err := m.allowed(test.from, test.to, test.port)
+		if err != nil {
+			t.Errorf("%s: unexpected error: %v", test.name, err)
+			continue
+		}
+		if allowed != test.allowed || reason != test.reason {
+			t.Errorf("%s: expected %v (%q), got %v (%q)", test.name, test.allowed, test.reason, allowed, reason)
+		}
+	}
+}
@@ -0,0 +1,353 @@
+package rubykube
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/apimachinery/pkg/fields"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/util/yaml"
+	"k8s.io/apimachinery/pkg/watch"
+	"k8s.io/client-go/kubernetes/fake"
+	"k8s.io/client-go/kubernetes/scheme"
+	"k8s.io/client-go/testing"
+)
+
+var (
+	fromDir  = flag.String("from-dir", "", "load objects from manifests in `directory` (e.g. output of `kubectl cluster-info dump --output-directory`) instead of connecting to a cluster")
+	fromFile = flag.String("from-file", "", "load objects from a manifest `file` instead of connecting to a cluster")
+)
+
+// appsKinds have moved to `apps/v1` from `extensions/v1beta1` and earlier versions of `apps`, kubeplay
+// only uses `apps/v1`, so objects of older versions are loaded as `apps/v1`
+var appsKinds = map[string]bool{
+	"Deployment":  true,
+	"ReplicaSet":  true,
+	"DaemonSet":   true,
+	"StatefulSet": true,
+}
+
+// fieldAliases are field selectors that don't match the path of the field they select by
+var fieldAliases = map[string]string{
+	"source": "source.component",
+}
+
+// offlineCluster backs the clientset with an object tracker filled from manifests, so verbs work the same
+// way as they do with a cluster; changes are only made in memory
+type offlineCluster struct {
+	source  string
+	dir     string
+	tracker testing.ObjectTracker
+	// loaded counts objects by kind
+	loaded map[string]int
+	// skipped counts objects of kinds that are not known to the clientset
+	skipped map[string]int
+}
+
+func offlineMode() bool {
+	return *fromDir != "" || *fromFile != ""
+}
+
+// newOfflineClientset loads objects from `-from-dir` or `-from-file`; list requests are filtered by
+// field selectors, which the fake clientset doesn't do by itself, and watches never get any events
+func newOfflineClientset() (*fake.Clientset, *offlineCluster, error) {
+	if *fromDir != "" && *fromFile != "" {
+		return nil, nil, fmt.Errorf("only one of -from-dir and -from-file can be used")
+	}
+
+	o := &offlineCluster{
+		source:  *fromFile,
+		dir:     *fromDir,
+		tracker: testing.NewObjectTracker(scheme.Scheme, scheme.Codecs.UniversalDecoder()),
+		loaded:  map[string]int{},
+		skipped: map[string]int{},
+	}
+
+	if o.dir != "" {
+		o.source = o.dir
+		if err := o.loadDir(o.dir); err != nil {
+			return nil, nil, err
+		}
+	} else if err := o.loadFile(o.source); err != nil {
+		return nil, nil, err
+	}
+
+	clientset := fake.NewSimpleClientset()
+	clientset.ReactionChain = nil
+	clientset.AddReactor("create", "pods", o.evict)
+	clientset.AddReactor("list", "*", o.list)
+	clientset.AddReactor("*", "*", testing.ObjectReaction(o.tracker))
+	clientset.WatchReactionChain = nil
+	clientset.AddWatchReactor("*", func(action testing.Action) (bool, watch.Interface, error) {
+		return true, watch.NewFake(), nil
+	})
+	return clientset, o, nil
+}
+
+func isManifest(file string) bool {
+	switch filepath.Ext(file) {
+	case ".json", ".yaml", ".yml":
+		return true
+	}
+	return false
+}
+
+// loadDir loads every manifest in the directory and its subdirectories, support bundles tend to have
+// other JSON files in them, so files that cannot be parsed are reported and skipped
+func (o *offlineCluster) loadDir(dir string) error {
+	return filepath.Walk(dir, func(file string, info os.FileInfo, err error) error {
+		if err != nil || !info.Mode().IsRegular() || !isManifest(file) {
+			return err
+		}
+		if err := o.loadFile(file); err != nil {
+			fmt.Printf("+++ Error: skipping %q – %v\n", file, err)
+		}
+		return nil
+	})
+}
+
+// loadFile loads a file with one or more YAML documents or JSON objects, including lists and
+// output of `kubectl cluster-info dump`, which is a stream of JSON lists
+func (o *offlineCluster) loadFile(file string) error {
+	f, err := os.Open(file)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	decoder := yaml.NewYAMLOrJSONDecoder(f, 4096)
+	for {
+		doc := map[string]interface{}{}
+		if err := decoder.Decode(&doc); err == io.EOF {
+			return nil
+		} else if err != nil {
+			return fmt.Errorf("could not parse %q – %v", file, err)
+		}
+		if err := o.add(doc); err != nil {
+			return fmt.Errorf("could not load an object from %q – %v", file, err)
+		}
+	}
+}
+
+// add adds an object, or items of a list, to the tracker; items of typed lists (e.g. `PodList`)
+// don't have to have `kind` and `apiVersion` set
+func (o *offlineCluster) add(doc map[string]interface{}) error {
+	kind, _ := doc["kind"].(string)
+	apiVersion, _ := doc["apiVersion"].(string)
+	if kind == "" {
+		return nil
+	}
+
+	if items, ok := doc["items"].([]interface{}); ok && strings.HasSuffix(kind, "List") {
+		for _, item := range items {
+			object, ok := item.(map[string]interface{})
+			if !ok {
+				continue
+			}
+			if _, ok := object["kind"]; !ok && kind != "List" {
+				object["kind"] = strings.TrimSuffix(kind, "List")
+				object["apiVersion"] = apiVersion
+			}
+			if err := o.add(object); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+
+	if appsKinds[kind] && (strings.HasPrefix(apiVersion, "extensions/") || strings.HasPrefix(apiVersion, "apps/")) {
+		doc["apiVersion"] = "apps/v1"
+	}
+
+	data, err := json.Marshal(doc)
+	if err != nil {
+		return err
+	}
+	obj, _, err := scheme.Codecs.UniversalDeserializer().Decode(data, nil, nil)
+	if err != nil {
+		if runtime.IsNotRegisteredError(err) {
+			o.skipped[kind]++
+			return nil
+		}
+		return err
+	}
+
+	// the same object may appear in more than one file, the first copy is kept
+	if err := o.tracker.Add(obj); err != nil && !apierrors.IsAlreadyExists(err) {
+		return err
+	}
+	o.loaded[kind]++
+	return nil
+}
+
+func countsByKind(counts map[string]int) string {
+	kinds := []string{}
+	for kind, n := range counts {
+		kinds = append(kinds, fmt.Sprintf("%s=%d", kind, n))
+	}
+	sort.Strings(kinds)
+	return strings.Join(kinds, " ")
+}
+
+func (o *offlineCluster) String() string {
+	s := fmt.Sprintf("offline cluster loaded from %q: %s", o.source, countsByKind(o.loaded))
+	if len(o.skipped) > 0 {
+		s += fmt.Sprintf(" (skipped unknown kinds: %s)", countsByKind(o.skipped))
+	}
+	return s
+}
+
+// list filters lists returned by the tracker with field selectors
+func (o *offlineCluster) list(action testing.Action) (bool, runtime.Object, error) {
+	handled, obj, err := testing.ObjectReaction(o.tracker)(action)
+	if err != nil || obj == nil {
+		return handled, obj, err
+	}
+
+	listAction, ok := action.(testing.ListAction)
+	if !ok {
+		return handled, obj, err
+	}
+	selector := listAction.GetListRestrictions().Fields
+	if selector == nil || selector.Empty() {
+		return handled, obj, err
+	}
+
+	items, err := meta.ExtractList(obj)
+	if err != nil {
+		return true, nil, err
+	}
+	matching := []runtime.Object{}
+	for _, item := range items {
+		ok, err := matchFields(item, selector)
+		if err != nil {
+			return true, nil, err
+		}
+		if ok {
+			matching = append(matching, item)
+		}
+	}
+	if err := meta.SetList(obj, matching); err != nil {
+		return true, nil, err
+	}
+	return true, obj, nil
+}
+
+// matchFields evaluates a field selector against fields of the object, a field selector is a dotted
+// path to the field, except for those in `fieldAliases`
+func matchFields(obj runtime.Object, selector fields.Selector) (bool, error) {
+	data, err := json.Marshal(obj)
+	if err != nil {
+		return false, err
+	}
+	object := map[string]interface{}{}
+	if err := json.Unmarshal(data, &object); err != nil {
+		return false, err
+	}
+
+	set := fields.Set{}
+	for _, requirement := range selector.Requirements() {
+		p := requirement.Field
+		if alias, ok := fieldAliases[p]; ok {
+			p = alias
+		}
+
+		var value interface{} = object
+		for _, key := range strings.Split(p, ".") {
+			m, ok := value.(map[string]interface{})
+			if !ok {
+				value = nil
+				break
+			}
+			value = m[key]
+		}
+		if value != nil {
+			set[requirement.Field] = fmt.Sprintf("%v", value)
+		} else {
+			set[requirement.Field] = ""
+		}
+	}
+	return selector.Matches(set), nil
+}
+
+// evict handles evictions, which are made as create requests for `eviction` subresource of a pod,
+// by deleting the pod; the fake clientset doesn't set namespace of the action, so it's taken from
+// the eviction
+func (o *offlineCluster) evict(action testing.Action) (bool, runtime.Object, error) {
+	if action.GetSubresource() != "eviction" {
+		return false, nil, nil
+	}
+	createAction, ok := action.(testing.CreateAction)
+	if !ok {
+		return false, nil, nil
+	}
+	objMeta, err := meta.Accessor(createAction.GetObject())
+	if err != nil {
+		return true, nil, err
+	}
+	return true, nil, o.tracker.Delete(action.GetResource(), objMeta.GetNamespace(), objMeta.GetName())
+}
+
+// logs reads logs from a `kubectl cluster-info dump` directory, which has `<namespace>/<pod>/logs.txt`,
+// or `<namespace>/<pod>/<container>/logs.txt` in newer versions of kubectl; logs of previous
+// containers are not dumped
+func (o *offlineCluster) logs(pod *corev1.Pod, options *corev1.PodLogOptions) (*bytes.Buffer, error) {
+	if o.dir == "" {
+		return nil, fmt.Errorf("logs are not available offline, unless loaded with -from-dir")
+	}
+	if options.Previous {
+		return nil, fmt.Errorf("logs of previous containers are not available offline")
+	}
+
+	podDir := filepath.Join(o.dir, pod.ObjectMeta.Namespace, pod.ObjectMeta.Name)
+	files := []string{filepath.Join(podDir, "logs.txt")}
+	if options.Container != "" {
+		files = append([]string{filepath.Join(podDir, options.Container, "logs.txt")}, files...)
+	}
+
+	for _, file := range files {
+		data, err := ioutil.ReadFile(file)
+		if os.IsNotExist(err) {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		return tailLines(data, options.TailLines), nil
+	}
+	return nil, fmt.Errorf("logs of pod %s are not in %q", objectName(pod.ObjectMeta), o.dir)
+}
+
+func tailLines(data []byte, n *int64) *bytes.Buffer {
+	if n == nil {
+		return bytes.NewBuffer(data)
+	}
+
+	lines := []string{}
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		lines = append(lines, scanner.Text())
+	}
+	if int64(len(lines)) > *n {
+		lines = lines[int64(len(lines))-*n:]
+	}
+
+	buffer := &bytes.Buffer{}
+	for _, line := range lines {
+		fmt.Fprintln(buffer, line)
+	}
+	return buffer
+}
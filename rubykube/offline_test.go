@@ -0,0 +1,153 @@
+package rubykube
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"testing"
+
+	policyv1beta1 "k8s.io/api/policy/v1beta1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+)
+
+const offlineManifest = `
+apiVersion: v1
+kind: Pod
+metadata:
+  name: web-a
+  namespace: prod
+spec:
+  nodeName: node-1
+  containers:
+  - name: web
+    image: nginx
+---
+apiVersion: v1
+kind: Pod
+metadata:
+  name: web-b
+  namespace: prod
+spec:
+  nodeName: node-2
+  containers:
+  - name: web
+    image: nginx
+---
+apiVersion: v1
+kind: Pod
+metadata:
+  name: web-a
+  namespace: dev
+spec:
+  nodeName: node-1
+  containers:
+  - name: web
+    image: nginx
+---
+apiVersion: extensions/v1beta1
+kind: Deployment
+metadata:
+  name: web
+  namespace: prod
+spec:
+  template:
+    spec:
+      containers:
+      - name: web
+        image: nginx
+`
+
+func newTestOfflineClientset(t *testing.T) (kubernetes.Interface, *offlineCluster) {
+	dir, err := ioutil.TempDir("", "kubeplay-offline")
+	if err != nil {
+		t.Fatal(err)
+	}
+	// objects are loaded when the clientset is made, so the file isn't needed afterwards
+	defer os.RemoveAll(dir)
+
+	file := filepath.Join(dir, "manifest.yaml")
+	if err := ioutil.WriteFile(file, []byte(offlineManifest), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	defer func(file string) { *fromFile = file }(*fromFile)
+	*fromFile = file
+
+	clientset, offline, err := newOfflineClientset()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	return clientset, offline
+}
+
+func podNames(t *testing.T, clientset kubernetes.Interface, ns string, options metav1.ListOptions) []string {
+	pods, err := clientset.Core().Pods(ns).List(options)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	names := []string{}
+	for _, p := range pods.Items {
+		names = append(names, p.ObjectMeta.Namespace+"/"+p.ObjectMeta.Name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+func TestOfflineLoad(t *testing.T) {
+	_, offline := newTestOfflineClientset(t)
+
+	if expected, actual := "Deployment=1 Pod=3", countsByKind(offline.loaded); actual != expected {
+		t.Errorf("expected %q to be loaded, got %q", expected, actual)
+	}
+}
+
+func TestOfflineList(t *testing.T) {
+	clientset, _ := newTestOfflineClientset(t)
+
+	tests := []struct {
+		name    string
+		ns      string
+		options metav1.ListOptions
+		pods    []string
+	}{
+		{"all namespaces", "", metav1.ListOptions{}, []string{"dev/web-a", "prod/web-a", "prod/web-b"}},
+		{"one namespace", "prod", metav1.ListOptions{}, []string{"prod/web-a", "prod/web-b"}},
+		{"field selector", "", metav1.ListOptions{FieldSelector: "spec.nodeName=node-1"}, []string{"dev/web-a", "prod/web-a"}},
+		{"negated field selector", "prod", metav1.ListOptions{FieldSelector: "spec.nodeName!=node-1"}, []string{"prod/web-b"}},
+		{"field selector of a missing field", "", metav1.ListOptions{FieldSelector: "spec.hostname=web"}, []string{}},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			names := podNames(t, clientset, test.ns, test.options)
+			if len(names) != len(test.pods) {
+				t.Fatalf("expected pods %v, got %v", test.pods, names)
+			}
+			for i := range names {
+				if names[i] != test.pods[i] {
+					t.Fatalf("expected pods %v, got %v", test.pods, names)
+				}
+			}
+		})
+	}
+}
+
+func TestOfflineEvict(t *testing.T) {
+	clientset, _ := newTestOfflineClientset(t)
+
+	eviction := &policyv1beta1.Eviction{ObjectMeta: metav1.ObjectMeta{Name: "web-a", Namespace: "prod"}}
+	if err := clientset.Core().Pods("prod").Evict(eviction); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	names := podNames(t, clientset, "", metav1.ListOptions{})
+	if expected := []string{"dev/web-a", "prod/web-b"}; len(names) != len(expected) || names[0] != expected[0] || names[1] != expected[1] {
+		t.Errorf("expected pods %v after eviction, got %v", expected, names)
+	}
+
+	if err := clientset.Core().Pods("prod").Evict(eviction); err == nil {
+		t.Error("expected an error when evicting a pod that doesn't exist")
+	}
+}
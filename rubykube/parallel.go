@@ -0,0 +1,99 @@
+package rubykube
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"os/signal"
+	"sync"
+
+	mruby "github.com/mitchellh/go-mruby"
+)
+
+var parallelismFlag = flag.Int("parallelism", 10, "maximum number of concurrent API requests made by verbs that fan out, e.g. pods.logs")
+
+// interruptedError maps to `RubyKube::Interrupted`
+type interruptedError struct{ error }
+
+func newInterruptedError() error {
+	return &interruptedError{fmt.Errorf("interrupted")}
+}
+
+// parallel calls fn for each of n items on a pool of at most `parallelism` goroutines, and returns
+// the first error; fn must not use mruby, as the VM is only safe to use from the goroutine that owns
+// it, so results should be stored by index and converted to Ruby values once parallel returns.
+// On ^C it returns right away, without waiting for requests that are in flight, so results must be
+// discarded if it returns an error
+func (rk *RubyKube) parallel(n int, fn func(i int) error) error {
+	if n == 0 {
+		return nil
+	}
+
+	workers := rk.parallelism
+	if workers < 1 {
+		workers = 1
+	}
+	if workers > n {
+		workers = n
+	}
+
+	interrupt := make(chan os.Signal, 1)
+	signal.Notify(interrupt, os.Interrupt)
+	defer signal.Stop(interrupt)
+
+	items := make(chan int)
+	// errs is buffered, so that workers never block once we stop reading from it
+	errs := make(chan error, n)
+	stop := make(chan struct{})
+
+	wg := sync.WaitGroup{}
+	for w := 0; w < workers; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range items {
+				errs <- fn(i)
+			}
+		}()
+	}
+
+	go func() {
+		defer close(items)
+		for i := 0; i < n; i++ {
+			select {
+			case items <- i:
+			case <-stop:
+				return
+			}
+		}
+	}()
+
+	for done := 0; done < n; done++ {
+		select {
+		case err := <-errs:
+			if err != nil {
+				close(stop)
+				wg.Wait()
+				return err
+			}
+		case <-interrupt:
+			close(stop)
+			return newInterruptedError()
+		}
+	}
+	return nil
+}
+
+// parallelismVerb implements `parallelism`, which returns current limit, and `parallelism 20`,
+// which sets it
+func parallelismVerb(rk *RubyKube, args []*mruby.MrbValue, m *mruby.Mrb, self *mruby.MrbValue) (mruby.Value, mruby.Value) {
+	if len(args) == 0 {
+		return m.FixnumValue(rk.parallelism), nil
+	}
+
+	if args[0].Type() != mruby.TypeFixnum || args[0].Fixnum() < 1 {
+		return nil, createException(m, "Argument must be a positive integer")
+	}
+	rk.parallelism = args[0].Fixnum()
+	return m.FixnumValue(rk.parallelism), nil
+}
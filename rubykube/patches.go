@@ -31,8 +31,10 @@ var patches = []string{
 	//`,
 }
 
+// applyPatches also defines `RubyKube::Error` hierarchy, the testing framework and built-in security rules,
+// which are written in Ruby
 func (rk *RubyKube) applyPatches() error {
-	for _, p := range patches {
+	for _, p := range append([]string{errorClasses, testingFramework, securityRules}, patches...) {
 		if _, err := rk.mrb.LoadString(p); err != nil {
 			return err
 		}
@@ -0,0 +1,75 @@
+package rubykube
+
+import (
+	"fmt"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/types"
+)
+
+// podSelectorOf returns metadata and the pod selector of a deployment, replica set, daemon set or service,
+// and whether pods it selects must also be controlled by it (services don't own pods)
+func podSelectorOf(obj interface{}) (*metav1.ObjectMeta, labels.Selector, bool, error) {
+	switch o := obj.(type) {
+	case deploymentTypeAlias:
+		selector, err := metav1.LabelSelectorAsSelector(o.Spec.Selector)
+		return &o.ObjectMeta, selector, true, err
+	case replicaSetTypeAlias:
+		selector, err := metav1.LabelSelectorAsSelector(o.Spec.Selector)
+		return &o.ObjectMeta, selector, true, err
+	case daemonSetTypeAlias:
+		selector, err := metav1.LabelSelectorAsSelector(o.Spec.Selector)
+		return &o.ObjectMeta, selector, true, err
+	case serviceTypeAlias:
+		// a service without a selector has its endpoints managed by hand
+		if len(o.Spec.Selector) == 0 {
+			return &o.ObjectMeta, labels.Nothing(), false, nil
+		}
+		return &o.ObjectMeta, labels.SelectorFromSet(o.Spec.Selector), false, nil
+	default:
+		return nil, nil, false, fmt.Errorf("cannot find pods of %T", obj)
+	}
+}
+
+// findPods lists pods that match the selector of the object and, unless it's a service, are controlled by it;
+// pods of a deployment are controlled by its replica sets
+func (rk *RubyKube) findPods(obj interface{}) (*corev1.PodList, error) {
+	meta, selector, owned, err := podSelectorOf(obj)
+	if err != nil {
+		return nil, err
+	}
+	if _, ok := selector.Requirements(); !ok {
+		return &corev1.PodList{Items: []corev1.Pod{}}, nil
+	}
+	listOptions := metav1.ListOptions{LabelSelector: selector.String()}
+
+	pods, err := rk.clientset.Core().Pods(meta.Namespace).List(listOptions)
+	if err != nil || !owned {
+		return pods, err
+	}
+
+	controllers := map[types.UID]bool{meta.UID: true}
+	if _, ok := obj.(deploymentTypeAlias); ok {
+		replicaSets, err := rk.clientset.Apps().ReplicaSets(meta.Namespace).List(listOptions)
+		if err != nil {
+			return nil, err
+		}
+		controllers = map[types.UID]bool{}
+		for i := range replicaSets.Items {
+			if ref := metav1.GetControllerOf(&replicaSets.Items[i]); ref != nil && ref.UID == meta.UID {
+				controllers[replicaSets.Items[i].UID] = true
+			}
+		}
+	}
+
+	controlled := []corev1.Pod{}
+	for i := range pods.Items {
+		if ref := metav1.GetControllerOf(&pods.Items[i]); ref != nil && controllers[ref.UID] {
+			controlled = append(controlled, pods.Items[i])
+		}
+	}
+	pods.Items = controlled
+	return pods, nil
+}
@@ -0,0 +1,96 @@
+package rubykube
+
+import (
+	"sort"
+	"testing"
+
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+func controlledBy(kind, name string, uid types.UID) []metav1.OwnerReference {
+	controller := true
+	return []metav1.OwnerReference{{Kind: kind, Name: name, UID: uid, Controller: &controller}}
+}
+
+func TestFindPods(t *testing.T) {
+	selector := &metav1.LabelSelector{MatchLabels: map[string]string{"app": "web"}}
+
+	deployment := appsv1.Deployment{
+		ObjectMeta: metav1.ObjectMeta{Name: "web", Namespace: "prod", UID: "deployment-web", Labels: map[string]string{"team": "a"}},
+		Spec:       appsv1.DeploymentSpec{Selector: selector},
+	}
+	replicaSet := appsv1.ReplicaSet{
+		ObjectMeta: metav1.ObjectMeta{Name: "web-1", Namespace: "prod", UID: "replicaset-web-1",
+			Labels: map[string]string{"app": "web"}, OwnerReferences: controlledBy("Deployment", "web", "deployment-web")},
+		Spec: appsv1.ReplicaSetSpec{Selector: selector},
+	}
+	otherReplicaSet := appsv1.ReplicaSet{
+		ObjectMeta: metav1.ObjectMeta{Name: "web-canary", Namespace: "prod", UID: "replicaset-web-canary",
+			Labels: map[string]string{"app": "web"}},
+		Spec: appsv1.ReplicaSetSpec{Selector: selector},
+	}
+	daemonSet := appsv1.DaemonSet{
+		ObjectMeta: metav1.ObjectMeta{Name: "agent", Namespace: "prod", UID: "daemonset-agent"},
+		Spec:       appsv1.DaemonSetSpec{Selector: &metav1.LabelSelector{MatchLabels: map[string]string{"app": "agent"}}},
+	}
+	pod := func(name string, labels map[string]string, owners []metav1.OwnerReference) runtime.Object {
+		return &corev1.Pod{ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: "prod", Labels: labels, OwnerReferences: owners}}
+	}
+
+	clientset := fake.NewSimpleClientset(
+		&replicaSet, &otherReplicaSet,
+		pod("web-1-a", map[string]string{"app": "web", "team": "a"}, controlledBy("ReplicaSet", "web-1", "replicaset-web-1")),
+		pod("web-1-b", map[string]string{"app": "web"}, controlledBy("ReplicaSet", "web-1", "replicaset-web-1")),
+		pod("web-canary-a", map[string]string{"app": "web"}, controlledBy("ReplicaSet", "web-canary", "replicaset-web-canary")),
+		pod("web-debug", map[string]string{"app": "web", "team": "a"}, nil),
+		pod("agent-a", map[string]string{"app": "agent"}, controlledBy("DaemonSet", "agent", "daemonset-agent")),
+		pod("other", map[string]string{"team": "a"}, nil),
+	)
+	rk := &RubyKube{clientset: clientset}
+
+	tests := []struct {
+		name string
+		obj  interface{}
+		pods []string
+	}{
+		{"deployment finds pods of its replica sets", deploymentTypeAlias(deployment), []string{"web-1-a", "web-1-b"}},
+		{"replica set finds pods it controls", replicaSetTypeAlias(replicaSet), []string{"web-1-a", "web-1-b"}},
+		{"replica set without pods", replicaSetTypeAlias(appsv1.ReplicaSet{ObjectMeta: metav1.ObjectMeta{Name: "web-2", Namespace: "prod", UID: "replicaset-web-2"},
+			Spec: appsv1.ReplicaSetSpec{Selector: selector}}), []string{}},
+		{"daemon set finds pods it controls", daemonSetTypeAlias(daemonSet), []string{"agent-a"}},
+		{"service finds all selected pods", serviceTypeAlias(corev1.Service{ObjectMeta: metav1.ObjectMeta{Name: "web", Namespace: "prod"},
+			Spec: corev1.ServiceSpec{Selector: map[string]string{"app": "web"}}}), []string{"web-1-a", "web-1-b", "web-canary-a", "web-debug"}},
+		{"service without selector finds no pods", serviceTypeAlias(corev1.Service{ObjectMeta: metav1.ObjectMeta{Name: "external", Namespace: "prod"}}), []string{}},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			pods, err := rk.findPods(test.obj)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			names := []string{}
+			for _, p := range pods.Items {
+				names = append(names, p.Name)
+			}
+			sort.Strings(names)
+			if len(names) != len(test.pods) {
+				t.Fatalf("expected pods %v, got %v", test.pods, names)
+			}
+			for i := range names {
+				if names[i] != test.pods[i] {
+					t.Fatalf("expected pods %v, got %v", test.pods, names)
+				}
+			}
+		})
+	}
+
+	if _, err := rk.findPods(corev1.Pod{}); err == nil {
+		t.Error("expected an error for a pod")
+	}
+}
@@ -0,0 +1,96 @@
+package rubykube
+
+import (
+	"fmt"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/client-go/tools/portforward"
+	"k8s.io/client-go/transport/spdy"
+)
+
+// httpGetTimeout applies to requests made through a port-forward
+const httpGetTimeout = 10 * time.Second
+
+// freePort asks the kernel for an unused local port, as the port-forwarder doesn't tell
+// which one it picked
+func freePort() (int, error) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		return 0, err
+	}
+	defer listener.Close()
+	return listener.Addr().(*net.TCPAddr).Port, nil
+}
+
+// defaultPort returns the first port declared by containers of a pod, or 80
+func defaultPort(pod *corev1.Pod) int {
+	for _, container := range pod.Spec.Containers {
+		for _, port := range container.Ports {
+			return int(port.ContainerPort)
+		}
+	}
+	return 80
+}
+
+// withPortForward forwards a local port to given port of a pod for as long as fn runs
+func (rk *RubyKube) withPortForward(pod *corev1.Pod, port int, fn func(localPort int) error) error {
+	if rk.offline != nil {
+		return fmt.Errorf("cannot forward a port to pod %s – there is no cluster in offline mode", objectName(pod.ObjectMeta))
+	}
+
+	transport, upgrader, err := spdy.RoundTripperFor(rk.config)
+	if err != nil {
+		return err
+	}
+
+	req := rk.clientset.Core().RESTClient().Post().
+		Resource("pods").
+		Namespace(pod.ObjectMeta.Namespace).
+		Name(pod.ObjectMeta.Name).
+		SubResource("portforward")
+
+	dialer := spdy.NewDialer(upgrader, &http.Client{Transport: transport}, "POST", req.URL())
+
+	localPort, err := freePort()
+	if err != nil {
+		return err
+	}
+
+	stopChan, readyChan := make(chan struct{}), make(chan struct{})
+	forwarder, err := portforward.New(dialer, []string{fmt.Sprintf("%d:%d", localPort, port)}, stopChan, readyChan, ioutil.Discard, ioutil.Discard)
+	if err != nil {
+		return err
+	}
+
+	errChan := make(chan error, 1)
+	go func() { errChan <- forwarder.ForwardPorts() }()
+
+	select {
+	case <-readyChan:
+	case err := <-errChan:
+		return fmt.Errorf("port-forward to %s/%s:%d failed – %v", pod.ObjectMeta.Namespace, pod.ObjectMeta.Name, port, err)
+	}
+	defer close(stopChan)
+
+	return fn(localPort)
+}
+
+// httpStatus makes a GET request to a pod through a port-forward and returns the status code
+func (rk *RubyKube) httpStatus(pod *corev1.Pod, port int, path string) (int, error) {
+	status := 0
+	err := rk.withPortForward(pod, port, func(localPort int) error {
+		client := &http.Client{Timeout: httpGetTimeout}
+		resp, err := client.Get(fmt.Sprintf("http://127.0.0.1:%d%s", localPort, path))
+		if err != nil {
+			return err
+		}
+		defer resp.Body.Close()
+		status = resp.StatusCode
+		return nil
+	})
+	return status, err
+}
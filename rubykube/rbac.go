@@ -0,0 +1,375 @@
+package rubykube
+
+import (
+	"sort"
+	"strings"
+
+	mruby "github.com/mitchellh/go-mruby"
+	authorizationv1 "k8s.io/api/authorization/v1"
+	rbacv1 "k8s.io/api/rbac/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/rest"
+)
+
+// resourceGroups are API groups of common resources, any other resource is assumed to be
+// in the core group, unless `group:` is given
+var resourceGroups = map[string]string{
+	"deployments":          "apps",
+	"replicasets":          "apps",
+	"daemonsets":           "apps",
+	"statefulsets":         "apps",
+	"jobs":                 "batch",
+	"cronjobs":             "batch",
+	"poddisruptionbudgets": "policy",
+	"networkpolicies":      "networking.k8s.io",
+	"ingresses":            "extensions",
+	"roles":                "rbac.authorization.k8s.io",
+	"rolebindings":         "rbac.authorization.k8s.io",
+	"clusterroles":         "rbac.authorization.k8s.io",
+	"clusterrolebindings":  "rbac.authorization.k8s.io",
+}
+
+// accessQuery holds arguments of `can_i?` and `who_can`, an empty namespace stands for all namespaces
+type accessQuery struct {
+	verb        string
+	resource    string
+	subresource string
+	group       string
+	namespace   string
+	name        string
+}
+
+// parseAccessQuery parses `(:verb, :resource, namespace: "prod", name: "x", group: "apps")`, a resource
+// may have a subresource, e.g. `"pods/log"`; namespace defaults to current namespace, and to all
+// namespaces in all-namespaces mode
+func (rk *RubyKube) parseAccessQuery(args []*mruby.MrbValue) (*accessQuery, error) {
+	usage := "verb and resource must be given, e.g. `(:delete, :pods, namespace: \"prod\")`"
+	if len(args) < 2 || args[0].Type() == mruby.TypeHash || args[1].Type() == mruby.TypeHash {
+		return nil, newArgumentError(usage)
+	}
+
+	q := &accessQuery{verb: args[0].String(), resource: args[1].String()}
+	if parts := strings.SplitN(q.resource, "/", 2); len(parts) == 2 {
+		q.resource, q.subresource = parts[0], parts[1]
+	}
+	if q.verb == "" || q.resource == "" {
+		return nil, newArgumentError(usage)
+	}
+
+	if rk.state.Namespace != "*" {
+		q.namespace = rk.state.Namespace
+	}
+
+	hasGroup := false
+	for _, arg := range args[2:] {
+		if arg.Type() != mruby.TypeHash {
+			return nil, newArgumentError(usage)
+		}
+		if err := iterateHash(arg, func(key, value *mruby.MrbValue) error {
+			switch k := key.String(); k {
+			case "namespace":
+				if q.namespace = value.String(); q.namespace == "*" {
+					q.namespace = ""
+				}
+			case "name":
+				q.name = value.String()
+			case "subresource":
+				q.subresource = value.String()
+			case "group":
+				q.group, hasGroup = value.String(), true
+			default:
+				return newArgumentError("unknown parameter %q – not one of [namespace name subresource group]", k)
+			}
+			return nil
+		}); err != nil {
+			return nil, err
+		}
+	}
+
+	if !hasGroup {
+		q.group = resourceGroups[q.resource]
+	}
+	return q, nil
+}
+
+func contains(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
+
+// allows checks whether a policy rule grants access, following the same matching rules
+// as the RBAC authorizer, including `*` and `*/<subresource>` wildcards
+func (q *accessQuery) allows(rule rbacv1.PolicyRule) bool {
+	if !contains(rule.Verbs, rbacv1.VerbAll) && !contains(rule.Verbs, q.verb) {
+		return false
+	}
+	if !contains(rule.APIGroups, rbacv1.APIGroupAll) && !contains(rule.APIGroups, q.group) {
+		return false
+	}
+
+	resource := q.resource
+	if q.subresource != "" {
+		resource += "/" + q.subresource
+	}
+	if !contains(rule.Resources, rbacv1.ResourceAll) && !contains(rule.Resources, resource) &&
+		!(q.subresource != "" && contains(rule.Resources, "*/"+q.subresource)) {
+		return false
+	}
+
+	if len(rule.ResourceNames) > 0 && !contains(rule.ResourceNames, q.name) {
+		return false
+	}
+	return true
+}
+
+func (q *accessQuery) allowedBy(rules []rbacv1.PolicyRule) bool {
+	for _, rule := range rules {
+		if q.allows(rule) {
+			return true
+		}
+	}
+	return false
+}
+
+// accessSubject is a user, group or service account that has access, along with the binding
+// that grants it, e.g. `RoleBinding/prod/deployers` and the role it refers to
+type accessSubject struct {
+	Kind      string `json:"kind"`
+	Name      string `json:"name"`
+	Namespace string `json:"namespace,omitempty"`
+	Role      string `json:"role"`
+	Binding   string `json:"binding"`
+}
+
+func bindingSubjects(subjects []rbacv1.Subject, role, binding string) []accessSubject {
+	result := []accessSubject{}
+	for _, s := range subjects {
+		result = append(result, accessSubject{
+			Kind:      s.Kind,
+			Name:      s.Name,
+			Namespace: s.Namespace,
+			Role:      role,
+			Binding:   binding,
+		})
+	}
+	return result
+}
+
+// whoCan finds subjects that are granted access by cluster role bindings, and by role bindings in the
+// namespace, or in any namespace if it's empty; aggregated cluster roles have their rules filled in by
+// the controller manager, so these need no special handling
+func (rk *RubyKube) whoCan(q *accessQuery) ([]accessSubject, error) {
+	var (
+		clusterRoles        *rbacv1.ClusterRoleList
+		clusterRoleBindings *rbacv1.ClusterRoleBindingList
+		roles               *rbacv1.RoleList
+		roleBindings        *rbacv1.RoleBindingList
+	)
+
+	listers := []func() error{
+		func() (err error) {
+			clusterRoles, err = rk.clientset.Rbac().ClusterRoles().List(metav1.ListOptions{})
+			return err
+		},
+		func() (err error) {
+			clusterRoleBindings, err = rk.clientset.Rbac().ClusterRoleBindings().List(metav1.ListOptions{})
+			return err
+		},
+		// empty namespace of the query is the same as `metav1.NamespaceAll`
+		func() (err error) {
+			roles, err = rk.clientset.Rbac().Roles(q.namespace).List(metav1.ListOptions{})
+			return err
+		},
+		func() (err error) {
+			roleBindings, err = rk.clientset.Rbac().RoleBindings(q.namespace).List(metav1.ListOptions{})
+			return err
+		},
+	}
+	if err := rk.parallel(len(listers), func(i int) error { return listers[i]() }); err != nil {
+		return nil, err
+	}
+
+	clusterRoleRules := map[string][]rbacv1.PolicyRule{}
+	for _, role := range clusterRoles.Items {
+		clusterRoleRules[role.ObjectMeta.Name] = role.Rules
+	}
+	// roles are keyed by namespace and name, as a role binding can only refer to a role in its own namespace
+	roleRules := map[string][]rbacv1.PolicyRule{}
+	for _, role := range roles.Items {
+		roleRules[role.ObjectMeta.Namespace+"/"+role.ObjectMeta.Name] = role.Rules
+	}
+
+	subjects := []accessSubject{}
+	for _, binding := range clusterRoleBindings.Items {
+		if q.allowedBy(clusterRoleRules[binding.RoleRef.Name]) {
+			subjects = append(subjects, bindingSubjects(binding.Subjects,
+				"ClusterRole/"+binding.RoleRef.Name,
+				"ClusterRoleBinding/"+binding.ObjectMeta.Name)...)
+		}
+	}
+	for _, binding := range roleBindings.Items {
+		ns := binding.ObjectMeta.Namespace
+		rules, role := roleRules[ns+"/"+binding.RoleRef.Name], "Role/"+ns+"/"+binding.RoleRef.Name
+		if binding.RoleRef.Kind == "ClusterRole" {
+			rules, role = clusterRoleRules[binding.RoleRef.Name], "ClusterRole/"+binding.RoleRef.Name
+		}
+		if q.allowedBy(rules) {
+			subjects = append(subjects, bindingSubjects(binding.Subjects, role,
+				"RoleBinding/"+ns+"/"+binding.ObjectMeta.Name)...)
+		}
+	}
+
+	sort.SliceStable(subjects, func(i, j int) bool {
+		a, b := subjects[i], subjects[j]
+		if a.Kind != b.Kind {
+			return a.Kind < b.Kind
+		}
+		if a.Namespace != b.Namespace {
+			return a.Namespace < b.Namespace
+		}
+		if a.Name != b.Name {
+			return a.Name < b.Name
+		}
+		return a.Binding < b.Binding
+	})
+	return subjects, nil
+}
+
+// canI implements `can_i?` verb, e.g. `can_i?(:delete, :pods, namespace: "prod")`, which asks the API
+// server with a `SelfSubjectAccessReview`; within `as` it checks access of the impersonated user
+func canI(rk *RubyKube, args []*mruby.MrbValue, m *mruby.Mrb, self *mruby.MrbValue) (mruby.Value, mruby.Value) {
+	if rk.offline != nil {
+		return nil, createException(m, "can_i? needs a cluster to ask, use who_can in offline mode")
+	}
+
+	q, err := rk.parseAccessQuery(args)
+	if err != nil {
+		return nil, createError(m, err)
+	}
+
+	review, err := rk.clientset.Authorization().SelfSubjectAccessReviews().Create(&authorizationv1.SelfSubjectAccessReview{
+		Spec: authorizationv1.SelfSubjectAccessReviewSpec{
+			ResourceAttributes: &authorizationv1.ResourceAttributes{
+				Namespace:   q.namespace,
+				Verb:        q.verb,
+				Group:       q.group,
+				Resource:    q.resource,
+				Subresource: q.subresource,
+				Name:        q.name,
+			},
+		},
+	})
+	if err != nil {
+		return nil, createError(m, err)
+	}
+
+	if review.Status.Allowed {
+		return m.TrueValue(), nil
+	}
+	return m.FalseValue(), nil
+}
+
+// whoCanVerb implements `who_can` verb, e.g. `who_can(:get, :secrets, namespace: "prod")`, which returns
+// an array of hashes with `kind`, `name`, `namespace` (of service accounts), `role` and `binding`
+func whoCanVerb(rk *RubyKube, args []*mruby.MrbValue, m *mruby.Mrb, self *mruby.MrbValue) (mruby.Value, mruby.Value) {
+	q, err := rk.parseAccessQuery(args)
+	if err != nil {
+		return nil, createError(m, err)
+	}
+
+	subjects, err := rk.whoCan(q)
+	if err != nil {
+		return nil, createError(m, err)
+	}
+
+	value, err := nativeRubyValueOf(m, subjects)
+	if err != nil {
+		return nil, createError(m, err)
+	}
+	return value, nil
+}
+
+// parseImpersonation parses `user:`, `groups:` and `extra:` arguments of `as`
+func parseImpersonation(args []*mruby.MrbValue) (rest.ImpersonationConfig, error) {
+	impersonate := rest.ImpersonationConfig{}
+	for _, arg := range args {
+		if arg.Type() != mruby.TypeHash {
+			continue
+		}
+		if err := iterateHash(arg, func(key, value *mruby.MrbValue) error {
+			switch k := key.String(); k {
+			case "user":
+				impersonate.UserName = value.String()
+			case "groups":
+				if value.Type() != mruby.TypeArray {
+					impersonate.Groups = []string{value.String()}
+					return nil
+				}
+				return iterateArray(value, func(_ int, group *mruby.MrbValue) error {
+					impersonate.Groups = append(impersonate.Groups, group.String())
+					return nil
+				})
+			case "extra":
+				if value.Type() != mruby.TypeHash {
+					return newArgumentError("extra must be a hash")
+				}
+				impersonate.Extra = map[string][]string{}
+				return iterateHash(value, func(key, value *mruby.MrbValue) error {
+					impersonate.Extra[key.String()] = append(impersonate.Extra[key.String()], value.String())
+					return nil
+				})
+			default:
+				return newArgumentError("unknown parameter %q – not one of [user groups extra]", k)
+			}
+			return nil
+		}); err != nil {
+			return impersonate, err
+		}
+	}
+
+	if impersonate.UserName == "" {
+		return impersonate, newArgumentError("user must be given, e.g. `as(user: \"alice\", groups: [\"dev\"]) { ... }`")
+	}
+	return impersonate, nil
+}
+
+// as implements `as(user: "alice", groups: ["dev"]) { ... }`, which runs the block with a clientset that
+// impersonates the user; the block's value is returned, and the clientset is restored even if it raises
+func as(rk *RubyKube, args []*mruby.MrbValue, m *mruby.Mrb, self *mruby.MrbValue) (mruby.Value, mruby.Value) {
+	block := blockArg(args)
+	if block == nil {
+		return nil, createException(m, "Usage: as(user: \"alice\", groups: [\"dev\"]) { ... }")
+	}
+	if rk.offline != nil {
+		return nil, createException(m, "Impersonation needs a cluster, it's not available in offline mode")
+	}
+
+	impersonate, err := parseImpersonation(args)
+	if err != nil {
+		return nil, createError(m, err)
+	}
+
+	config := rest.CopyConfig(rk.config)
+	config.Impersonate = impersonate
+	clientset, err := kubernetes.NewForConfig(config)
+	if err != nil {
+		return nil, createError(m, err)
+	}
+
+	previousClientset, previousAs := rk.clientset, rk.state.As
+	rk.clientset, rk.state.As = clientset, impersonate.UserName
+	defer func() {
+		rk.clientset, rk.state.As = previousClientset, previousAs
+	}()
+
+	value, err := block.Call("call")
+	if err != nil {
+		return nil, createError(m, err)
+	}
+	return value, nil
+}
@@ -0,0 +1,64 @@
+package rubykube
+
+import (
+	"reflect"
+	"testing"
+
+	rbacv1 "k8s.io/api/rbac/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+func TestWhoCan(t *testing.T) {
+	readSecrets := []rbacv1.PolicyRule{{Verbs: []string{"get", "list"}, APIGroups: []string{""}, Resources: []string{"secrets"}}}
+	readPods := []rbacv1.PolicyRule{{Verbs: []string{"get"}, APIGroups: []string{""}, Resources: []string{"pods"}}}
+
+	// both namespaces have a role called reader, but only the one in prod can read secrets
+	clientset := fake.NewSimpleClientset(
+		&rbacv1.ClusterRole{ObjectMeta: metav1.ObjectMeta{Name: "secret-reader"}, Rules: readSecrets},
+		&rbacv1.ClusterRoleBinding{ObjectMeta: metav1.ObjectMeta{Name: "auditors"},
+			RoleRef:  rbacv1.RoleRef{Kind: "ClusterRole", Name: "secret-reader"},
+			Subjects: []rbacv1.Subject{{Kind: "Group", Name: "auditors"}}},
+		&rbacv1.Role{ObjectMeta: metav1.ObjectMeta{Name: "reader", Namespace: "prod"}, Rules: readSecrets},
+		&rbacv1.Role{ObjectMeta: metav1.ObjectMeta{Name: "reader", Namespace: "dev"}, Rules: readPods},
+		&rbacv1.RoleBinding{ObjectMeta: metav1.ObjectMeta{Name: "readers", Namespace: "prod"},
+			RoleRef:  rbacv1.RoleRef{Kind: "Role", Name: "reader"},
+			Subjects: []rbacv1.Subject{{Kind: "User", Name: "alice"}}},
+		&rbacv1.RoleBinding{ObjectMeta: metav1.ObjectMeta{Name: "readers", Namespace: "dev"},
+			RoleRef:  rbacv1.RoleRef{Kind: "Role", Name: "reader"},
+			Subjects: []rbacv1.Subject{{Kind: "User", Name: "bob"}}},
+		&rbacv1.RoleBinding{ObjectMeta: metav1.ObjectMeta{Name: "ci", Namespace: "dev"},
+			RoleRef:  rbacv1.RoleRef{Kind: "ClusterRole", Name: "secret-reader"},
+			Subjects: []rbacv1.Subject{{Kind: "ServiceAccount", Name: "ci", Namespace: "dev"}}},
+	)
+	rk := &RubyKube{clientset: clientset, parallelism: 2}
+
+	auditors := accessSubject{Kind: "Group", Name: "auditors", Role: "ClusterRole/secret-reader", Binding: "ClusterRoleBinding/auditors"}
+	alice := accessSubject{Kind: "User", Name: "alice", Role: "Role/prod/reader", Binding: "RoleBinding/prod/readers"}
+	bob := accessSubject{Kind: "User", Name: "bob", Role: "Role/dev/reader", Binding: "RoleBinding/dev/readers"}
+	ci := accessSubject{Kind: "ServiceAccount", Name: "ci", Namespace: "dev", Role: "ClusterRole/secret-reader", Binding: "RoleBinding/dev/ci"}
+
+	tests := []struct {
+		name     string
+		query    accessQuery
+		subjects []accessSubject
+	}{
+		{"secrets in prod", accessQuery{verb: "get", resource: "secrets", namespace: "prod"}, []accessSubject{auditors, alice}},
+		{"secrets in dev", accessQuery{verb: "get", resource: "secrets", namespace: "dev"}, []accessSubject{auditors, ci}},
+		{"secrets in any namespace", accessQuery{verb: "get", resource: "secrets"}, []accessSubject{auditors, ci, alice}},
+		{"pods in any namespace", accessQuery{verb: "get", resource: "pods"}, []accessSubject{bob}},
+		{"nobody deletes secrets", accessQuery{verb: "delete", resource: "secrets"}, []accessSubject{}},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			subjects, err := rk.whoCan(&test.query)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if !reflect.DeepEqual(subjects, test.subjects) {
+				t.Errorf("expected %+v, got %+v", test.subjects, subjects)
+			}
+		})
+	}
+}
@@ -0,0 +1,41 @@
+package rubykube
+
+import (
+	"flag"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+)
+
+var noRC = flag.Bool("no-rc", false, "do not load ~/.kubeplay/plugins/*.rb and ~/.kubeplay/rc.rb on startup")
+
+// rcFiles returns plugins in lexical order followed by the rc file, so that the rc file
+// can use helpers defined by plugins
+func rcFiles() []string {
+	files, _ := filepath.Glob(filepath.Join(kubeplayDir, "plugins", "*.rb"))
+	return append(files, filepath.Join(kubeplayDir, "rc.rb"))
+}
+
+// loadRC evaluates user's plugins and rc file, a file that fails to load is reported
+// and skipped, so a broken plugin doesn't make kubeplay unusable
+func (rk *RubyKube) loadRC() {
+	if *noRC {
+		return
+	}
+
+	for _, file := range rcFiles() {
+		content, err := ioutil.ReadFile(file)
+		if os.IsNotExist(err) {
+			continue
+		}
+		if err != nil {
+			fmt.Printf("+++ Error: could not read %q – %v\n", file, err)
+			continue
+		}
+
+		if err := rk.RunFile(file, string(content)); err != nil {
+			fmt.Printf("+++ Error: could not load %q – %s\n", file, FormatError(err))
+		}
+	}
+}
@@ -1,8 +1,12 @@
 package rubykube
 
 import (
+	"fmt"
+
 	mruby "github.com/mitchellh/go-mruby"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/watch"
 	appsv1 "k8s.io/api/apps/v1"
 )
 
@@ -14,6 +18,10 @@ func (c *deploymentClass) getSingleton(ns, name string) (*appsv1.Deployment, err
 	return c.rk.clientset.Apps().Deployments(ns).Get(name, metav1.GetOptions{})
 }
 
+func (c *deploymentClass) watchSingleton(ns string, listOptions metav1.ListOptions) (watch.Interface, error) {
+	return c.rk.clientset.Apps().Deployments(ns).Watch(listOptions)
+}
+
 //go:generate gotemplate "./templates/resource/singleton" "deploymentSingletonModule(deploymentClass, \"deployment\", deployment, deploymentTypeAlias)"
 
 //go:generate gotemplate "./templates/resource/podfinder" "deploymentPodFinderModule(deploymentClass, \"deployment\", deployment, deploymentTypeAlias)"
@@ -23,11 +31,26 @@ func (c *deploymentClass) defineOwnMethods() {
 	c.definePodFinderMethods()
 
 	c.rk.appendMethods(c.class, map[string]methodDefintion{
+		"selector": {
+			mruby.ArgsNone(), func(m *mruby.Mrb, self *mruby.MrbValue) (mruby.Value, mruby.Value) {
+				vars, err := c.LookupVars(self)
+				if err != nil {
+					return nil, createError(m, err)
+				}
+
+				newLabelSelectorObj, err := c.rk.classes.LabelSelector.NewFromLabelSelector(vars.deployment.Spec.Selector)
+				if err != nil {
+					return nil, createError(m, err)
+				}
+				return newLabelSelectorObj.self, nil
+			},
+			instanceMethod,
+		},
 		"replicasets": {
 			mruby.ArgsNone(), func(m *mruby.Mrb, self *mruby.MrbValue) (mruby.Value, mruby.Value) {
 				vars, err := c.LookupVars(self)
 				if err != nil {
-					return nil, createException(m, err.Error())
+					return nil, createError(m, err)
 				}
 
 				ns := vars.deployment.ObjectMeta.Namespace
@@ -36,21 +59,122 @@ func (c *deploymentClass) defineOwnMethods() {
 
 				replicaSets, err := c.rk.clientset.Apps().ReplicaSets(ns).List(listOptions)
 				if err != nil {
-					return nil, createException(m, err.Error())
+					return nil, createError(m, err)
 				}
 
 				newReplicaSetsObj, err := c.rk.classes.ReplicaSets.New()
 				if err != nil {
-					return nil, createException(m, err.Error())
+					return nil, createError(m, err)
 				}
 				newReplicaSetsObj.vars.replicaSets = replicaSetListTypeAlias(*replicaSets)
 				return newReplicaSetsObj.self, nil
 			},
 			instanceMethod,
 		},
+		"diagnose": {
+			mruby.ArgsNone(), func(m *mruby.Mrb, self *mruby.MrbValue) (mruby.Value, mruby.Value) {
+				vars, err := c.LookupVars(self)
+				if err != nil {
+					return nil, createError(m, err)
+				}
+
+				ns := c.rk.GetDefaultNamespace(vars.deployment.ObjectMeta.Namespace)
+				deployment, err := c.getSingleton(ns, vars.deployment.ObjectMeta.Name)
+				if err != nil {
+					return nil, createError(m, err)
+				}
+				vars.deployment = deploymentTypeAlias(*deployment)
+
+				pods, err := c.findPods(vars.deployment)
+				if err != nil {
+					return nil, createError(m, err)
+				}
+
+				newDiagnosisObj, err := c.rk.classes.Diagnosis.New()
+				if err != nil {
+					return nil, createError(m, err)
+				}
+				if newDiagnosisObj.vars.diagnosis, err = c.rk.diagnoseDeployment(deployment, pods.Items); err != nil {
+					return nil, createError(m, err)
+				}
+				return newDiagnosisObj.self, nil
+			},
+			instanceMethod,
+		},
+		"ready?": {
+			mruby.ArgsNone(), func(m *mruby.Mrb, self *mruby.MrbValue) (mruby.Value, mruby.Value) {
+				vars, err := c.LookupVars(self)
+				if err != nil {
+					return nil, createError(m, err)
+				}
+
+				ns := c.rk.GetDefaultNamespace(vars.deployment.ObjectMeta.Namespace)
+				deployment, err := c.getSingleton(ns, vars.deployment.ObjectMeta.Name)
+				if err != nil {
+					return nil, createError(m, err)
+				}
+				vars.deployment = deploymentTypeAlias(*deployment)
+
+				if ok, _ := deploymentAvailable(deployment); ok {
+					return m.TrueValue(), nil
+				}
+				return m.FalseValue(), nil
+			},
+			instanceMethod,
+		},
+		"wait_available": {
+			mruby.ArgsOpt(1), func(m *mruby.Mrb, self *mruby.MrbValue) (mruby.Value, mruby.Value) {
+				vars, err := c.LookupVars(self)
+				if err != nil {
+					return nil, createError(m, err)
+				}
+
+				timeout, err := waitTimeout(m.GetArgs())
+				if err != nil {
+					return nil, createError(m, err)
+				}
+
+				obj, err := c.waitFor(vars.deployment.ObjectMeta, "to become available", timeout, deploymentAvailable)
+				if err != nil {
+					return nil, createError(m, err)
+				}
+				vars.deployment = deploymentTypeAlias(*obj.(*appsv1.Deployment))
+
+				return self, nil
+			},
+			instanceMethod,
+		},
 	})
 }
 
+// deploymentAvailable is the condition for `wait_available`, it's the same as what
+// `kubectl rollout status` checks – all replicas are updated and available, and no
+// old replicas are left
+func deploymentAvailable(obj runtime.Object) (bool, error) {
+	if obj == nil {
+		return false, fmt.Errorf("deployment has been deleted")
+	}
+
+	deployment := obj.(*appsv1.Deployment)
+	if deployment.Generation > deployment.Status.ObservedGeneration {
+		return false, nil
+	}
+
+	for _, condition := range deployment.Status.Conditions {
+		if condition.Type == appsv1.DeploymentProgressing && condition.Reason == "ProgressDeadlineExceeded" {
+			return false, fmt.Errorf("deployment %s/%s has exceeded its progress deadline", deployment.Namespace, deployment.Name)
+		}
+	}
+
+	replicas := int32(1)
+	if deployment.Spec.Replicas != nil {
+		replicas = *deployment.Spec.Replicas
+	}
+
+	status := deployment.Status
+	return status.UpdatedReplicas >= replicas && status.Replicas == status.UpdatedReplicas && status.AvailableReplicas >= status.UpdatedReplicas, nil
+}
+
 func (o *deploymentClassInstance) Update() (mruby.Value, error) {
 	return call(o.self, "get!")
 }
@@ -0,0 +1,30 @@
+package rubykube
+
+import (
+	mruby "github.com/mitchellh/go-mruby"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/watch"
+)
+
+type eventTypeAlias = corev1.Event
+
+//go:generate gotemplate "./templates/resource" "eventClass(\"Event\", event, eventTypeAlias)"
+
+func (c *eventClass) getSingleton(ns, name string) (*corev1.Event, error) {
+	return c.rk.clientset.Core().Events(ns).Get(name, metav1.GetOptions{})
+}
+
+func (c *eventClass) watchSingleton(ns string, listOptions metav1.ListOptions) (watch.Interface, error) {
+	return c.rk.clientset.Core().Events(ns).Watch(listOptions)
+}
+
+//go:generate gotemplate "./templates/resource/singleton" "eventSingletonModule(eventClass, \"Event\", event, eventTypeAlias)"
+
+func (c *eventClass) defineOwnMethods() {
+	c.defineSingletonMethods()
+}
+
+func (o *eventClassInstance) Update() (mruby.Value, error) {
+	return call(o.self, "get!")
+}
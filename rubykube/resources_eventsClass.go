@@ -0,0 +1,123 @@
+package rubykube
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	mruby "github.com/mitchellh/go-mruby"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+type eventListTypeAlias = corev1.EventList
+
+//go:generate gotemplate "./templates/resource" "eventsClass(\"Events\", events, eventListTypeAlias)"
+
+func (c *eventsClass) getList(ns string, listOptions metav1.ListOptions) (*corev1.EventList, error) {
+	return c.rk.clientset.Core().Events(ns).List(listOptions)
+}
+
+func (c *eventsClass) getItem(events eventListTypeAlias, index int) (*eventClassInstance, error) {
+	newEventObj, err := c.rk.classes.Event.New()
+	if err != nil {
+		return nil, err
+	}
+	event := events.Items[index]
+	newEventObj.vars.event = eventTypeAlias(event)
+	return newEventObj, nil
+}
+
+//go:generate gotemplate "./templates/resource/list" "eventsListModule(eventsClass, \"Events\", events, eventListTypeAlias)"
+
+func (c *eventsClass) defineOwnMethods() {
+	c.defineListMethods()
+
+	// events are printed as a timeline, rather than by name like other lists
+	c.rk.appendMethods(c.class, map[string]methodDefintion{
+		"to_s": {
+			mruby.ArgsNone(), func(m *mruby.Mrb, self *mruby.MrbValue) (mruby.Value, mruby.Value) {
+				vars, err := c.LookupVars(self)
+				if err != nil {
+					return nil, createError(m, err)
+				}
+
+				return m.StringValue(vars.String()), nil
+			},
+			instanceMethod,
+		},
+		"inspect": {
+			mruby.ArgsNone(), func(m *mruby.Mrb, self *mruby.MrbValue) (mruby.Value, mruby.Value) {
+				vars, err := c.LookupVars(self)
+				if err != nil {
+					return nil, createError(m, err)
+				}
+
+				return m.StringValue(fmt.Sprintf("#<Events count=%d>\n%s", len(vars.events.Items), strings.TrimSuffix(vars.String(), "\n"))), nil
+			},
+			instanceMethod,
+		},
+		"table": {
+			mruby.ArgsNone(), func(m *mruby.Mrb, self *mruby.MrbValue) (mruby.Value, mruby.Value) {
+				vars, err := c.LookupVars(self)
+				if err != nil {
+					return nil, createError(m, err)
+				}
+
+				return m.StringValue(vars.Table()), nil
+			},
+			instanceMethod,
+		},
+		"pager": {
+			mruby.ArgsNone(), func(m *mruby.Mrb, self *mruby.MrbValue) (mruby.Value, mruby.Value) {
+				vars, err := c.LookupVars(self)
+				if err != nil {
+					return nil, createError(m, err)
+				}
+
+				if err := c.rk.page(vars.Table()); err != nil {
+					return nil, createError(m, err)
+				}
+				return nil, nil
+			},
+			instanceMethod,
+		},
+		"grep": {
+			mruby.ArgsAny(), func(m *mruby.Mrb, self *mruby.MrbValue) (mruby.Value, mruby.Value) {
+				vars, err := c.LookupVars(self)
+				if err != nil {
+					return nil, createError(m, err)
+				}
+
+				args := m.GetArgs()
+
+				if len(args) == 0 {
+					return nil, createException(m, "At least one argument must be specified")
+				}
+
+				matchAgainst := []*regexp.Regexp{}
+				for _, arg := range args {
+					if arg.Type() != mruby.TypeString {
+						return nil, createException(m, "Arguments must be strings")
+					}
+					re, err := regexp.Compile(arg.String())
+					if err != nil {
+						return nil, createError(m, err)
+					}
+					matchAgainst = append(matchAgainst, re)
+				}
+
+				newEventsObj, err := c.grep(vars, matchAgainst)
+				if err != nil {
+					return nil, createError(m, err)
+				}
+				return newEventsObj.self, nil
+			},
+			instanceMethod,
+		},
+	})
+}
+
+func (o *eventsClassInstance) Update(args ...*mruby.MrbValue) (mruby.Value, error) {
+	return call(o.self, "get!", args...)
+}
@@ -0,0 +1,116 @@
+package rubykube
+
+import (
+	"fmt"
+
+	mruby "github.com/mitchellh/go-mruby"
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/watch"
+)
+
+type jobTypeAlias = batchv1.Job
+
+//go:generate gotemplate "./templates/resource" "jobClass(\"Job\", job, jobTypeAlias)"
+
+func (c *jobClass) getSingleton(ns, name string) (*batchv1.Job, error) {
+	return c.rk.clientset.Batch().Jobs(ns).Get(name, metav1.GetOptions{})
+}
+
+func (c *jobClass) watchSingleton(ns string, listOptions metav1.ListOptions) (watch.Interface, error) {
+	return c.rk.clientset.Batch().Jobs(ns).Watch(listOptions)
+}
+
+//go:generate gotemplate "./templates/resource/singleton" "jobSingletonModule(jobClass, \"Job\", job, jobTypeAlias)"
+
+func (c *jobClass) defineOwnMethods() {
+	c.defineSingletonMethods()
+
+	c.rk.appendMethods(c.class, map[string]methodDefintion{
+		"selector": {
+			mruby.ArgsNone(), func(m *mruby.Mrb, self *mruby.MrbValue) (mruby.Value, mruby.Value) {
+				vars, err := c.LookupVars(self)
+				if err != nil {
+					return nil, createError(m, err)
+				}
+
+				newLabelSelectorObj, err := c.rk.classes.LabelSelector.NewFromLabelSelector(vars.job.Spec.Selector)
+				if err != nil {
+					return nil, createError(m, err)
+				}
+				return newLabelSelectorObj.self, nil
+			},
+			instanceMethod,
+		},
+		"ready?": {
+			mruby.ArgsNone(), func(m *mruby.Mrb, self *mruby.MrbValue) (mruby.Value, mruby.Value) {
+				vars, err := c.LookupVars(self)
+				if err != nil {
+					return nil, createError(m, err)
+				}
+
+				ns := c.rk.GetDefaultNamespace(vars.job.ObjectMeta.Namespace)
+				job, err := c.getSingleton(ns, vars.job.ObjectMeta.Name)
+				if err != nil {
+					return nil, createError(m, err)
+				}
+				vars.job = jobTypeAlias(*job)
+
+				if ok, _ := jobComplete(job); ok {
+					return m.TrueValue(), nil
+				}
+				return m.FalseValue(), nil
+			},
+			instanceMethod,
+		},
+		"wait_complete": {
+			mruby.ArgsOpt(1), func(m *mruby.Mrb, self *mruby.MrbValue) (mruby.Value, mruby.Value) {
+				vars, err := c.LookupVars(self)
+				if err != nil {
+					return nil, createError(m, err)
+				}
+
+				timeout, err := waitTimeout(m.GetArgs())
+				if err != nil {
+					return nil, createError(m, err)
+				}
+
+				obj, err := c.waitFor(vars.job.ObjectMeta, "to complete", timeout, jobComplete)
+				if err != nil {
+					return nil, createError(m, err)
+				}
+				vars.job = jobTypeAlias(*obj.(*batchv1.Job))
+
+				return self, nil
+			},
+			instanceMethod,
+		},
+	})
+}
+
+// jobComplete is the condition for `wait_complete`, it fails early if the job has failed
+func jobComplete(obj runtime.Object) (bool, error) {
+	if obj == nil {
+		return false, fmt.Errorf("job has been deleted")
+	}
+
+	job := obj.(*batchv1.Job)
+	for _, condition := range job.Status.Conditions {
+		if condition.Status != corev1.ConditionTrue {
+			continue
+		}
+		switch condition.Type {
+		case batchv1.JobComplete:
+			return true, nil
+		case batchv1.JobFailed:
+			return false, fmt.Errorf("job %s/%s has failed – %s", job.Namespace, job.Name, condition.Message)
+		}
+	}
+	return false, nil
+}
+
+func (o *jobClassInstance) Update() (mruby.Value, error) {
+	return call(o.self, "get!")
+}
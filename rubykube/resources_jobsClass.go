@@ -0,0 +1,35 @@
+package rubykube
+
+import (
+	mruby "github.com/mitchellh/go-mruby"
+	batchv1 "k8s.io/api/batch/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+type jobListTypeAlias = batchv1.JobList
+
+//go:generate gotemplate "./templates/resource" "jobsClass(\"Jobs\", jobs, jobListTypeAlias)"
+
+func (c *jobsClass) getList(ns string, listOptions metav1.ListOptions) (*batchv1.JobList, error) {
+	return c.rk.clientset.Batch().Jobs(ns).List(listOptions)
+}
+
+func (c *jobsClass) getItem(jobs jobListTypeAlias, index int) (*jobClassInstance, error) {
+	newJobObj, err := c.rk.classes.Job.New()
+	if err != nil {
+		return nil, err
+	}
+	job := jobs.Items[index]
+	newJobObj.vars.job = jobTypeAlias(job)
+	return newJobObj, nil
+}
+
+//go:generate gotemplate "./templates/resource/list" "jobsListModule(jobsClass, \"Jobs\", jobs, jobListTypeAlias)"
+
+func (c *jobsClass) defineOwnMethods() {
+	c.defineListMethods()
+}
+
+func (o *jobsClassInstance) Update(args ...*mruby.MrbValue) (mruby.Value, error) {
+	return call(o.self, "get!", args...)
+}
@@ -1,8 +1,12 @@
 package rubykube
 
 import (
+	"fmt"
+
 	mruby "github.com/mitchellh/go-mruby"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/watch"
 	corev1 "k8s.io/api/core/v1"
 )
 
@@ -14,6 +18,10 @@ func (c *podClass) getSingleton(ns, name string) (*corev1.Pod, error) {
 	return c.rk.clientset.Core().Pods(ns).Get(name, metav1.GetOptions{})
 }
 
+func (c *podClass) watchSingleton(ns string, listOptions metav1.ListOptions) (watch.Interface, error) {
+	return c.rk.clientset.Core().Pods(ns).Watch(listOptions)
+}
+
 //go:generate gotemplate "./templates/resource/singleton" "podSingletonModule(podClass, \"Pod\", pod, podTypeAlias)"
 
 func (c *podClass) defineOwnMethods() {
@@ -23,47 +31,47 @@ func (c *podClass) defineOwnMethods() {
 			mruby.ArgsNone(), func(m *mruby.Mrb, self *mruby.MrbValue) (mruby.Value, mruby.Value) {
 				vars, err := c.LookupVars(self)
 				if err != nil {
-					return nil, createException(m, err.Error())
+					return nil, createError(m, err)
 				}
 
 				pod := corev1.Pod(vars.pod)
 				ns := c.rk.GetDefaultNamespace(pod.ObjectMeta.Namespace)
 
 				if _, err = c.rk.clientset.Core().Pods(ns).Create(&pod); err != nil {
-					return nil, createException(m, err.Error())
+					return nil, createError(m, err)
 				}
 
 				return self, nil
 			},
-			instanceMethod,
+			mutatingMethod,
 		},
 		"delete!": {
 			mruby.ArgsNone(), func(m *mruby.Mrb, self *mruby.MrbValue) (mruby.Value, mruby.Value) {
 				vars, err := c.LookupVars(self)
 				if err != nil {
-					return nil, createException(m, err.Error())
+					return nil, createError(m, err)
 				}
 
 				ns := c.rk.GetDefaultNamespace(vars.pod.ObjectMeta.Namespace)
 
 				if err = c.rk.clientset.Core().Pods(ns).Delete(vars.pod.ObjectMeta.Name, &metav1.DeleteOptions{}); err != nil {
-					return nil, createException(m, err.Error())
+					return nil, createError(m, err)
 				}
 
 				return self, nil
 			},
-			instanceMethod,
+			mutatingMethod,
 		},
 		"logs": {
 			mruby.ArgsNone(), func(m *mruby.Mrb, self *mruby.MrbValue) (mruby.Value, mruby.Value) {
 				vars, err := c.LookupVars(self)
 				if err != nil {
-					return nil, createException(m, err.Error())
+					return nil, createError(m, err)
 				}
 
 				newPodLogsObj, err := c.rk.classes.PodLogs.New()
 				if err != nil {
-					return nil, createException(m, err.Error())
+					return nil, createError(m, err)
 				}
 				pod := corev1.Pod(vars.pod)
 				newPodLogsObj.vars.pods = []corev1.Pod{pod}
@@ -71,9 +79,136 @@ func (c *podClass) defineOwnMethods() {
 			},
 			instanceMethod,
 		},
+		"diagnose": {
+			mruby.ArgsNone(), func(m *mruby.Mrb, self *mruby.MrbValue) (mruby.Value, mruby.Value) {
+				vars, err := c.LookupVars(self)
+				if err != nil {
+					return nil, createError(m, err)
+				}
+
+				ns := c.rk.GetDefaultNamespace(vars.pod.ObjectMeta.Namespace)
+				pod, err := c.getSingleton(ns, vars.pod.ObjectMeta.Name)
+				if err != nil {
+					return nil, createError(m, err)
+				}
+				vars.pod = podTypeAlias(*pod)
+
+				d, err := c.rk.diagnosePod(pod)
+				if err != nil {
+					return nil, createError(m, err)
+				}
+
+				newDiagnosisObj, err := c.rk.classes.Diagnosis.New()
+				if err != nil {
+					return nil, createError(m, err)
+				}
+				newDiagnosisObj.vars.diagnosis = &diagnosis{
+					Subject: "pod " + objectName(pod.ObjectMeta),
+					Pods:    []*podDiagnosis{d},
+				}
+				return newDiagnosisObj.self, nil
+			},
+			instanceMethod,
+		},
+		"ready?": {
+			mruby.ArgsNone(), func(m *mruby.Mrb, self *mruby.MrbValue) (mruby.Value, mruby.Value) {
+				vars, err := c.LookupVars(self)
+				if err != nil {
+					return nil, createError(m, err)
+				}
+
+				ns := c.rk.GetDefaultNamespace(vars.pod.ObjectMeta.Namespace)
+				pod, err := c.getSingleton(ns, vars.pod.ObjectMeta.Name)
+				if err != nil {
+					return nil, createError(m, err)
+				}
+				vars.pod = podTypeAlias(*pod)
+
+				if ok, _ := podReady(pod); ok {
+					return m.TrueValue(), nil
+				}
+				return m.FalseValue(), nil
+			},
+			instanceMethod,
+		},
+		"http_status": {
+			mruby.ArgsReq(1) | mruby.ArgsOpt(1), func(m *mruby.Mrb, self *mruby.MrbValue) (mruby.Value, mruby.Value) {
+				vars, err := c.LookupVars(self)
+				if err != nil {
+					return nil, createError(m, err)
+				}
+
+				args := m.GetArgs()
+				if len(args) == 0 || args[0].Type() != mruby.TypeString {
+					return nil, createException(m, "First argument must be a string")
+				}
+
+				pod := corev1.Pod(vars.pod)
+				port := defaultPort(&pod)
+				if len(args) == 2 {
+					if err := iterateHash(args[1], func(key, value *mruby.MrbValue) error {
+						if key.String() != "port" || value.Type() != mruby.TypeFixnum {
+							return newArgumentError("only `port: <number>` option is allowed")
+						}
+						port = value.Fixnum()
+						return nil
+					}); err != nil {
+						return nil, createError(m, err)
+					}
+				}
+
+				status, err := c.rk.httpStatus(&pod, port, args[0].String())
+				if err != nil {
+					return nil, createError(m, err)
+				}
+				return m.FixnumValue(status), nil
+			},
+			instanceMethod,
+		},
+		"wait_ready": {
+			mruby.ArgsOpt(1), func(m *mruby.Mrb, self *mruby.MrbValue) (mruby.Value, mruby.Value) {
+				vars, err := c.LookupVars(self)
+				if err != nil {
+					return nil, createError(m, err)
+				}
+
+				timeout, err := waitTimeout(m.GetArgs())
+				if err != nil {
+					return nil, createError(m, err)
+				}
+
+				obj, err := c.waitFor(vars.pod.ObjectMeta, "to become ready", timeout, podReady)
+				if err != nil {
+					return nil, createError(m, err)
+				}
+				vars.pod = podTypeAlias(*obj.(*corev1.Pod))
+
+				return self, nil
+			},
+			instanceMethod,
+		},
 	})
 }
 
+// podReady is the condition for `wait_ready`, it fails early if the pod has terminated
+func podReady(obj runtime.Object) (bool, error) {
+	if obj == nil {
+		return false, fmt.Errorf("pod has been deleted")
+	}
+
+	pod := obj.(*corev1.Pod)
+	if phase := pod.Status.Phase; phase == corev1.PodSucceeded || phase == corev1.PodFailed {
+		return false, fmt.Errorf("pod %s/%s has terminated with phase %q", pod.Namespace, pod.Name, phase)
+	}
+
+	for _, condition := range pod.Status.Conditions {
+		if condition.Type == corev1.PodReady {
+			return condition.Status == corev1.ConditionTrue, nil
+		}
+	}
+	return false, nil
+}
+
 func (o *podClassInstance) Update() (mruby.Value, error) {
 	return call(o.self, "get!")
 }
@@ -33,12 +33,12 @@ func (c *podsClass) defineOwnMethods() {
 			mruby.ArgsNone(), func(m *mruby.Mrb, self *mruby.MrbValue) (mruby.Value, mruby.Value) {
 				vars, err := c.LookupVars(self)
 				if err != nil {
-					return nil, createException(m, err.Error())
+					return nil, createError(m, err)
 				}
 
 				newPodLogsObj, err := c.rk.classes.PodLogs.New()
 				if err != nil {
-					return nil, createException(m, err.Error())
+					return nil, createError(m, err)
 				}
 				newPodLogsObj.vars.pods = vars.pods.Items
 				return callWithException(m, newPodLogsObj.self, "get!")
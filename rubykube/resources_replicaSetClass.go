@@ -3,6 +3,7 @@ package rubykube
 import (
 	mruby "github.com/mitchellh/go-mruby"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/watch"
 	appsv1 "k8s.io/api/apps/v1"
 )
 
@@ -14,6 +15,10 @@ func (c *replicaSetClass) getSingleton(ns, name string) (*appsv1.ReplicaSet, err
 	return c.rk.clientset.Apps().ReplicaSets(ns).Get(name, metav1.GetOptions{})
 }
 
+func (c *replicaSetClass) watchSingleton(ns string, listOptions metav1.ListOptions) (watch.Interface, error) {
+	return c.rk.clientset.Apps().ReplicaSets(ns).Watch(listOptions)
+}
+
 //go:generate gotemplate "./templates/resource/singleton" "replicaSetSingletonModule(replicaSetClass, \"replicaSet\", replicaSet, replicaSetTypeAlias)"
 
 //go:generate gotemplate "./templates/resource/podfinder" "replicaSetPodFinderModule(replicaSetClass, \"replicaSet\", replicaSet, replicaSetTypeAlias)"
@@ -21,6 +26,24 @@ func (c *replicaSetClass) getSingleton(ns, name string) (*appsv1.ReplicaSet, err
 func (c *replicaSetClass) defineOwnMethods() {
 	c.defineSingletonMethods()
 	c.definePodFinderMethods()
+
+	c.rk.appendMethods(c.class, map[string]methodDefintion{
+		"selector": {
+			mruby.ArgsNone(), func(m *mruby.Mrb, self *mruby.MrbValue) (mruby.Value, mruby.Value) {
+				vars, err := c.LookupVars(self)
+				if err != nil {
+					return nil, createError(m, err)
+				}
+
+				newLabelSelectorObj, err := c.rk.classes.LabelSelector.NewFromLabelSelector(vars.replicaSet.Spec.Selector)
+				if err != nil {
+					return nil, createError(m, err)
+				}
+				return newLabelSelectorObj.self, nil
+			},
+			instanceMethod,
+		},
+	})
 }
 
 func (o *replicaSetClassInstance) Update() (mruby.Value, error) {
@@ -3,7 +3,9 @@ package rubykube
 import (
 	mruby "github.com/mitchellh/go-mruby"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/watch"
 	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/labels"
 )
 
 type serviceTypeAlias = corev1.Service
@@ -14,6 +16,10 @@ func (c *serviceClass) getSingleton(ns, name string) (*corev1.Service, error) {
 	return c.rk.clientset.Core().Services(ns).Get(name, metav1.GetOptions{})
 }
 
+func (c *serviceClass) watchSingleton(ns string, listOptions metav1.ListOptions) (watch.Interface, error) {
+	return c.rk.clientset.Core().Services(ns).Watch(listOptions)
+}
+
 //go:generate gotemplate "./templates/resource/singleton" "serviceSingletonModule(serviceClass, \"Service\", service, serviceTypeAlias)"
 
 //go:generate gotemplate "./templates/resource/podfinder" "servicePodFinderModule(serviceClass, \"Service\", service, serviceTypeAlias)"
@@ -21,6 +27,30 @@ func (c *serviceClass) getSingleton(ns, name string) (*corev1.Service, error) {
 func (c *serviceClass) defineOwnMethods() {
 	c.defineSingletonMethods()
 	c.definePodFinderMethods()
+
+	c.rk.appendMethods(c.class, map[string]methodDefintion{
+		"selector": {
+			mruby.ArgsNone(), func(m *mruby.Mrb, self *mruby.MrbValue) (mruby.Value, mruby.Value) {
+				vars, err := c.LookupVars(self)
+				if err != nil {
+					return nil, createError(m, err)
+				}
+
+				// a service without a selector has its endpoints managed by hand, an empty selector
+				// would match all pods, so there is no selector to return
+				if len(vars.service.Spec.Selector) == 0 {
+					return m.NilValue(), nil
+				}
+
+				newLabelSelectorObj, err := c.rk.classes.LabelSelector.NewFromLabelSelector(metav1.SetAsLabelSelector(labels.Set(vars.service.Spec.Selector)))
+				if err != nil {
+					return nil, createError(m, err)
+				}
+				return newLabelSelectorObj.self, nil
+			},
+			instanceMethod,
+		},
+	})
 }
 
 func (o *serviceClassInstance) Update() (mruby.Value, error) {
@@ -4,14 +4,15 @@ import (
 	"flag"
 	"fmt"
 	"os"
-	"regexp"
+	"strings"
+	"sync"
 
 	"github.com/chzyer/readline"
 	//"github.com/erikh/box/signal"
 	mruby "github.com/mitchellh/go-mruby"
 
-	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/rest"
 	"k8s.io/client-go/tools/clientcmd"
 )
 
@@ -19,10 +20,33 @@ var kubeconfig = flag.String("kubeconfig", os.ExpandEnv("${HOME}/.kube/config"),
 
 type RubyKube struct {
 	mrb       *mruby.Mrb
-	clientset *kubernetes.Clientset
+	config    *rest.Config
+	clientset kubernetes.Interface
 	classes   Classes
 	readline  *readline.Instance
 	state     *CurrentState
+	history   *history
+	audit     *auditLog
+	offline   *offlineCluster
+	tests     *testRunner
+
+	// securityRules are checked by `audit_security`, in order of definition
+	securityRules []*securityRule
+
+	registries []*instanceRegistry
+
+	// parallelism limits concurrent API requests made by verbs that fan out
+	parallelism int
+	// settings holds per-context policies from kubeplay config file
+	settings *kubeplayConfig
+
+	classNames      map[*mruby.Class]string
+	instanceMethods map[string][]string
+	aliases         map[string]string
+
+	// evalLock is held while the REPL is using the interpreter, completion runs on
+	// readline's own goroutine and takes it too
+	evalLock sync.Mutex
 }
 
 type Classes struct {
@@ -43,8 +67,21 @@ type Classes struct {
 	DaemonSets *daemonSetsClass
 	DaemonSet  *daemonSetClass
 
+	Jobs *jobsClass
+	Job  *jobClass
+
+	Events *eventsClass
+	Event  *eventClass
+
 	PodLogs *podLogsClass
 
+	Diagnosis    *diagnosisClass
+	SnapshotDiff *snapshotDiffClass
+	NetpolMatrix *netpolMatrixClass
+
+	ImageInventory *imageInventoryClass
+	SecurityReport *securityReportClass
+
 	PodMaker *podMakerClass
 
 	LabelSelector  *labelSelectorClass
@@ -60,6 +97,9 @@ type CurrentState struct {
 	Namespace string
 	Cluster   string
 	Context   string
+	User      string
+	// As is the user impersonated within `as` block
+	As string
 }
 
 func keep(omitFuncs []string, name string) bool {
@@ -75,16 +115,64 @@ func keep(omitFuncs []string, name string) bool {
 func NewRubyKube(omitFuncs []string, rl *readline.Instance) (*RubyKube, error) {
 	flag.Parse()
 
-	config, err := clientcmd.BuildConfigFromFlags("", *kubeconfig)
-	if err != nil {
-		panic(fmt.Errorf("clientcmd.BuildConfigFromFlags: %v", err))
+	var (
+		config    *rest.Config
+		clientset kubernetes.Interface
+		offline   *offlineCluster
+		err       error
+	)
+
+	// in offline mode there is no kubeconfig, objects are loaded from files instead
+	state := &CurrentState{}
+	if offlineMode() {
+		if clientset, offline, err = newOfflineClientset(); err != nil {
+			return nil, err
+		}
+		fmt.Println(offline)
+		state.Context = "offline"
+		state.Cluster = offline.source
+	} else {
+		config, err = clientcmd.BuildConfigFromFlags("", *kubeconfig)
+		if err != nil {
+			panic(fmt.Errorf("clientcmd.BuildConfigFromFlags: %v", err))
+		}
+
+		fmt.Printf("kubeconfig=%+v\n", config)
+
+		if rawConfig, err := clientcmd.LoadFromFile(*kubeconfig); err == nil {
+			state.Context = rawConfig.CurrentContext
+			if context, ok := rawConfig.Contexts[rawConfig.CurrentContext]; ok {
+				state.Cluster = context.Cluster
+				state.User = context.AuthInfo
+			}
+		}
 	}
 
-	fmt.Printf("kubeconfig=%+v\n", config)
+	settings, err := loadConfig(*configFile)
+	if err != nil {
+		return nil, err
+	}
 
-	rk := &RubyKube{mrb: mruby.NewMrb(), readline: rl, state: &CurrentState{}}
+	rk := &RubyKube{
+		mrb:             mruby.NewMrb(),
+		config:          config,
+		clientset:       clientset,
+		offline:         offline,
+		readline:        rl,
+		state:           state,
+		tests:           &testRunner{},
+		parallelism:     *parallelismFlag,
+		settings:        settings,
+		classNames:      map[*mruby.Class]string{},
+		instanceMethods: map[string][]string{},
+		aliases:         map[string]string{},
+	}
 
+	// GC is enabled once classes have been defined and patches applied
 	rk.mrb.DisableGC()
+	if err := rk.initGCRoots(); err != nil {
+		return nil, err
+	}
 
 	for name, def := range verbJumpTable {
 		if keep(omitFuncs, name) {
@@ -105,9 +193,19 @@ func NewRubyKube(omitFuncs []string, rl *readline.Instance) (*RubyKube, error) {
 
 	//signal.SignalHandler(nil)
 
-	rk.clientset, err = kubernetes.NewForConfig(config)
-	if err != nil {
-		panic(fmt.Errorf("kubernetes.NewForConfig: %v", err))
+	if rk.offline == nil {
+		// every request goes through the audit log, so that no change is missed
+		if *auditLogFile != "" {
+			if rk.audit, err = newAuditLog(*auditLogFile, state); err != nil {
+				return nil, err
+			}
+			config.WrapTransport = rk.audit.wrap
+		}
+
+		rk.clientset, err = kubernetes.NewForConfig(config)
+		if err != nil {
+			panic(fmt.Errorf("kubernetes.NewForConfig: %v", err))
+		}
 	}
 
 	rk.classes = Classes{Root: rk.mrb.DefineClass("RubyKube", nil)}
@@ -142,9 +240,36 @@ func NewRubyKube(omitFuncs []string, rl *readline.Instance) (*RubyKube, error) {
 	rk.classes.DaemonSet = newDaemonSetClass(rk)
 	rk.classes.DaemonSet.defineOwnMethods()
 
+	rk.classes.Jobs = newJobsClass(rk)
+	rk.classes.Jobs.defineOwnMethods()
+
+	rk.classes.Job = newJobClass(rk)
+	rk.classes.Job.defineOwnMethods()
+
+	rk.classes.Events = newEventsClass(rk)
+	rk.classes.Events.defineOwnMethods()
+
+	rk.classes.Event = newEventClass(rk)
+	rk.classes.Event.defineOwnMethods()
+
 	rk.classes.PodLogs = newPodLogsClass(rk)
 	rk.classes.PodLogs.defineOwnMethods()
 
+	rk.classes.Diagnosis = newDiagnosisClass(rk)
+	rk.classes.Diagnosis.defineOwnMethods()
+
+	rk.classes.SnapshotDiff = newSnapshotDiffClass(rk)
+	rk.classes.SnapshotDiff.defineOwnMethods()
+
+	rk.classes.NetpolMatrix = newNetpolMatrixClass(rk)
+	rk.classes.NetpolMatrix.defineOwnMethods()
+
+	rk.classes.ImageInventory = newImageInventoryClass(rk)
+	rk.classes.ImageInventory.defineOwnMethods()
+
+	rk.classes.SecurityReport = newSecurityReportClass(rk)
+	rk.classes.SecurityReport.defineOwnMethods()
+
 	rk.classes.PodMaker = newPodMakerClass(rk)
 	rk.classes.PodMaker.defineOwnMethods()
 
@@ -170,6 +295,21 @@ func NewRubyKube(omitFuncs []string, rl *readline.Instance) (*RubyKube, error) {
 	if err := rk.applyPatches(); err != nil {
 		return nil, err
 	}
+	rk.mrb.EnableGC()
+
+	rk.loadRC()
+
+	// there is no readline instance in script mode, and history is only kept for the REPL
+	if rk.readline != nil {
+		cfg := rk.readline.Config.Clone()
+		cfg.AutoComplete = newCompleter(rk)
+		rk.readline.SetConfig(cfg)
+
+		if rk.history, err = newHistory(*historyFile, *recordFile, rk.state.Context); err != nil {
+			return nil, err
+		}
+		rk.seedReadline()
+	}
 
 	return rk, nil
 }
@@ -195,11 +335,9 @@ func (rk *RubyKube) Run(script string) (*mruby.MrbValue, error) {
 		return nil, err
 	}
 
-	if _, err := value.Call("inspect"); err != nil {
-		return value, fmt.Errorf("could not call `#inspect` [%q]", err)
-	}
-
 	getLastValue := func(m *mruby.Mrb, self *mruby.MrbValue) (mruby.Value, mruby.Value) { return value, nil }
+	// the closure above is the only reference to the value otherwise
+	rk.mrb.SetGlobalVariable(lastValueVariable, value)
 
 	if value.Type() != mruby.TypeNil {
 		rk.mrb.TopSelf().SingletonClass().DefineMethod("_", getLastValue, mruby.ArgsReq(0))
@@ -210,6 +348,48 @@ func (rk *RubyKube) Run(script string) (*mruby.MrbValue, error) {
 	return value, nil
 }
 
+// RunFile evaluates a script one top-level statement at a time, so that changes made to the cluster
+// are attributed to file:line of the statement in the audit log; the whole script is parsed first,
+// so that a syntax error doesn't leave it half evaluated.
+func (rk *RubyKube) RunFile(path, script string) error {
+	parser := mruby.NewParser(rk.mrb)
+	defer parser.Close()
+	context := mruby.NewCompileContext(rk.mrb)
+	defer context.Close()
+	context.CaptureErrors(true)
+	context.SetFilename(path)
+
+	if _, err := parser.Parse(script, context); err != nil {
+		return err
+	}
+	defer rk.SetSource("", "")
+
+	var chunk string
+	start, stackKeep := 0, 0
+	for i, line := range strings.SplitAfter(script, "\n") {
+		if chunk == "" {
+			if strings.TrimSpace(line) == "" {
+				continue
+			}
+			start = i + 1
+		}
+		chunk += line
+
+		// same as in the REPL, a statement is complete once it parses
+		if _, err := parser.Parse(chunk, context); err != nil {
+			continue
+		}
+
+		rk.SetSource(fmt.Sprintf("%s:%d", path, start), chunk)
+		var err error
+		if _, stackKeep, err = rk.RunCode(parser.GenerateCode(), stackKeep); err != nil {
+			return err
+		}
+		chunk = ""
+	}
+	return nil
+}
+
 // RunCode runs the ruby value (a proc) and returns the result.
 func (rk *RubyKube) RunCode(block *mruby.MrbValue, stackKeep int) (*mruby.MrbValue, int, error) {
 	var value *mruby.MrbValue
@@ -219,11 +399,9 @@ func (rk *RubyKube) RunCode(block *mruby.MrbValue, stackKeep int) (*mruby.MrbVal
 		return nil, keep, err
 	}
 
-	if _, err := value.Call("inspect"); err != nil {
-		return value, keep, fmt.Errorf("could not call `#inspect` [%q]", err)
-	}
-
 	getLastValue := func(m *mruby.Mrb, self *mruby.MrbValue) (mruby.Value, mruby.Value) { return value, nil }
+	// the closure above is the only reference to the value otherwise
+	rk.mrb.SetGlobalVariable(lastValueVariable, value)
 
 	if value.Type() != mruby.TypeNil {
 		rk.mrb.TopSelf().SingletonClass().DefineMethod("_", getLastValue, mruby.ArgsReq(0))
@@ -232,15 +410,32 @@ func (rk *RubyKube) RunCode(block *mruby.MrbValue, stackKeep int) (*mruby.MrbVal
 	rk.mrb.TopSelf().SingletonClass().DefineMethod("$?", getLastValue, mruby.ArgsReq(0))
 
 	return value, keep, nil
+}
+
+// Inspect returns what the REPL shows for a value, that's the result of `#inspect`
+func (rk *RubyKube) Inspect(value *mruby.MrbValue) (string, error) {
+	inspected, err := value.Call("inspect")
+	if err != nil {
+		return "", err
+	}
+	if inspected.Type() != mruby.TypeString {
+		return "", fmt.Errorf("`#inspect` of %s returned a %s instead of a string", value.Class(), inspected.Class())
+	}
+	return inspected.String(), nil
+}
 
+func (rk *RubyKube) setPrompt(format string) {
+	if rk.readline != nil {
+		rk.readline.SetPrompt(fmt.Sprintf(format, rk.state.Namespace))
+	}
 }
 
 func (rk *RubyKube) NormalPrompt() {
-	rk.readline.SetPrompt(fmt.Sprintf("kubeplay (namespace=%q)> ", rk.state.Namespace))
+	rk.setPrompt("kubeplay (namespace=%q)> ")
 }
 
 func (rk *RubyKube) MultiLinePrompt() {
-	rk.readline.SetPrompt(fmt.Sprintf("kubeplay (namespace=%q)> ....| ", rk.state.Namespace))
+	rk.setPrompt("kubeplay (namespace=%q)> ....| ")
 }
 
 func (rk *RubyKube) SetNamespace(ns string) {
@@ -248,7 +443,7 @@ func (rk *RubyKube) SetNamespace(ns string) {
 		ns = "*"
 	}
 	rk.state.Namespace = ns
-	rk.readline.SetPrompt(fmt.Sprintf("kubeplay (namespace=%q)> ", rk.state.Namespace))
+	rk.NormalPrompt()
 }
 
 func (rk *RubyKube) GetNamespace(override string) string {
@@ -275,77 +470,49 @@ func (rk *RubyKube) GetDefaultNamespace(override string) string {
 	return ns
 }
 
-func (rk *RubyKube) resourceArgs(args []*mruby.MrbValue) (string, *regexp.Regexp, *metav1.ListOptions, error) {
+func (rk *RubyKube) resourceArgs(args []*mruby.MrbValue) ([]resourceQuery, *resourceSelectors, error) {
 	var (
-		ns          string
-		nameRegexp  *regexp.Regexp
-		listOptions metav1.ListOptions
+		queries   []resourceQuery
+		selectors resourceSelectors
 	)
 
-	validName := "[a-z0-9]([-a-z0-9]*[a-z0-9])?"
-	namespacePrefix := fmt.Sprintf(`?P<namespace>%s|\*`, validName)
-
-	// pods "foo/"
-	// pods "*/"
-	allWithinNamespace := regexp.MustCompile(
-		fmt.Sprintf(`^(%s)\/(\*)?$`,
-			namespacePrefix))
-	// pods "*-bar"
-	nameBeginsWith := regexp.MustCompile(
-		fmt.Sprintf(`^((%s)\/)?(?P<name>%s(-)?)\*$`,
-			namespacePrefix,
-			validName))
-	// pods "bar-*"
-	nameEndsWith := regexp.MustCompile(
-		fmt.Sprintf(`^((%s)\/)?\*(?P<name>(-)?%s)$`,
-			namespacePrefix,
-			validName))
-	// pods "*-bar-*"
-	nameContains := regexp.MustCompile(
-		fmt.Sprintf(`^((%s)\/)?\*(?P<name>(-)?%s(-)?)\*$`,
-			namespacePrefix,
-			validName))
-
-	// TODO pods "*-foo-*-bar-*"
-
 	hasNameGlob := false
 	hasSelectors := false
 
 	parseNameGlob := func(arg *mruby.MrbValue) error {
-		s := arg.String()
-		var p string
-		switch {
-		case allWithinNamespace.MatchString(s):
-			getNamedMatch(allWithinNamespace, s, "namespace", &ns)
-		case nameBeginsWith.MatchString(s):
-			getNamedMatch(nameBeginsWith, s, "namespace", &ns)
-			getNamedMatch(nameBeginsWith, s, "name", &p)
-			nameRegexp = regexp.MustCompile(fmt.Sprintf("^(%s)-?(%s)$", p, validName))
-		case nameEndsWith.MatchString(s):
-			getNamedMatch(nameEndsWith, s, "namespace", &ns)
-			getNamedMatch(nameEndsWith, s, "name", &p)
-			nameRegexp = regexp.MustCompile(fmt.Sprintf("^(%s)-?(%s)$", validName, p))
-		case nameContains.MatchString(s):
-			getNamedMatch(nameContains, s, "namespace", &ns)
-			getNamedMatch(nameContains, s, "name", &p)
-			nameRegexp = regexp.MustCompile(fmt.Sprintf("^(%s)?-?(%s)-?(%s)?$", validName, p, validName))
-		default:
-			if s != "*" {
-				return fmt.Errorf("Invalid glob expression - try `pods \"<namespace>/\"`, `pods \"*/\"` or `pods \"*/foo-*\"`\n")
-			}
+		q, err := newResourceQuery(arg.String())
+		if err != nil {
+			return err
 		}
+		queries = append(queries, *q)
 
 		hasNameGlob = true
 		return nil
 	}
 
+	parseNameGlobs := func(arg *mruby.MrbValue) error {
+		if err := iterateArray(arg, func(i int, v *mruby.MrbValue) error {
+			if v.Type() != mruby.TypeString {
+				return fmt.Errorf("Array element %d is not a string, only glob strings are allowed in array notation", i)
+			}
+			return parseNameGlob(v)
+		}); err != nil {
+			return err
+		}
+
+		if len(queries) == 0 {
+			return fmt.Errorf("Array of globs must not be empty")
+		}
+		return nil
+	}
+
 	evalLabelSelector := func(block *mruby.MrbValue) error {
 		newLabelNameObj, err := rk.classes.LabelSelector.New(block)
 		if err != nil {
 			return err
 		}
 
-		listOptions.LabelSelector = newLabelNameObj.self.String()
+		selectors.labelSelector = newLabelNameObj.vars.String()
 
 		return nil
 	}
@@ -356,7 +523,7 @@ func (rk *RubyKube) resourceArgs(args []*mruby.MrbValue) (string, *regexp.Regexp
 			return err
 		}
 
-		listOptions.FieldSelector = newFieldNameObj.self.String()
+		selectors.fields = newFieldNameObj.vars.collector.vars.fields
 
 		return nil
 	}
@@ -381,18 +548,22 @@ func (rk *RubyKube) resourceArgs(args []*mruby.MrbValue) (string, *regexp.Regexp
 		p := stringCollection.ToMapOfStrings()
 
 		if v, ok := p["labels"]; ok {
-			listOptions.LabelSelector = v
+			if err := selectors.setLabelSelector(v); err != nil {
+				return err
+			}
 		}
 		if v, ok := p["fields"]; ok {
-			listOptions.FieldSelector = v
+			if selectors.fields, err = parseFieldExpressions(v); err != nil {
+				return err
+			}
 		}
 
 		hasSelectors = true
 		return nil
 	}
 
-	fail := func(err error) (string, *regexp.Regexp, *metav1.ListOptions, error) {
-		return "", nil, nil, err
+	fail := func(err error) ([]resourceQuery, *resourceSelectors, error) {
+		return nil, nil, &argumentError{err}
 	}
 
 	secondArgError := func(kind string) error {
@@ -416,9 +587,11 @@ func (rk *RubyKube) resourceArgs(args []*mruby.MrbValue) (string, *regexp.Regexp
 			if err := evalFieldSelector(args[0]); err != nil {
 				return fail(err)
 			}
+			hasSelectors = true
 		case mruby.TypeArray:
-			// TODO: we could allow users to collect object matching multiple globs
-			return fail(fmt.Errorf("Not yet implemented!"))
+			if err := parseNameGlobs(args[0]); err != nil {
+				return fail(err)
+			}
 		}
 	}
 
@@ -447,11 +620,16 @@ func (rk *RubyKube) resourceArgs(args []*mruby.MrbValue) (string, *regexp.Regexp
 			if err := evalLabelSelector(args[1]); err != nil {
 				return fail(err)
 			}
-			if err := evalFieldSelector(args[0]); err != nil {
+			if err := evalFieldSelector(args[1]); err != nil {
 				return fail(err)
 			}
 		case mruby.TypeArray:
-			return fail(fmt.Errorf("Only single array argument is allowed"))
+			if hasNameGlob {
+				return fail(secondArgError("glob"))
+			}
+			if err := parseNameGlobs(args[1]); err != nil {
+				return fail(err)
+			}
 		}
 	}
 
@@ -459,7 +637,12 @@ func (rk *RubyKube) resourceArgs(args []*mruby.MrbValue) (string, *regexp.Regexp
 		return fail(fmt.Errorf("Maximum 2 arguments allowed"))
 	}
 
-	return ns, nameRegexp, &listOptions, nil
+	if len(queries) == 0 {
+		// no glob was given, so we get everything in current namespace
+		queries = append(queries, resourceQuery{})
+	}
+
+	return queries, &selectors, nil
 }
 
 // Mrb returns the mrb (mruby) instance the builder is using.
@@ -467,6 +650,16 @@ func (rk *RubyKube) Mrb() *mruby.Mrb {
 	return rk.mrb
 }
 
+// Lock takes the interpreter away from completion, the REPL holds it except while reading input.
+func (rk *RubyKube) Lock() {
+	rk.evalLock.Lock()
+}
+
+// Unlock lets completion use the interpreter again.
+func (rk *RubyKube) Unlock() {
+	rk.evalLock.Unlock()
+}
+
 // Close tears down all functions of the RubyKube, preparing it for exit.
 func (rk *RubyKube) Close() error {
 	rk.mrb.EnableGC()
@@ -0,0 +1,164 @@
+package rubykube
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+
+	mruby "github.com/mitchellh/go-mruby"
+)
+
+var (
+	readOnlyFlag = flag.Bool("read-only", false, "refuse to run methods and verbs that change the cluster, e.g. delete! or chaos")
+	yesFlag      = flag.Bool("yes", false, "in script mode, make changes in contexts that require confirmation without asking")
+	configFile   = flag.String("config", filepath.Join(kubeplayDir, "config.json"), "path to the config file with per-context policies")
+)
+
+// refusedError is returned when a change is not allowed by the policy or was not confirmed, it maps
+// to `RubyKube::Refused`
+type refusedError struct{ error }
+
+func newRefusedError(format string, a ...interface{}) error {
+	return &refusedError{fmt.Errorf(format, a...)}
+}
+
+// contextPolicy applies to contexts with names matching the glob, the first matching policy is used
+type contextPolicy struct {
+	Context  string `json:"context"`
+	ReadOnly bool   `json:"readOnly,omitempty"`
+	Confirm  bool   `json:"confirm,omitempty"`
+}
+
+// kubeplayConfig is read from `~/.kubeplay/config.json`, e.g. `{"contexts": [{"context": "prod-*", "confirm": true}]}`
+type kubeplayConfig struct {
+	Contexts []contextPolicy `json:"contexts"`
+	// AllowedRegistries are globs of registries that `images` doesn't report, e.g. `["gcr.io", "*.ecr.amazonaws.com"]`
+	AllowedRegistries []string `json:"allowedRegistries,omitempty"`
+}
+
+func loadConfig(file string) (*kubeplayConfig, error) {
+	config := &kubeplayConfig{}
+
+	data, err := ioutil.ReadFile(file)
+	if os.IsNotExist(err) {
+		return config, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if err := json.Unmarshal(data, config); err != nil {
+		return nil, fmt.Errorf("could not parse %q – %v", file, err)
+	}
+	for _, policy := range config.Contexts {
+		if _, err := path.Match(policy.Context, ""); err != nil {
+			return nil, fmt.Errorf("invalid context glob %q in %q – %v", policy.Context, file, err)
+		}
+	}
+	for _, glob := range config.AllowedRegistries {
+		if _, err := path.Match(glob, ""); err != nil {
+			return nil, fmt.Errorf("invalid registry glob %q in %q – %v", glob, file, err)
+		}
+	}
+	return config, nil
+}
+
+// policy returns the policy of current context, `-read-only` applies to all contexts
+func (rk *RubyKube) policy() contextPolicy {
+	policy := contextPolicy{Context: rk.state.Context}
+	for _, p := range rk.settings.Contexts {
+		if ok, _ := path.Match(p.Context, rk.state.Context); ok {
+			policy = p
+			break
+		}
+	}
+	if *readOnlyFlag {
+		policy.ReadOnly = true
+	}
+	return policy
+}
+
+// allowChange must be called before anything that changes the cluster, it returns an error if
+// current context is read-only, or the change requires confirmation and it wasn't given
+func (rk *RubyKube) allowChange(what string) error {
+	return rk.allowDescribedChange(func() string { return what })
+}
+
+// allowDescribedChange is `allowChange` for changes that are costly to describe, the description
+// is only made when the change is refused or has to be confirmed
+func (rk *RubyKube) allowDescribedChange(describe func() string) error {
+	policy := rk.policy()
+
+	if policy.ReadOnly {
+		return newRefusedError("refusing to %s – context %q is read-only", describe(), rk.state.Context)
+	}
+	if !policy.Confirm {
+		return nil
+	}
+	what := describe()
+
+	// there is nobody to ask in script mode
+	if rk.readline == nil {
+		if *yesFlag {
+			return nil
+		}
+		return newRefusedError("refusing to %s – context %q requires confirmation, use -yes to confirm changes in script mode", what, rk.state.Context)
+	}
+
+	if !rk.confirm(fmt.Sprintf("%s in context %q? [y/N] ", what, rk.state.Context)) {
+		return newRefusedError("refusing to %s – not confirmed", what)
+	}
+	return nil
+}
+
+// confirm asks a yes/no question, answers are not added to readline history; config is changed in
+// place, as setting a new one would reset the history
+func (rk *RubyKube) confirm(question string) bool {
+	disabled := rk.readline.Config.DisableAutoSaveHistory
+	rk.readline.Config.DisableAutoSaveHistory = true
+	rk.readline.SetPrompt(question)
+	defer func() {
+		rk.readline.Config.DisableAutoSaveHistory = disabled
+		rk.NormalPrompt()
+	}()
+
+	answer, err := rk.readline.Readline()
+	if err != nil {
+		return false
+	}
+	switch strings.ToLower(strings.TrimSpace(answer)) {
+	case "y", "yes":
+		return true
+	}
+	return false
+}
+
+// guardChange wraps methods tagged as `mutatingMethod`, so that they are checked with `allowChange`,
+// the object is described by `changeSummary`
+func (rk *RubyKube) guardChange(name string, fn mruby.Func) mruby.Func {
+	return func(m *mruby.Mrb, self *mruby.MrbValue) (mruby.Value, mruby.Value) {
+		if err := rk.allowDescribedChange(func() string { return rk.changeSummary(name, self) }); err != nil {
+			return nil, createError(m, err)
+		}
+		return fn(m, self)
+	}
+}
+
+// changeSummary describes a call in one line, so it fits a prompt: lists are inspected as a header
+// followed by a line per item, so only the header is kept; if the object cannot be inspected, its
+// class and name are used
+func (rk *RubyKube) changeSummary(name string, self *mruby.MrbValue) string {
+	inspected, err := rk.Inspect(self)
+	if err != nil {
+		return fmt.Sprintf("%s %s %s", name, self.Class(), self.String())
+	}
+	if i := strings.IndexByte(inspected, '\n'); i >= 0 {
+		inspected = inspected[:i]
+	}
+	return fmt.Sprintf("%s %s", name, inspected)
+}
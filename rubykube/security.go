@@ -0,0 +1,717 @@
+package rubykube
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+	"text/tabwriter"
+
+	mruby "github.com/mitchellh/go-mruby"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// securityRules defines built-in rules of `audit_security`, these are written in Ruby, so they can be used as
+// examples for rules of your own; rules are given a pod spec and pod metadata, and return paths of fields that
+// violate the rule, or `[path, message]` pairs
+const securityRules = `
+class RubyKube
+  module Security
+    BASELINE_CAPABILITIES = ["AUDIT_WRITE", "CHOWN", "DAC_OVERRIDE", "FOWNER", "FSETID", "KILL", "MKNOD",
+                             "NET_BIND_SERVICE", "SETFCAP", "SETGID", "SETPCAP", "SETUID", "SYS_CHROOT"]
+    RESTRICTED_VOLUMES = ["configMap", "csi", "downwardAPI", "emptyDir", "ephemeral", "persistentVolumeClaim",
+                          "projected", "secret"]
+    SAFE_SYSCTLS = ["kernel.shm_rmid_forced", "net.ipv4.ip_local_port_range", "net.ipv4.ip_unprivileged_port_start",
+                    "net.ipv4.tcp_syncookies", "net.ipv4.ping_group_range"]
+    SELINUX_TYPES = ["container_t", "container_init_t", "container_kvm_t"]
+    # seccomp profiles are set with annotations, as there is no seccompProfile field in Kubernetes 1.9
+    SECCOMP_POD_ANNOTATION = "seccomp.security.alpha.kubernetes.io/pod"
+    SECCOMP_CONTAINER_ANNOTATION = "container.seccomp.security.alpha.kubernetes.io/"
+    SECCOMP_PROFILES = ["runtime/default", "docker/default"]
+
+    # containers returns [container, path] pairs, including init and ephemeral containers
+    def self.containers(spec, kinds = ["initContainers", "containers", "ephemeralContainers"])
+      found = []
+      kinds.each do |kind|
+        (spec[kind] || []).each_with_index { |c, i| found << [c, "#{kind}[#{i}]"] }
+      end
+      found
+    end
+
+    # setting returns [value, path] of a security context setting of the container, or of the pod
+    # if the container doesn't override it
+    def self.setting(spec, container, path, key)
+      context = container["securityContext"] || {}
+      return [context[key], "#{path}.securityContext.#{key}"] if context.has_key?(key)
+      [(spec["securityContext"] || {})[key], "securityContext.#{key}"]
+    end
+
+    # seccomp returns [profile, path] of the container annotation, or of the pod one if the container doesn't
+    # have it
+    def self.seccomp(metadata, container)
+      annotations = (metadata || {})["annotations"] || {}
+      key = "#{SECCOMP_CONTAINER_ANNOTATION}#{container["name"]}"
+      key = SECCOMP_POD_ANNOTATION unless annotations.has_key?(key)
+      [annotations[key], "metadata.annotations['#{key}']"]
+    end
+  end
+end
+
+security_rule "privileged", severity: :critical, level: :baseline,
+  description: "privileged containers have full access to the host" do |spec|
+  found = []
+  RubyKube::Security.containers(spec).each do |c, path|
+    found << ["#{path}.securityContext.privileged", "container #{c["name"]} is privileged"] if (c["securityContext"] || {})["privileged"]
+  end
+  found
+end
+
+security_rule "host-namespaces", severity: :high, level: :baseline,
+  description: "pods must not share network, PID or IPC namespaces with the host" do |spec|
+  found = []
+  ["hostNetwork", "hostPID", "hostIPC"].each { |key| found << [key, "#{key} is enabled"] if spec[key] }
+  found
+end
+
+security_rule "host-path", severity: :high, level: :baseline,
+  description: "hostPath volumes expose files of the host" do |spec|
+  found = []
+  (spec["volumes"] || []).each_with_index do |v, i|
+    found << ["volumes[#{i}].hostPath", "volume #{v["name"]} mounts #{v["hostPath"]["path"]} from the host"] if v["hostPath"]
+  end
+  found
+end
+
+security_rule "host-ports", severity: :medium, level: :baseline,
+  description: "host ports bypass network policies" do |spec|
+  found = []
+  RubyKube::Security.containers(spec).each do |c, path|
+    (c["ports"] || []).each_with_index do |p, i|
+      found << ["#{path}.ports[#{i}].hostPort", "container #{c["name"]} uses host port #{p["hostPort"]}"] if (p["hostPort"] || 0) != 0
+    end
+  end
+  found
+end
+
+security_rule "added-capabilities", severity: :high, level: :baseline,
+  description: "capabilities beyond the default set must not be added" do |spec|
+  found = []
+  RubyKube::Security.containers(spec).each do |c, path|
+    added = ((c["securityContext"] || {})["capabilities"] || {})["add"] || []
+    added.each_with_index do |cap, i|
+      next if RubyKube::Security::BASELINE_CAPABILITIES.include?(cap)
+      found << ["#{path}.securityContext.capabilities.add[#{i}]", "container #{c["name"]} adds #{cap}"]
+    end
+  end
+  found
+end
+
+security_rule "proc-mount", severity: :high, level: :baseline,
+  description: "/proc must be masked" do |spec|
+  found = []
+  RubyKube::Security.containers(spec).each do |c, path|
+    mount = (c["securityContext"] || {})["procMount"]
+    found << ["#{path}.securityContext.procMount", "container #{c["name"]} has #{mount} /proc mount"] if mount && mount != "Default"
+  end
+  found
+end
+
+security_rule "seccomp-unconfined", severity: :high, level: :baseline,
+  description: "seccomp profile must not be unconfined" do |spec, metadata|
+  found = []
+  annotations = (metadata || {})["annotations"] || {}
+  key = RubyKube::Security::SECCOMP_POD_ANNOTATION
+  found << ["metadata.annotations['#{key}']", "pod is unconfined"] if annotations[key] == "unconfined"
+  RubyKube::Security.containers(spec).each do |c, path|
+    key = "#{RubyKube::Security::SECCOMP_CONTAINER_ANNOTATION}#{c["name"]}"
+    found << ["metadata.annotations['#{key}']", "container #{c["name"]} is unconfined"] if annotations[key] == "unconfined"
+  end
+  found
+end
+
+security_rule "selinux", severity: :medium, level: :baseline,
+  description: "SELinux user and role must not be set, and only container types can be used" do |spec|
+  found = []
+  contexts = [[spec["securityContext"] || {}, "securityContext"]]
+  RubyKube::Security.containers(spec).each { |c, path| contexts << [c["securityContext"] || {}, "#{path}.securityContext"] }
+  contexts.each do |context, path|
+    options = context["seLinuxOptions"] || {}
+    found << ["#{path}.seLinuxOptions.type", "SELinux type #{options["type"]} is not allowed"] if options["type"] && !RubyKube::Security::SELINUX_TYPES.include?(options["type"])
+    found << ["#{path}.seLinuxOptions.user", "SELinux user is set"] if options["user"]
+    found << ["#{path}.seLinuxOptions.role", "SELinux role is set"] if options["role"]
+  end
+  found
+end
+
+security_rule "sysctls", severity: :medium, level: :baseline,
+  description: "only safe sysctls can be set" do |spec|
+  found = []
+  ((spec["securityContext"] || {})["sysctls"] || []).each_with_index do |s, i|
+    found << ["securityContext.sysctls[#{i}]", "sysctl #{s["name"]} is not safe"] unless RubyKube::Security::SAFE_SYSCTLS.include?(s["name"])
+  end
+  found
+end
+
+security_rule "volume-types", severity: :medium, level: :restricted,
+  description: "only config maps, secrets, ephemeral and persistent volume claims can be used as volumes" do |spec|
+  found = []
+  (spec["volumes"] || []).each_with_index do |v, i|
+    v.keys.each do |type|
+      next if type == "name" || RubyKube::Security::RESTRICTED_VOLUMES.include?(type)
+      found << ["volumes[#{i}].#{type}", "volume #{v["name"]} has type #{type}"]
+    end
+  end
+  found
+end
+
+security_rule "privilege-escalation", severity: :medium, level: :restricted,
+  description: "containers must set allowPrivilegeEscalation to false" do |spec|
+  found = []
+  RubyKube::Security.containers(spec).each do |c, path|
+    next if (c["securityContext"] || {})["allowPrivilegeEscalation"] == false
+    found << ["#{path}.securityContext.allowPrivilegeEscalation", "container #{c["name"]} allows privilege escalation"]
+  end
+  found
+end
+
+security_rule "run-as-root", severity: :high, level: :restricted,
+  description: "containers must not run as root" do |spec|
+  found = []
+  RubyKube::Security.containers(spec).each do |c, path|
+    user, at = RubyKube::Security.setting(spec, c, path, "runAsUser")
+    found << [at, "container #{c["name"]} runs as root"] if user == 0
+  end
+  found
+end
+
+security_rule "run-as-non-root", severity: :medium, level: :restricted,
+  description: "containers must set runAsNonRoot, otherwise the image may run as root" do |spec|
+  found = []
+  RubyKube::Security.containers(spec).each do |c, path|
+    non_root, at = RubyKube::Security.setting(spec, c, path, "runAsNonRoot")
+    found << [at, "container #{c["name"]} may run as root"] unless non_root
+  end
+  found
+end
+
+security_rule "seccomp-profile", severity: :medium, level: :restricted,
+  description: "seccomp profile must be runtime/default, docker/default or localhost/<profile>" do |spec, metadata|
+  found = []
+  RubyKube::Security.containers(spec).each do |c, path|
+    profile, at = RubyKube::Security.seccomp(metadata, c)
+    next if RubyKube::Security::SECCOMP_PROFILES.include?(profile) || profile.to_s.index("localhost/") == 0
+    found << [at, "container #{c["name"]} has no seccomp profile"]
+  end
+  found
+end
+
+security_rule "capabilities", severity: :medium, level: :restricted,
+  description: "containers must drop ALL capabilities, and can only add NET_BIND_SERVICE" do |spec|
+  found = []
+  RubyKube::Security.containers(spec).each do |c, path|
+    capabilities = (c["securityContext"] || {})["capabilities"] || {}
+    unless (capabilities["drop"] || []).include?("ALL")
+      found << ["#{path}.securityContext.capabilities.drop", "container #{c["name"]} doesn't drop ALL capabilities"]
+    end
+    (capabilities["add"] || []).each_with_index do |cap, i|
+      found << ["#{path}.securityContext.capabilities.add[#{i}]", "container #{c["name"]} adds #{cap}"] unless cap == "NET_BIND_SERVICE"
+    end
+  end
+  found
+end
+
+security_rule "read-only-root-filesystem", severity: :low,
+  description: "containers should have a read-only root filesystem" do |spec|
+  found = []
+  RubyKube::Security.containers(spec).each do |c, path|
+    next if (c["securityContext"] || {})["readOnlyRootFilesystem"]
+    found << ["#{path}.securityContext.readOnlyRootFilesystem", "container #{c["name"]} has a writable root filesystem"]
+  end
+  found
+end
+
+security_rule "resource-limits", severity: :medium,
+  description: "containers should have CPU and memory limits" do |spec|
+  found = []
+  RubyKube::Security.containers(spec, ["initContainers", "containers"]).each do |c, path|
+    limits = (c["resources"] || {})["limits"] || {}
+    ["cpu", "memory"].each do |resource|
+      found << ["#{path}.resources.limits.#{resource}", "container #{c["name"]} has no #{resource} limit"] unless limits[resource]
+    end
+  end
+  found
+end
+
+security_rule "service-account-token", severity: :low,
+  description: "service account token should not be mounted, unless the pod uses the API" do |spec|
+  spec["automountServiceAccountToken"] == false ? [] : [["automountServiceAccountToken", "service account token is mounted"]]
+end
+`
+
+// securitySeverities are in order of decreasing severity
+var securitySeverities = []string{"critical", "high", "medium", "low"}
+
+// securityLevels are Pod Security Standards levels, each level includes checks of the previous one
+var securityLevels = []string{"baseline", "restricted"}
+
+func indexOf(values []string, value string) int {
+	for i, v := range values {
+		if v == value {
+			return i
+		}
+	}
+	return -1
+}
+
+// securityRule is defined with `security_rule`, the block is called with a pod spec and pod metadata
+type securityRule struct {
+	Name        string `json:"name"`
+	Severity    string `json:"severity"`
+	Level       string `json:"level,omitempty"`
+	Description string `json:"description,omitempty"`
+
+	block *mruby.MrbValue
+}
+
+type securityFinding struct {
+	Object   string `json:"object"`
+	Rule     string `json:"rule"`
+	Severity string `json:"severity"`
+	Level    string `json:"level,omitempty"`
+	Path     string `json:"path"`
+	Message  string `json:"message"`
+}
+
+type securityReport struct {
+	Level    string            `json:"level,omitempty"`
+	Rules    []string          `json:"rules"`
+	Objects  []string          `json:"objects"`
+	Findings []securityFinding `json:"findings"`
+}
+
+// securityTarget is an object with a pod spec, or a list of these
+type securityTarget struct {
+	Kind     string                 `json:"kind"`
+	Metadata metav1.ObjectMeta      `json:"metadata"`
+	Spec     map[string]interface{} `json:"spec"`
+	Items    []securityTarget       `json:"items"`
+}
+
+// podSpecPaths are where pods, workloads and cron jobs have their pod spec
+var podSpecPaths = [][]string{
+	{"spec"},
+	{"spec", "template", "spec"},
+	{"spec", "jobTemplate", "spec", "template", "spec"},
+}
+
+// podSpec finds the pod spec of the object, and returns it with the path of the pod (or pod template),
+// which is empty for pods
+func (t *securityTarget) podSpec() (map[string]interface{}, string, bool) {
+	for _, p := range podSpecPaths {
+		var value interface{} = map[string]interface{}{"spec": t.Spec}
+		for _, key := range p {
+			if m, ok := value.(map[string]interface{}); ok {
+				value = m[key]
+			} else {
+				value = nil
+			}
+		}
+		if spec, ok := value.(map[string]interface{}); ok {
+			if _, ok := spec["containers"]; ok {
+				return spec, strings.Join(p[:len(p)-1], "."), true
+			}
+		}
+	}
+	return nil, "", false
+}
+
+// podMetadata returns metadata of the pod, or of the pod template, which has seccomp annotations
+func (t *securityTarget) podMetadata(podPath string) (map[string]interface{}, error) {
+	if podPath == "" {
+		data, err := json.Marshal(t.Metadata)
+		if err != nil {
+			return nil, err
+		}
+		metadata := map[string]interface{}{}
+		if err := json.Unmarshal(data, &metadata); err != nil {
+			return nil, err
+		}
+		return metadata, nil
+	}
+
+	var value interface{} = map[string]interface{}{"spec": t.Spec}
+	for _, key := range append(strings.Split(podPath, "."), "metadata") {
+		if m, ok := value.(map[string]interface{}); ok {
+			value = m[key]
+		} else {
+			value = nil
+		}
+	}
+	metadata, _ := value.(map[string]interface{})
+	return metadata, nil
+}
+
+// defineSecurityRule adds a rule, or replaces a rule with the same name
+func (rk *RubyKube) defineSecurityRule(rule *securityRule) error {
+	// the block is only referenced from Go; protection is counted, so when a rule is replaced by one
+	// with the same block, or a block is shared by rules, it stays protected for the others
+	if err := rk.protect(rule.block); err != nil {
+		return err
+	}
+	for i, r := range rk.securityRules {
+		if r.Name == rule.Name {
+			if err := rk.unprotect(r.block); err != nil {
+				return err
+			}
+			rk.securityRules[i] = rule
+			return nil
+		}
+	}
+	rk.securityRules = append(rk.securityRules, rule)
+	return nil
+}
+
+// selectRules returns rules that check the given PSS level (all rules if it's empty), or only those named
+func (rk *RubyKube) selectRules(level string, names []string) ([]*securityRule, error) {
+	rules := []*securityRule{}
+	for _, name := range names {
+		found := false
+		for _, r := range rk.securityRules {
+			if r.Name == name {
+				rules, found = append(rules, r), true
+			}
+		}
+		if !found {
+			return nil, newArgumentError("unknown rule %q", name)
+		}
+	}
+	if len(names) == 0 {
+		rules = rk.securityRules
+	}
+
+	if level == "" {
+		return rules, nil
+	}
+	selected := []*securityRule{}
+	for _, r := range rules {
+		if r.Level != "" && indexOf(securityLevels, r.Level) <= indexOf(securityLevels, level) {
+			selected = append(selected, r)
+		}
+	}
+	return selected, nil
+}
+
+// check calls the rule with a pod spec and pod metadata, paths it returns are relative to the pod spec,
+// unless these start with `metadata.`
+func (r *securityRule) check(spec, metadata *mruby.MrbValue) ([][2]string, error) {
+	result, err := r.block.Call("call", spec, metadata)
+	if err != nil {
+		return nil, err
+	}
+	if result.Type() == mruby.TypeNil {
+		return nil, nil
+	}
+
+	invalid := fmt.Errorf("rule %q must return an array of paths, or of [path, message] pairs", r.Name)
+	if result.Type() != mruby.TypeArray {
+		return nil, invalid
+	}
+
+	found := [][2]string{}
+	if err := iterateArray(result, func(_ int, v *mruby.MrbValue) error {
+		switch v.Type() {
+		case mruby.TypeString:
+			found = append(found, [2]string{v.String(), r.Description})
+		case mruby.TypeArray:
+			if v.Array().Len() != 2 {
+				return invalid
+			}
+			path, _ := v.Array().Get(0)
+			message, _ := v.Array().Get(1)
+			found = append(found, [2]string{path.String(), message.String()})
+		default:
+			return invalid
+		}
+		return nil
+	}); err != nil {
+		return nil, err
+	}
+	return found, nil
+}
+
+// auditTargets checks every object with each rule, kind is used for list items, as these don't have it set
+func (rk *RubyKube) auditTargets(m *mruby.Mrb, targets []securityTarget, kind string, rules []*securityRule, report *securityReport) error {
+	for i := range targets {
+		t := &targets[i]
+		if t.Kind == "" {
+			t.Kind = kind
+		}
+
+		spec, podPath, ok := t.podSpec()
+		if !ok {
+			return newArgumentError("%s %s has no pod spec", t.Kind, objectName(t.Metadata))
+		}
+		object := fmt.Sprintf("%s %s", strings.ToLower(t.Kind), objectName(t.Metadata))
+		report.Objects = append(report.Objects, object)
+
+		metadata, err := t.podMetadata(podPath)
+		if err != nil {
+			return err
+		}
+		// both values are only referenced from Go while rules run Ruby code
+		values, err := nativeRubyValueOf(m, []interface{}{spec, metadata})
+		if err != nil {
+			return err
+		}
+		if err := protectValue(m, values); err != nil {
+			return err
+		}
+		specValue, _ := values.Array().Get(0)
+		metadataValue, _ := values.Array().Get(1)
+		err = checkRules(rules, object, podPath, specValue, metadataValue, report)
+		unprotectValue(m, values)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// checkRules adds findings of each rule for an object to the report
+func checkRules(rules []*securityRule, object, podPath string, spec, metadata *mruby.MrbValue, report *securityReport) error {
+	for _, rule := range rules {
+		found, err := rule.check(spec, metadata)
+		if err != nil {
+			return err
+		}
+		for _, f := range found {
+			report.Findings = append(report.Findings, securityFinding{
+				Object:   object,
+				Rule:     rule.Name,
+				Severity: rule.Severity,
+				Level:    rule.Level,
+				Path:     findingPath(podPath, f[0]),
+				Message:  f[1],
+			})
+		}
+	}
+	return nil
+}
+
+// findingPath makes a path returned by a rule relative to the object
+func findingPath(podPath, path string) string {
+	if !strings.HasPrefix(path, "metadata.") {
+		path = "spec." + path
+	}
+	if podPath == "" {
+		return path
+	}
+	return podPath + "." + path
+}
+
+// findings returns findings of at least the given severity, or all of them
+func (report *securityReport) findings(severity string) []securityFinding {
+	if severity == "" {
+		return report.Findings
+	}
+	findings := []securityFinding{}
+	for _, f := range report.Findings {
+		if indexOf(securitySeverities, f.Severity) <= indexOf(securitySeverities, severity) {
+			findings = append(findings, f)
+		}
+	}
+	return findings
+}
+
+func (report *securityReport) String() string {
+	text := bytes.Buffer{}
+
+	if len(report.Findings) == 0 {
+		fmt.Fprintf(&text, "No findings in %d objects\n", len(report.Objects))
+		return text.String()
+	}
+	bySeverity := map[string]int{}
+	for _, f := range report.Findings {
+		bySeverity[f.Severity]++
+	}
+	counts := []string{}
+	for _, severity := range securitySeverities {
+		if bySeverity[severity] > 0 {
+			counts = append(counts, fmt.Sprintf("%s=%d", severity, bySeverity[severity]))
+		}
+	}
+	fmt.Fprintf(&text, "%d findings in %d objects (%s)\n\n", len(report.Findings), len(report.Objects), strings.Join(counts, " "))
+
+	w := tabwriter.NewWriter(&text, 0, 8, 2, ' ', 0)
+	fmt.Fprintln(w, "SEVERITY\tRULE\tOBJECT\tPATH\tMESSAGE")
+	for _, f := range report.Findings {
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\n", f.Severity, f.Rule, f.Object, f.Path, f.Message)
+	}
+	w.Flush()
+	return text.String()
+}
+
+func severityArg(value *mruby.MrbValue) (string, error) {
+	severity := value.String()
+	if indexOf(securitySeverities, severity) < 0 {
+		return "", newArgumentError("unknown severity %q – not one of %v", severity, securitySeverities)
+	}
+	return severity, nil
+}
+
+// securityRuleVerb implements `security_rule`, e.g.
+// `security_rule("no-default-namespace", severity: :low) { |spec| spec["serviceAccountName"] ? [] : ["serviceAccountName"] }`
+func securityRuleVerb(rk *RubyKube, args []*mruby.MrbValue, m *mruby.Mrb, self *mruby.MrbValue) (mruby.Value, mruby.Value) {
+	block := blockArg(args)
+	if block == nil {
+		return nil, createException(m, "Block must be given")
+	}
+	if args[0].Type() != mruby.TypeString && args[0].Type() != mruby.TypeSymbol {
+		return nil, createException(m, "Rule name must be a string")
+	}
+
+	rule := &securityRule{Name: args[0].String(), Severity: "medium", block: block}
+	for _, arg := range args[1:] {
+		if arg.Type() == mruby.TypeProc {
+			continue
+		}
+		if arg.Type() != mruby.TypeHash {
+			return nil, createException(m, "Second argument must be a hash, e.g. `severity: :high, level: :baseline`")
+		}
+		if err := iterateHash(arg, func(key, value *mruby.MrbValue) error {
+			switch k := key.String(); k {
+			case "severity":
+				severity, err := severityArg(value)
+				if err != nil {
+					return err
+				}
+				rule.Severity = severity
+			case "level":
+				if rule.Level = value.String(); indexOf(securityLevels, rule.Level) < 0 {
+					return newArgumentError("unknown level %q – not one of %v", rule.Level, securityLevels)
+				}
+			case "description":
+				rule.Description = value.String()
+			default:
+				return newArgumentError("unknown parameter %q – not one of [severity level description]", k)
+			}
+			return nil
+		}); err != nil {
+			return nil, createError(m, err)
+		}
+	}
+	if rule.Description == "" {
+		rule.Description = fmt.Sprintf("violates %s rule", rule.Name)
+	}
+
+	if err := rk.defineSecurityRule(rule); err != nil {
+		return nil, createError(m, err)
+	}
+	return nil, nil
+}
+
+// securityRulesVerb implements `security_rules`, which lists rules that `audit_security` checks
+func securityRulesVerb(rk *RubyKube, args []*mruby.MrbValue, m *mruby.Mrb, self *mruby.MrbValue) (mruby.Value, mruby.Value) {
+	value, err := nativeRubyValueOf(m, rk.securityRules)
+	if err != nil {
+		return nil, createError(m, err)
+	}
+	return value, nil
+}
+
+// auditSecurity implements `audit_security`, which checks pod specs of pods, workloads or lists of these,
+// e.g. `audit_security(pods("*/"), level: :restricted)`
+func auditSecurity(rk *RubyKube, args []*mruby.MrbValue, m *mruby.Mrb, self *mruby.MrbValue) (mruby.Value, mruby.Value) {
+	usage := "First argument must be pods, workloads or a list of these, e.g. `pods(\"*/\")`"
+	if len(args) == 0 {
+		return nil, createException(m, usage)
+	}
+
+	var (
+		level, severity string
+		names           []string
+	)
+	for _, arg := range args[1:] {
+		if arg.Type() != mruby.TypeHash {
+			return nil, createException(m, "Second argument must be a hash, e.g. `level: :restricted`")
+		}
+		if err := iterateHash(arg, func(key, value *mruby.MrbValue) error {
+			switch k := key.String(); k {
+			case "level":
+				if level = value.String(); indexOf(securityLevels, level) < 0 {
+					return newArgumentError("unknown level %q – not one of %v", level, securityLevels)
+				}
+			case "severity":
+				s, err := severityArg(value)
+				if err != nil {
+					return err
+				}
+				severity = s
+			case "rules":
+				if value.Type() != mruby.TypeArray {
+					return newArgumentError("rules must be an array of names")
+				}
+				return iterateArray(value, func(_ int, v *mruby.MrbValue) error {
+					names = append(names, v.String())
+					return nil
+				})
+			default:
+				return newArgumentError("unknown parameter %q – not one of [level severity rules]", k)
+			}
+			return nil
+		}); err != nil {
+			return nil, createError(m, err)
+		}
+	}
+
+	rules, err := rk.selectRules(level, names)
+	if err != nil {
+		return nil, createError(m, err)
+	}
+
+	if ok, err := args[0].Call("respond_to?", m.StringValue("to_json")); err != nil || ok.Type() != mruby.TypeTrue {
+		return nil, createException(m, usage)
+	}
+	data, err := args[0].Call("to_json")
+	if err != nil {
+		return nil, createError(m, err)
+	}
+	target := securityTarget{}
+	if err := json.Unmarshal([]byte(data.String()), &target); err != nil {
+		return nil, createException(m, usage)
+	}
+
+	// list items don't have kind set, so it's taken from the class name, e.g. `Pods`
+	className, err := args[0].Call("class")
+	if err != nil {
+		return nil, createError(m, err)
+	}
+	kind := strings.TrimSuffix(className.String(), "s")
+
+	report := &securityReport{Level: level, Rules: []string{}, Objects: []string{}, Findings: []securityFinding{}}
+	for _, r := range rules {
+		report.Rules = append(report.Rules, r.Name)
+	}
+
+	// only lists have no spec, items of an empty list may be null
+	targets := []securityTarget{target}
+	if target.Spec == nil {
+		targets = target.Items
+	}
+	if err := rk.auditTargets(m, targets, kind, rules, report); err != nil {
+		return nil, createError(m, err)
+	}
+
+	report.Findings = report.findings(severity)
+	sort.SliceStable(report.Findings, func(i, j int) bool {
+		return indexOf(securitySeverities, report.Findings[i].Severity) < indexOf(securitySeverities, report.Findings[j].Severity)
+	})
+
+	newReportObj, err := rk.classes.SecurityReport.New()
+	if err != nil {
+		return nil, createError(m, err)
+	}
+	newReportObj.vars.report = report
+	return newReportObj.self, nil
+}
@@ -0,0 +1,184 @@
+package rubykube
+
+import (
+	"fmt"
+	"strings"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+)
+
+// supportedFieldSelectors lists fields that API server can select on for each list class,
+// anything else is filtered on the client; `metadata.name` and `metadata.namespace` are
+// supported by all kinds
+var supportedFieldSelectors = map[string][]string{
+	"Pods": {
+		"spec.nodeName",
+		"spec.restartPolicy",
+		"spec.schedulerName",
+		"spec.serviceAccountName",
+		"status.phase",
+		"status.podIP",
+	},
+	"ReplicaSets": {
+		"status.replicas",
+	},
+	"Events": {
+		"involvedObject.apiVersion",
+		"involvedObject.fieldPath",
+		"involvedObject.kind",
+		"involvedObject.name",
+		"involvedObject.namespace",
+		"involvedObject.resourceVersion",
+		"involvedObject.uid",
+		"reason",
+		"source",
+		"type",
+	},
+}
+
+func isSupportedFieldSelector(kind, key string) bool {
+	if key == "metadata.name" || key == "metadata.namespace" {
+		return true
+	}
+	for _, k := range supportedFieldSelectors[kind] {
+		if k == key {
+			return true
+		}
+	}
+	return false
+}
+
+// resourceSelectors holds label and field selectors given to a verb, field selectors are kept
+// as expressions until we know what kind of objects they apply to
+type resourceSelectors struct {
+	labelSelector string
+	fields        []fieldExpression
+}
+
+func (s *resourceSelectors) setLabelSelector(selector string) error {
+	if _, err := labels.Parse(selector); err != nil {
+		return fmt.Errorf("invalid label selector %q – %v", selector, err)
+	}
+	s.labelSelector = selector
+	return nil
+}
+
+// parseFieldExpressions parses field selector strings, e.g. `"status.phase != Running"`
+func parseFieldExpressions(selector string) ([]fieldExpression, error) {
+	exprs := []fieldExpression{}
+	for _, term := range strings.Split(selector, ",") {
+		if strings.TrimSpace(term) == "" {
+			continue
+		}
+
+		found := false
+		for _, operator := range []string{"!=", "==", "="} {
+			if i := strings.Index(term, operator); i > 0 {
+				key, value := strings.TrimSpace(term[:i]), strings.TrimSpace(term[i+len(operator):])
+				if key == "" || strings.ContainsAny(value, "=!") {
+					break
+				}
+				if operator == "=" {
+					operator = "=="
+				}
+				exprs = append(exprs, fieldExpression{key: key, operator: operator, values: []string{value}})
+				found = true
+				break
+			}
+		}
+		if !found {
+			return nil, fmt.Errorf("invalid field selector term %q – must be `<key>==<value>` or `<key>!=<value>`", term)
+		}
+	}
+	return exprs, nil
+}
+
+// fieldSelectorStrings expands set-valued equality expressions into multiple selectors
+// (as in `status.phase =~ %w(Running Pending)`), since a field selector can only express
+// an AND of its terms; results of each of the queries should be merged
+func fieldSelectorStrings(exprs []fieldExpression) []string {
+	selectors := [][]string{{}}
+	for _, e := range exprs {
+		if e.operator == "==" && len(e.values) > 1 {
+			expanded := [][]string{}
+			for _, s := range selectors {
+				for _, v := range e.values {
+					terms := append(append([]string{}, s...), fmt.Sprintf("%s%s%s", e.key, e.operator, v))
+					expanded = append(expanded, terms)
+				}
+			}
+			selectors = expanded
+			continue
+		}
+		for i := range selectors {
+			for _, v := range e.values {
+				selectors[i] = append(selectors[i], fmt.Sprintf("%s%s%s", e.key, e.operator, v))
+			}
+		}
+	}
+
+	out := []string{}
+	for _, s := range selectors {
+		out = append(out, strings.Join(s, ","))
+	}
+	return out
+}
+
+// listOptions splits field expressions into those API server supports for given kind, and
+// those that have to be evaluated on the client; it returns one set of list options per query
+func (s *resourceSelectors) listOptions(kind string) ([]metav1.ListOptions, []fieldExpression) {
+	serverFields, clientFields := []fieldExpression{}, []fieldExpression{}
+	for _, e := range s.fields {
+		if isSupportedFieldSelector(kind, e.key) {
+			serverFields = append(serverFields, e)
+		} else {
+			clientFields = append(clientFields, e)
+		}
+	}
+
+	listOptions := []metav1.ListOptions{}
+	for _, fieldSelector := range fieldSelectorStrings(serverFields) {
+		listOptions = append(listOptions, metav1.ListOptions{
+			LabelSelector: s.labelSelector,
+			FieldSelector: fieldSelector,
+		})
+	}
+	return listOptions, clientFields
+}
+
+// matchFieldValues implements semantics of field selectors, missing fields match as empty strings
+func matchFieldValues(e fieldExpression, found []string) bool {
+	if len(found) == 0 {
+		found = []string{""}
+	}
+
+	for _, f := range found {
+		for _, v := range e.values {
+			if f == v {
+				return e.operator == "=="
+			}
+		}
+	}
+	return e.operator != "=="
+}
+
+// matchFieldExpressions evaluates field expressions against a Go object on the client
+func matchFieldExpressions(obj interface{}, exprs []fieldExpression) (bool, error) {
+	for _, e := range exprs {
+		results, err := evalJSONPath(obj, e.key, true)
+		if err != nil {
+			return false, err
+		}
+
+		found := []string{}
+		for _, r := range results {
+			found = append(found, jsonPathResultToString(r))
+		}
+
+		if !matchFieldValues(e, found) {
+			return false, nil
+		}
+	}
+	return true, nil
+}
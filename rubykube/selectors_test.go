@@ -0,0 +1,154 @@
+package rubykube
+
+import (
+	"reflect"
+	"testing"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestParseFieldExpressions(t *testing.T) {
+	tests := []struct {
+		selector string
+		exprs    []fieldExpression
+		err      bool
+	}{
+		{selector: "", exprs: []fieldExpression{}},
+		{selector: "status.phase=Running", exprs: []fieldExpression{{key: "status.phase", operator: "==", values: []string{"Running"}}}},
+		{selector: "status.phase == Running", exprs: []fieldExpression{{key: "status.phase", operator: "==", values: []string{"Running"}}}},
+		{selector: "status.phase != Running, spec.nodeName=node-1", exprs: []fieldExpression{
+			{key: "status.phase", operator: "!=", values: []string{"Running"}},
+			{key: "spec.nodeName", operator: "==", values: []string{"node-1"}},
+		}},
+		{selector: "spec.nodeName=", exprs: []fieldExpression{{key: "spec.nodeName", operator: "==", values: []string{""}}}},
+		{selector: "status.phase", err: true},
+		{selector: "=Running", err: true},
+		{selector: "status.phase=Running=Pending", err: true},
+	}
+
+	for _, test := range tests {
+		exprs, err := parseFieldExpressions(test.selector)
+		if test.err {
+			if err == nil {
+				t.Errorf("expected an error for %q, got %+v", test.selector, exprs)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("unexpected error for %q: %v", test.selector, err)
+			continue
+		}
+		if !reflect.DeepEqual(exprs, test.exprs) {
+			t.Errorf("expected %+v for %q, got %+v", test.exprs, test.selector, exprs)
+		}
+	}
+}
+
+func TestFieldSelectorStrings(t *testing.T) {
+	tests := []struct {
+		name      string
+		exprs     []fieldExpression
+		selectors []string
+	}{
+		{"no expressions", []fieldExpression{}, []string{""}},
+		{"single value", []fieldExpression{{key: "status.phase", operator: "==", values: []string{"Running"}}},
+			[]string{"status.phase==Running"}},
+		{"set of values", []fieldExpression{{key: "status.phase", operator: "==", values: []string{"Running", "Pending"}}},
+			[]string{"status.phase==Running", "status.phase==Pending"}},
+		{"excluded values", []fieldExpression{{key: "status.phase", operator: "!=", values: []string{"Failed", "Succeeded"}}},
+			[]string{"status.phase!=Failed,status.phase!=Succeeded"}},
+		{"two sets of values", []fieldExpression{
+			{key: "status.phase", operator: "==", values: []string{"Running", "Pending"}},
+			{key: "spec.nodeName", operator: "==", values: []string{"a", "b"}},
+		}, []string{
+			"status.phase==Running,spec.nodeName==a",
+			"status.phase==Running,spec.nodeName==b",
+			"status.phase==Pending,spec.nodeName==a",
+			"status.phase==Pending,spec.nodeName==b",
+		}},
+		{"set and excluded values", []fieldExpression{
+			{key: "spec.nodeName", operator: "!=", values: []string{"c"}},
+			{key: "status.phase", operator: "==", values: []string{"Running", "Pending"}},
+		}, []string{"spec.nodeName!=c,status.phase==Running", "spec.nodeName!=c,status.phase==Pending"}},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if selectors := fieldSelectorStrings(test.exprs); !reflect.DeepEqual(selectors, test.selectors) {
+				t.Errorf("expected %q, got %q", test.selectors, selectors)
+			}
+		})
+	}
+}
+
+func TestListOptions(t *testing.T) {
+	phase := fieldExpression{key: "status.phase", operator: "==", values: []string{"Running", "Pending"}}
+	image := fieldExpression{key: "spec.containers[*].image", operator: "==", values: []string{"nginx"}}
+	name := fieldExpression{key: "metadata.name", operator: "!=", values: []string{"web"}}
+
+	tests := []struct {
+		name         string
+		kind         string
+		selectors    resourceSelectors
+		listOptions  []metav1.ListOptions
+		clientFields []fieldExpression
+	}{
+		{"labels only", "Pods", resourceSelectors{labelSelector: "app=web"},
+			[]metav1.ListOptions{{LabelSelector: "app=web"}}, []fieldExpression{}},
+		{"supported fields", "Pods", resourceSelectors{labelSelector: "app=web", fields: []fieldExpression{phase, name}},
+			[]metav1.ListOptions{
+				{LabelSelector: "app=web", FieldSelector: "status.phase==Running,metadata.name!=web"},
+				{LabelSelector: "app=web", FieldSelector: "status.phase==Pending,metadata.name!=web"},
+			}, []fieldExpression{}},
+		{"unsupported fields", "Pods", resourceSelectors{fields: []fieldExpression{image, name}},
+			[]metav1.ListOptions{{FieldSelector: "metadata.name!=web"}}, []fieldExpression{image}},
+		{"fields supported by another kind", "Services", resourceSelectors{fields: []fieldExpression{phase}},
+			[]metav1.ListOptions{{}}, []fieldExpression{phase}},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			listOptions, clientFields := test.selectors.listOptions(test.kind)
+			if !reflect.DeepEqual(listOptions, test.listOptions) {
+				t.Errorf("expected list options %+v, got %+v", test.listOptions, listOptions)
+			}
+			if !reflect.DeepEqual(clientFields, test.clientFields) {
+				t.Errorf("expected client fields %+v, got %+v", test.clientFields, clientFields)
+			}
+		})
+	}
+}
+
+func TestMatchFieldExpressions(t *testing.T) {
+	tests := []struct {
+		name  string
+		exprs []fieldExpression
+		match bool
+	}{
+		{"no expressions", nil, true},
+		{"equal", []fieldExpression{{key: "status.phase", operator: "==", values: []string{"Running"}}}, true},
+		{"one of", []fieldExpression{{key: "status.phase", operator: "==", values: []string{"Pending", "Running"}}}, true},
+		{"none of", []fieldExpression{{key: "status.phase", operator: "==", values: []string{"Pending", "Failed"}}}, false},
+		{"not equal", []fieldExpression{{key: "status.phase", operator: "!=", values: []string{"Running"}}}, false},
+		{"not any of", []fieldExpression{{key: "status.phase", operator: "!=", values: []string{"Pending", "Failed"}}}, true},
+		{"any of many results", []fieldExpression{{key: "spec.containers[*].image", operator: "==", values: []string{"envoy:1.5"}}}, true},
+		{"missing field is empty", []fieldExpression{{key: "metadata.labels.missing", operator: "==", values: []string{""}}}, true},
+		{"missing field is not equal", []fieldExpression{{key: "metadata.labels.missing", operator: "!=", values: []string{"x"}}}, true},
+		{"all expressions", []fieldExpression{
+			{key: "status.phase", operator: "==", values: []string{"Running"}},
+			{key: "spec.nodeName", operator: "!=", values: []string{"node-1"}},
+		}, false},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			match, err := matchFieldExpressions(testPod(), test.exprs)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if match != test.match {
+				t.Errorf("expected %v, got %v", test.match, match)
+			}
+		})
+	}
+}
@@ -0,0 +1,911 @@
+package rubykube
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strings"
+	"time"
+
+	mruby "github.com/mitchellh/go-mruby"
+	appsv1 "k8s.io/api/apps/v1"
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+)
+
+var snapshotsDir = flag.String("snapshots", filepath.Join(kubeplayDir, "snapshots"), "directory where snapshots taken by name are kept")
+
+const (
+	// snapshotInfoFile is stored along with the objects, `<kind>/<namespace>/<name>.json`
+	snapshotInfoFile = "snapshot.json"
+	// maxFieldValueLength is how much of a value is shown in a field-level diff
+	maxFieldValueLength = 80
+)
+
+// snapshotNoise are fields that change without anyone changing the object, so these are left out
+// of snapshots; the last applied configuration is a copy of the object itself
+var snapshotNoise = [][]string{
+	{"metadata", "resourceVersion"},
+	{"metadata", "selfLink"},
+	{"metadata", "managedFields"},
+	{"metadata", "generation"},
+	{"metadata", "annotations", "kubectl.kubernetes.io/last-applied-configuration"},
+	{"status", "observedGeneration"},
+}
+
+// snapshotKind takes objects out of list and singleton class instances, along with the query a list
+// was fetched with, loads them back into a list, and lists live objects with a query
+type snapshotKind struct {
+	listClass string
+	itemClass string
+	list      func(rk *RubyKube, v *mruby.MrbValue) (interface{}, *listQuery, error)
+	item      func(rk *RubyKube, v *mruby.MrbValue) (interface{}, error)
+	load      func(rk *RubyKube, data []byte) (*mruby.MrbValue, error)
+	query     func(rk *RubyKube, queries []resourceQuery, selectors *resourceSelectors) (interface{}, error)
+}
+
+var snapshotKinds = map[string]snapshotKind{
+	"pods": {
+		listClass: "Pods",
+		itemClass: "Pod",
+		list: func(rk *RubyKube, v *mruby.MrbValue) (interface{}, *listQuery, error) {
+			vars, err := rk.classes.Pods.LookupVars(v)
+			if err != nil {
+				return nil, nil, err
+			}
+			return vars.pods, vars.query, nil
+		},
+		item: func(rk *RubyKube, v *mruby.MrbValue) (interface{}, error) {
+			vars, err := rk.classes.Pod.LookupVars(v)
+			if err != nil {
+				return nil, err
+			}
+			return podListTypeAlias{Items: []corev1.Pod{vars.pod}}, nil
+		},
+		load: func(rk *RubyKube, data []byte) (*mruby.MrbValue, error) {
+			o, err := rk.classes.Pods.New()
+			if err != nil {
+				return nil, err
+			}
+			return o.self, json.Unmarshal(data, &o.vars.pods)
+		},
+		query: func(rk *RubyKube, queries []resourceQuery, selectors *resourceSelectors) (interface{}, error) {
+			return rk.classes.Pods.getQuery(queries, selectors)
+		},
+	},
+	"services": {
+		listClass: "Services",
+		itemClass: "Service",
+		list: func(rk *RubyKube, v *mruby.MrbValue) (interface{}, *listQuery, error) {
+			vars, err := rk.classes.Services.LookupVars(v)
+			if err != nil {
+				return nil, nil, err
+			}
+			return vars.services, vars.query, nil
+		},
+		item: func(rk *RubyKube, v *mruby.MrbValue) (interface{}, error) {
+			vars, err := rk.classes.Service.LookupVars(v)
+			if err != nil {
+				return nil, err
+			}
+			return serviceListTypeAlias{Items: []corev1.Service{vars.service}}, nil
+		},
+		load: func(rk *RubyKube, data []byte) (*mruby.MrbValue, error) {
+			o, err := rk.classes.Services.New()
+			if err != nil {
+				return nil, err
+			}
+			return o.self, json.Unmarshal(data, &o.vars.services)
+		},
+		query: func(rk *RubyKube, queries []resourceQuery, selectors *resourceSelectors) (interface{}, error) {
+			return rk.classes.Services.getQuery(queries, selectors)
+		},
+	},
+	"deployments": {
+		listClass: "Deployments",
+		itemClass: "Deployment",
+		list: func(rk *RubyKube, v *mruby.MrbValue) (interface{}, *listQuery, error) {
+			vars, err := rk.classes.Deployments.LookupVars(v)
+			if err != nil {
+				return nil, nil, err
+			}
+			return vars.deployments, vars.query, nil
+		},
+		item: func(rk *RubyKube, v *mruby.MrbValue) (interface{}, error) {
+			vars, err := rk.classes.Deployment.LookupVars(v)
+			if err != nil {
+				return nil, err
+			}
+			return deploymentListTypeAlias{Items: []appsv1.Deployment{vars.deployment}}, nil
+		},
+		load: func(rk *RubyKube, data []byte) (*mruby.MrbValue, error) {
+			o, err := rk.classes.Deployments.New()
+			if err != nil {
+				return nil, err
+			}
+			return o.self, json.Unmarshal(data, &o.vars.deployments)
+		},
+		query: func(rk *RubyKube, queries []resourceQuery, selectors *resourceSelectors) (interface{}, error) {
+			return rk.classes.Deployments.getQuery(queries, selectors)
+		},
+	},
+	"replicasets": {
+		listClass: "ReplicaSets",
+		itemClass: "ReplicaSet",
+		list: func(rk *RubyKube, v *mruby.MrbValue) (interface{}, *listQuery, error) {
+			vars, err := rk.classes.ReplicaSets.LookupVars(v)
+			if err != nil {
+				return nil, nil, err
+			}
+			return vars.replicaSets, vars.query, nil
+		},
+		item: func(rk *RubyKube, v *mruby.MrbValue) (interface{}, error) {
+			vars, err := rk.classes.ReplicaSet.LookupVars(v)
+			if err != nil {
+				return nil, err
+			}
+			return replicaSetListTypeAlias{Items: []appsv1.ReplicaSet{appsv1.ReplicaSet(vars.replicaSet)}}, nil
+		},
+		load: func(rk *RubyKube, data []byte) (*mruby.MrbValue, error) {
+			o, err := rk.classes.ReplicaSets.New()
+			if err != nil {
+				return nil, err
+			}
+			return o.self, json.Unmarshal(data, &o.vars.replicaSets)
+		},
+		query: func(rk *RubyKube, queries []resourceQuery, selectors *resourceSelectors) (interface{}, error) {
+			return rk.classes.ReplicaSets.getQuery(queries, selectors)
+		},
+	},
+	"daemonsets": {
+		listClass: "DaemonSets",
+		itemClass: "DaemonSet",
+		list: func(rk *RubyKube, v *mruby.MrbValue) (interface{}, *listQuery, error) {
+			vars, err := rk.classes.DaemonSets.LookupVars(v)
+			if err != nil {
+				return nil, nil, err
+			}
+			return vars.daemonSets, vars.query, nil
+		},
+		item: func(rk *RubyKube, v *mruby.MrbValue) (interface{}, error) {
+			vars, err := rk.classes.DaemonSet.LookupVars(v)
+			if err != nil {
+				return nil, err
+			}
+			return daemonSetListTypeAlias{Items: []appsv1.DaemonSet{vars.daemonSet}}, nil
+		},
+		load: func(rk *RubyKube, data []byte) (*mruby.MrbValue, error) {
+			o, err := rk.classes.DaemonSets.New()
+			if err != nil {
+				return nil, err
+			}
+			return o.self, json.Unmarshal(data, &o.vars.daemonSets)
+		},
+		query: func(rk *RubyKube, queries []resourceQuery, selectors *resourceSelectors) (interface{}, error) {
+			return rk.classes.DaemonSets.getQuery(queries, selectors)
+		},
+	},
+	"jobs": {
+		listClass: "Jobs",
+		itemClass: "Job",
+		list: func(rk *RubyKube, v *mruby.MrbValue) (interface{}, *listQuery, error) {
+			vars, err := rk.classes.Jobs.LookupVars(v)
+			if err != nil {
+				return nil, nil, err
+			}
+			return vars.jobs, vars.query, nil
+		},
+		item: func(rk *RubyKube, v *mruby.MrbValue) (interface{}, error) {
+			vars, err := rk.classes.Job.LookupVars(v)
+			if err != nil {
+				return nil, err
+			}
+			return jobListTypeAlias{Items: []batchv1.Job{vars.job}}, nil
+		},
+		load: func(rk *RubyKube, data []byte) (*mruby.MrbValue, error) {
+			o, err := rk.classes.Jobs.New()
+			if err != nil {
+				return nil, err
+			}
+			return o.self, json.Unmarshal(data, &o.vars.jobs)
+		},
+		query: func(rk *RubyKube, queries []resourceQuery, selectors *resourceSelectors) (interface{}, error) {
+			return rk.classes.Jobs.getQuery(queries, selectors)
+		},
+	},
+}
+
+type snapshotInfo struct {
+	Time    time.Time `json:"time"`
+	Context string    `json:"context,omitempty"`
+	// Queries are what lists of each kind were fetched with, objects taken one by one are queried by
+	// name; live objects are listed with the same queries when a snapshot is compared with current state
+	Queries map[string][]listQuery `json:"queries"`
+}
+
+// snapshot holds normalised objects by kind and `<namespace>/<name>`
+type snapshot struct {
+	name    string
+	info    snapshotInfo
+	objects map[string]map[string]map[string]interface{}
+}
+
+func newSnapshot(name string, info snapshotInfo) *snapshot {
+	if info.Queries == nil {
+		info.Queries = map[string][]listQuery{}
+	}
+	return &snapshot{
+		name:    name,
+		info:    info,
+		objects: map[string]map[string]map[string]interface{}{},
+	}
+}
+
+func (s *snapshot) describe() string {
+	if s.info.Time.IsZero() {
+		return s.name
+	}
+	return fmt.Sprintf("%s (taken %s in context %q)", s.name, s.info.Time.Format(time.RFC3339), s.info.Context)
+}
+
+func deleteField(object map[string]interface{}, field []string) {
+	for _, key := range field[:len(field)-1] {
+		next, ok := object[key].(map[string]interface{})
+		if !ok {
+			return
+		}
+		object = next
+	}
+	delete(object, field[len(field)-1])
+}
+
+// addList normalises items of a typed list, e.g. `corev1.PodList`, and adds these to the snapshot,
+// it returns `<namespace>/<name>` of each item
+func (s *snapshot) addList(kind string, list interface{}) ([]string, error) {
+	data, err := json.Marshal(list)
+	if err != nil {
+		return nil, err
+	}
+	items := struct {
+		Items []map[string]interface{} `json:"items"`
+	}{}
+	if err := json.Unmarshal(data, &items); err != nil {
+		return nil, err
+	}
+
+	keys := []string{}
+	for _, object := range items.Items {
+		for _, field := range snapshotNoise {
+			deleteField(object, field)
+		}
+		keys = append(keys, s.add(kind, object))
+	}
+	return keys, nil
+}
+
+func (s *snapshot) add(kind string, object map[string]interface{}) string {
+	meta, _ := object["metadata"].(map[string]interface{})
+	ns, _ := meta["namespace"].(string)
+	name, _ := meta["name"].(string)
+
+	if s.objects[kind] == nil {
+		s.objects[kind] = map[string]map[string]interface{}{}
+	}
+	s.objects[kind][ns+"/"+name] = object
+	return ns + "/" + name
+}
+
+func (s *snapshot) count() int {
+	n := 0
+	for _, objects := range s.objects {
+		n += len(objects)
+	}
+	return n
+}
+
+// files returns contents of the snapshot by path
+func (s *snapshot) files() (map[string][]byte, error) {
+	files := map[string][]byte{}
+
+	data, err := json.MarshalIndent(s.info, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	files[snapshotInfoFile] = append(data, '\n')
+
+	for kind, objects := range s.objects {
+		for key, object := range objects {
+			data, err := json.MarshalIndent(object, "", "  ")
+			if err != nil {
+				return nil, err
+			}
+			files[path.Join(kind, key+".json")] = append(data, '\n')
+		}
+	}
+	return files, nil
+}
+
+func parseSnapshot(name string, files map[string][]byte) (*snapshot, error) {
+	data, ok := files[snapshotInfoFile]
+	if !ok {
+		return nil, fmt.Errorf("%q is not a snapshot – %s is missing", name, snapshotInfoFile)
+	}
+	info := snapshotInfo{}
+	if err := json.Unmarshal(data, &info); err != nil {
+		return nil, fmt.Errorf("could not parse %s of %q – %v", snapshotInfoFile, name, err)
+	}
+
+	s := newSnapshot(name, info)
+	for p, data := range files {
+		parts := strings.Split(strings.TrimSuffix(p, ".json"), "/")
+		if p == snapshotInfoFile || len(parts) != 3 || !strings.HasSuffix(p, ".json") {
+			continue
+		}
+		object := map[string]interface{}{}
+		if err := json.Unmarshal(data, &object); err != nil {
+			return nil, fmt.Errorf("could not parse %s of %q – %v", p, name, err)
+		}
+		s.add(parts[0], object)
+	}
+	return s, nil
+}
+
+func isTarball(p string) bool {
+	return strings.HasSuffix(p, ".tar.gz") || strings.HasSuffix(p, ".tgz")
+}
+
+// snapshotPath returns path of a snapshot, a name without slashes refers to a directory
+// in `-snapshots` directory
+func snapshotPath(name string) string {
+	if isTarball(name) || strings.Contains(name, string(filepath.Separator)) {
+		return name
+	}
+	return filepath.Join(*snapshotsDir, name)
+}
+
+func sortedFileNames(files map[string][]byte) []string {
+	names := []string{}
+	for name := range files {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// writeSnapshot writes files to a tarball or a directory, an existing snapshot of the same name is replaced,
+// but anything else is not
+func writeSnapshot(p string, files map[string][]byte) error {
+	if isTarball(p) {
+		return writeTarball(p, files)
+	}
+
+	if _, err := os.Stat(p); err == nil {
+		if _, err := os.Stat(filepath.Join(p, snapshotInfoFile)); err != nil {
+			return fmt.Errorf("%q exists and is not a snapshot", p)
+		}
+		if err := os.RemoveAll(p); err != nil {
+			return err
+		}
+	}
+
+	for _, name := range sortedFileNames(files) {
+		file := filepath.Join(p, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(file), 0700); err != nil {
+			return err
+		}
+		if err := ioutil.WriteFile(file, files[name], 0600); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func writeTarball(p string, files map[string][]byte) error {
+	if dir := filepath.Dir(p); dir != "" {
+		if err := os.MkdirAll(dir, 0700); err != nil {
+			return err
+		}
+	}
+
+	buf := &bytes.Buffer{}
+	gz := gzip.NewWriter(buf)
+	tw := tar.NewWriter(gz)
+	now := time.Now()
+	for _, name := range sortedFileNames(files) {
+		header := &tar.Header{
+			Name:     name,
+			Mode:     0600,
+			Size:     int64(len(files[name])),
+			ModTime:  now,
+			Typeflag: tar.TypeReg,
+		}
+		if err := tw.WriteHeader(header); err != nil {
+			return err
+		}
+		if _, err := tw.Write(files[name]); err != nil {
+			return err
+		}
+	}
+	if err := tw.Close(); err != nil {
+		return err
+	}
+	if err := gz.Close(); err != nil {
+		return err
+	}
+	return ioutil.WriteFile(p, buf.Bytes(), 0600)
+}
+
+// readSnapshot reads files of a snapshot from a tarball or a directory
+func readSnapshot(p string) (map[string][]byte, error) {
+	files := map[string][]byte{}
+
+	if isTarball(p) {
+		f, err := os.Open(p)
+		if err != nil {
+			return nil, err
+		}
+		defer f.Close()
+
+		gz, err := gzip.NewReader(f)
+		if err != nil {
+			return nil, fmt.Errorf("could not read %q – %v", p, err)
+		}
+		tr := tar.NewReader(gz)
+		for {
+			header, err := tr.Next()
+			if err == io.EOF {
+				break
+			}
+			if err != nil {
+				return nil, fmt.Errorf("could not read %q – %v", p, err)
+			}
+			if header.Typeflag != tar.TypeReg {
+				continue
+			}
+			data, err := ioutil.ReadAll(tr)
+			if err != nil {
+				return nil, fmt.Errorf("could not read %q – %v", p, err)
+			}
+			files[strings.TrimPrefix(header.Name, "./")] = data
+		}
+		return files, nil
+	}
+
+	if err := filepath.Walk(p, func(file string, info os.FileInfo, err error) error {
+		if err != nil || !info.Mode().IsRegular() {
+			return err
+		}
+		rel, err := filepath.Rel(p, file)
+		if err != nil {
+			return err
+		}
+		data, err := ioutil.ReadFile(file)
+		if err != nil {
+			return err
+		}
+		files[filepath.ToSlash(rel)] = data
+		return nil
+	}); err != nil {
+		return nil, err
+	}
+	return files, nil
+}
+
+func loadSnapshot(name string) (*snapshot, error) {
+	files, err := readSnapshot(snapshotPath(name))
+	if err != nil {
+		return nil, err
+	}
+	return parseSnapshot(name, files)
+}
+
+// snapshotOf takes objects out of list and singleton class instances, e.g. `pods("prod/")`
+// or `pods("prod/")[0]`
+func (rk *RubyKube) snapshotOf(name string, values []*mruby.MrbValue) (*snapshot, error) {
+	s := newSnapshot(name, snapshotInfo{Time: time.Now().UTC(), Context: rk.state.Context})
+
+	for _, v := range values {
+		class, err := v.Call("class")
+		if err != nil {
+			return nil, err
+		}
+		className := class.String()
+
+		found := false
+		for kindName, kind := range snapshotKinds {
+			var (
+				list  interface{}
+				query *listQuery
+			)
+			switch className {
+			case kind.listClass:
+				list, query, err = kind.list(rk, v)
+			case kind.itemClass:
+				list, err = kind.item(rk, v)
+			default:
+				continue
+			}
+			if err != nil {
+				return nil, err
+			}
+			keys, err := s.addList(kindName, list)
+			if err != nil {
+				return nil, err
+			}
+			if query == nil {
+				query = queryOf(className == kind.itemClass, keys)
+			}
+			s.info.Queries[kindName] = append(s.info.Queries[kindName], *query)
+			found = true
+			break
+		}
+		if !found {
+			return nil, newArgumentError("cannot take a snapshot of %s – only lists and objects of %s are supported", className, supportedSnapshotKinds())
+		}
+	}
+	return s, nil
+}
+
+func supportedSnapshotKinds() string {
+	kinds := []string{}
+	for kindName := range snapshotKinds {
+		kinds = append(kinds, kindName)
+	}
+	sort.Strings(kinds)
+	return "[" + strings.Join(kinds, " ") + "]"
+}
+
+// queryOf makes a query for objects that didn't come from a list fetched with `get!`, an object is
+// queried by name, and a list made in some other way (e.g. with `where`) by namespaces of its items
+func queryOf(item bool, keys []string) *listQuery {
+	q := &listQuery{Globs: []string{}}
+	seen := map[string]bool{}
+	for _, key := range keys {
+		glob := key
+		if !item {
+			glob = strings.SplitN(key, "/", 2)[0] + "/"
+		}
+		if !seen[glob] {
+			seen[glob] = true
+			q.Globs = append(q.Globs, glob)
+		}
+	}
+	return q
+}
+
+// currentState lists live objects with the same queries as objects in the snapshot were taken with
+func (rk *RubyKube) currentState(s *snapshot) (*snapshot, error) {
+	kinds := []string{}
+	for kind := range s.info.Queries {
+		kinds = append(kinds, kind)
+	}
+	sort.Strings(kinds)
+
+	current := newSnapshot("current state", snapshotInfo{})
+	for _, kindName := range kinds {
+		kind, ok := snapshotKinds[kindName]
+		if !ok {
+			continue
+		}
+		// lists of each query are fetched concurrently already
+		for _, lq := range s.info.Queries[kindName] {
+			queries, selectors, err := lq.parse()
+			if err != nil {
+				return nil, fmt.Errorf("invalid query of %s in %s – %v", kindName, snapshotInfoFile, err)
+			}
+			list, err := kind.query(rk, queries, selectors)
+			if err != nil {
+				return nil, err
+			}
+			if _, err := current.addList(kindName, list); err != nil {
+				return nil, err
+			}
+		}
+	}
+	return current, nil
+}
+
+// objectChange refers to an object that was added, removed or changed, fields are set for
+// changed objects only
+type objectChange struct {
+	Kind   string   `json:"kind"`
+	Object string   `json:"object"`
+	Fields []string `json:"fields,omitempty"`
+}
+
+type snapshotDiff struct {
+	Before  string         `json:"before"`
+	After   string         `json:"after"`
+	Added   []objectChange `json:"added"`
+	Removed []objectChange `json:"removed"`
+	Changed []objectChange `json:"changed"`
+}
+
+func (d *snapshotDiff) empty() bool {
+	return len(d.Added) == 0 && len(d.Removed) == 0 && len(d.Changed) == 0
+}
+
+func (d *snapshotDiff) String() string {
+	text := bytes.Buffer{}
+	fmt.Fprintf(&text, "Comparing %s with %s\n", d.Before, d.After)
+	if d.empty() {
+		fmt.Fprintln(&text, "No differences")
+		return text.String()
+	}
+	for _, c := range d.Added {
+		fmt.Fprintf(&text, "+ %s %s\n", c.Kind, c.Object)
+	}
+	for _, c := range d.Removed {
+		fmt.Fprintf(&text, "- %s %s\n", c.Kind, c.Object)
+	}
+	for _, c := range d.Changed {
+		fmt.Fprintf(&text, "~ %s %s\n", c.Kind, c.Object)
+		for _, field := range c.Fields {
+			fmt.Fprintf(&text, "    %s\n", field)
+		}
+	}
+	return text.String()
+}
+
+func sortedObjectKeys(objects map[string]map[string]interface{}) []string {
+	keys := []string{}
+	for key := range objects {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+func diffSnapshots(before, after *snapshot) *snapshotDiff {
+	d := &snapshotDiff{
+		Before:  before.describe(),
+		After:   after.describe(),
+		Added:   []objectChange{},
+		Removed: []objectChange{},
+		Changed: []objectChange{},
+	}
+
+	kinds := []string{}
+	for kind := range before.objects {
+		kinds = append(kinds, kind)
+	}
+	for kind := range after.objects {
+		if _, ok := before.objects[kind]; !ok {
+			kinds = append(kinds, kind)
+		}
+	}
+	sort.Strings(kinds)
+
+	for _, kind := range kinds {
+		for _, key := range sortedObjectKeys(before.objects[kind]) {
+			object, ok := after.objects[kind][key]
+			if !ok {
+				d.Removed = append(d.Removed, objectChange{Kind: kind, Object: key})
+				continue
+			}
+			if fields := diffFields("", before.objects[kind][key], object); len(fields) > 0 {
+				d.Changed = append(d.Changed, objectChange{Kind: kind, Object: key, Fields: fields})
+			}
+		}
+		for _, key := range sortedObjectKeys(after.objects[kind]) {
+			if _, ok := before.objects[kind][key]; !ok {
+				d.Added = append(d.Added, objectChange{Kind: kind, Object: key})
+			}
+		}
+	}
+	return d
+}
+
+// fieldPath appends a key to a dotted path, keys such as annotations and labels may have dots
+// and slashes in them, so these are quoted
+func fieldPath(p, key string) string {
+	if strings.ContainsAny(key, "./") {
+		return fmt.Sprintf("%s[%q]", p, key)
+	}
+	if p == "" {
+		return key
+	}
+	return p + "." + key
+}
+
+func formatFieldValue(value interface{}) string {
+	data, err := json.Marshal(value)
+	if err != nil {
+		return fmt.Sprintf("%v", value)
+	}
+	if len(data) > maxFieldValueLength {
+		return string(data[:maxFieldValueLength]) + "..."
+	}
+	return string(data)
+}
+
+// diffFields compares two values parsed from JSON, arrays of the same length are compared item by
+// item, otherwise the whole array is shown
+func diffFields(p string, before, after interface{}) []string {
+	switch b := before.(type) {
+	case map[string]interface{}:
+		a, ok := after.(map[string]interface{})
+		if !ok {
+			break
+		}
+
+		keys := []string{}
+		for key := range b {
+			keys = append(keys, key)
+		}
+		for key := range a {
+			if _, ok := b[key]; !ok {
+				keys = append(keys, key)
+			}
+		}
+		sort.Strings(keys)
+
+		fields := []string{}
+		for _, key := range keys {
+			beforeValue, inBefore := b[key]
+			afterValue, inAfter := a[key]
+			switch {
+			case !inAfter:
+				fields = append(fields, fmt.Sprintf("%s: removed %s", fieldPath(p, key), formatFieldValue(beforeValue)))
+			case !inBefore:
+				fields = append(fields, fmt.Sprintf("%s: added %s", fieldPath(p, key), formatFieldValue(afterValue)))
+			default:
+				fields = append(fields, diffFields(fieldPath(p, key), beforeValue, afterValue)...)
+			}
+		}
+		return fields
+	case []interface{}:
+		a, ok := after.([]interface{})
+		if !ok || len(a) != len(b) {
+			break
+		}
+
+		fields := []string{}
+		for i := range b {
+			fields = append(fields, diffFields(fmt.Sprintf("%s[%d]", p, i), b[i], a[i])...)
+		}
+		return fields
+	}
+
+	if reflect.DeepEqual(before, after) {
+		return nil
+	}
+	return []string{fmt.Sprintf("%s: %s → %s", p, formatFieldValue(before), formatFieldValue(after))}
+}
+
+func snapshotName(args []*mruby.MrbValue) (string, error) {
+	if len(args) == 0 || args[0].Type() != mruby.TypeString || args[0].String() == "" {
+		return "", newArgumentError("Snapshot name must be a non-empty string")
+	}
+	return args[0].String(), nil
+}
+
+// snapshotVerb implements `snapshot` verb, e.g. `snapshot "before", pods("prod/"), deployments("prod/")`,
+// it returns path of the snapshot
+func snapshotVerb(rk *RubyKube, args []*mruby.MrbValue, m *mruby.Mrb, self *mruby.MrbValue) (mruby.Value, mruby.Value) {
+	name, err := snapshotName(args)
+	if err != nil {
+		return nil, createError(m, err)
+	}
+	if len(args) < 2 {
+		return nil, createException(m, "Nothing to take a snapshot of – pass lists or objects, e.g. `snapshot \"before\", pods(\"prod/\")`")
+	}
+
+	s, err := rk.snapshotOf(name, args[1:])
+	if err != nil {
+		return nil, createError(m, err)
+	}
+
+	files, err := s.files()
+	if err != nil {
+		return nil, createError(m, err)
+	}
+	p := snapshotPath(name)
+	if err := writeSnapshot(p, files); err != nil {
+		return nil, createError(m, err)
+	}
+
+	fmt.Printf("Saved %d objects to %s\n", s.count(), p)
+	return m.StringValue(p), nil
+}
+
+// compareSnapshot implements `compare_snapshot` verb, a snapshot is compared with current state
+// of the same namespaces, with another snapshot, or with the given lists and objects
+func compareSnapshot(rk *RubyKube, args []*mruby.MrbValue, m *mruby.Mrb, self *mruby.MrbValue) (mruby.Value, mruby.Value) {
+	name, err := snapshotName(args)
+	if err != nil {
+		return nil, createError(m, err)
+	}
+
+	before, err := loadSnapshot(name)
+	if err != nil {
+		return nil, createError(m, err)
+	}
+
+	var after *snapshot
+	switch {
+	case len(args) == 1:
+		after, err = rk.currentState(before)
+	case len(args) == 2 && args[1].Type() == mruby.TypeString:
+		after, err = loadSnapshot(args[1].String())
+	default:
+		if after, err = rk.snapshotOf("given objects", args[1:]); err == nil {
+			after.info.Time = time.Time{}
+		}
+	}
+	if err != nil {
+		return nil, createError(m, err)
+	}
+
+	newDiffObj, err := rk.classes.SnapshotDiff.New()
+	if err != nil {
+		return nil, createError(m, err)
+	}
+	newDiffObj.vars.diff = diffSnapshots(before, after)
+	return newDiffObj.self, nil
+}
+
+// loadSnapshotVerb implements `load_snapshot` verb, which returns a hash of lists by kind, lists
+// work the same way as they do with a cluster, until these are refreshed with `get!`
+func loadSnapshotVerb(rk *RubyKube, args []*mruby.MrbValue, m *mruby.Mrb, self *mruby.MrbValue) (mruby.Value, mruby.Value) {
+	name, err := snapshotName(args)
+	if err != nil {
+		return nil, createError(m, err)
+	}
+
+	s, err := loadSnapshot(name)
+	if err != nil {
+		return nil, createError(m, err)
+	}
+
+	hash, err := m.LoadString("{}")
+	if err != nil {
+		return nil, createError(m, err)
+	}
+	// lists are made while the hash is only referenced from Go
+	if err := protectValue(m, hash); err != nil {
+		return nil, createError(m, err)
+	}
+	defer unprotectValue(m, hash)
+
+	kinds := []string{}
+	for kind := range s.objects {
+		kinds = append(kinds, kind)
+	}
+	sort.Strings(kinds)
+
+	for _, kindName := range kinds {
+		kind, ok := snapshotKinds[kindName]
+		if !ok {
+			continue
+		}
+
+		items := []map[string]interface{}{}
+		for _, key := range sortedObjectKeys(s.objects[kindName]) {
+			items = append(items, s.objects[kindName][key])
+		}
+		data, err := json.Marshal(map[string]interface{}{"items": items})
+		if err != nil {
+			return nil, createError(m, err)
+		}
+
+		list, err := kind.load(rk, data)
+		if err != nil {
+			return nil, createError(m, err)
+		}
+		if err := hash.Hash().Set(m.StringValue(kindName), list); err != nil {
+			return nil, createError(m, err)
+		}
+	}
+	return hash, nil
+}
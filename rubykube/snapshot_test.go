@@ -0,0 +1,105 @@
+package rubykube
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestListQuery(t *testing.T) {
+	tests := []struct {
+		name      string
+		namespace string
+		globs     []string
+		expected  []string
+	}{
+		{"current namespace", "prod", []string{"web-*"}, []string{"prod/web-*"}},
+		{"all namespaces mode", "*", []string{"web-*"}, []string{"*/web-*"}},
+		{"namespace is kept", "dev", []string{"prod/"}, []string{"prod/"}},
+		{"namespace glob is kept", "dev", []string{"team-*/api-?"}, []string{"team-*/api-?"}},
+		{"all namespaces", "dev", []string{"*/"}, []string{"*/"}},
+		{"multiple globs", "dev", []string{"web", "prod/db-*"}, []string{"dev/web", "prod/db-*"}},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			rk := &RubyKube{state: &CurrentState{Namespace: test.namespace}}
+
+			queries := []resourceQuery{}
+			for _, glob := range test.globs {
+				q, err := newResourceQuery(glob)
+				if err != nil {
+					t.Fatalf("unexpected error: %v", err)
+				}
+				queries = append(queries, *q)
+			}
+			selectors := &resourceSelectors{
+				labelSelector: "app=web",
+				fields:        []fieldExpression{{key: "status.phase", operator: "==", values: []string{"Running", "Pending"}}},
+			}
+
+			lq := rk.newListQuery(queries, selectors)
+			if !reflect.DeepEqual(lq.Globs, test.expected) {
+				t.Errorf("expected globs %v, got %v", test.expected, lq.Globs)
+			}
+
+			// the query must select the same objects in another namespace
+			rk.state.Namespace = "other"
+			parsedQueries, parsedSelectors, err := lq.parse()
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if !reflect.DeepEqual(parsedSelectors, selectors) {
+				t.Errorf("expected selectors %+v, got %+v", selectors, parsedSelectors)
+			}
+			if again := rk.newListQuery(parsedQueries, parsedSelectors); !reflect.DeepEqual(again, lq) {
+				t.Errorf("expected the same query after parsing, got %+v instead of %+v", again, lq)
+			}
+		})
+	}
+}
+
+func TestQueryOf(t *testing.T) {
+	keys := []string{"prod/web-a", "prod/web-b", "dev/web-a"}
+
+	if expected, actual := []string{"prod/web-a", "prod/web-b", "dev/web-a"}, queryOf(true, keys).Globs; !reflect.DeepEqual(actual, expected) {
+		t.Errorf("expected globs %v for items, got %v", expected, actual)
+	}
+	if expected, actual := []string{"prod/", "dev/"}, queryOf(false, keys).Globs; !reflect.DeepEqual(actual, expected) {
+		t.Errorf("expected globs %v for a list, got %v", expected, actual)
+	}
+	if actual := queryOf(false, nil).Globs; len(actual) != 0 {
+		t.Errorf("expected no globs for an empty list, got %v", actual)
+	}
+}
+
+func TestDiffFields(t *testing.T) {
+	tests := []struct {
+		name          string
+		before, after interface{}
+		fields        []string
+	}{
+		{"same", map[string]interface{}{"a": "x"}, map[string]interface{}{"a": "x"}, nil},
+		{"changed", map[string]interface{}{"a": "x"}, map[string]interface{}{"a": "y"}, []string{`a: "x" → "y"`}},
+		{"added", map[string]interface{}{}, map[string]interface{}{"a": 1.0}, []string{"a: added 1"}},
+		{"removed", map[string]interface{}{"a": true}, map[string]interface{}{}, []string{"a: removed true"}},
+		{"nested key with dots", map[string]interface{}{"labels": map[string]interface{}{"app.kubernetes.io/name": "a"}},
+			map[string]interface{}{"labels": map[string]interface{}{"app.kubernetes.io/name": "b"}},
+			[]string{`labels["app.kubernetes.io/name"]: "a" → "b"`}},
+		{"array items", map[string]interface{}{"c": []interface{}{"a", "b"}}, map[string]interface{}{"c": []interface{}{"a", "c"}},
+			[]string{`c[1]: "b" → "c"`}},
+		{"array length", map[string]interface{}{"c": []interface{}{"a"}}, map[string]interface{}{"c": []interface{}{"a", "b"}},
+			[]string{`c: ["a"] → ["a","b"]`}},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			fields := diffFields("", test.before, test.after)
+			if len(fields) == 0 && len(test.fields) == 0 {
+				return
+			}
+			if !reflect.DeepEqual(fields, test.fields) {
+				t.Errorf("expected %q, got %q", test.fields, fields)
+			}
+		})
+	}
+}
@@ -1,8 +1,6 @@
 package basic
 
 import (
-	"fmt"
-
 	mruby "github.com/mitchellh/go-mruby"
 )
 
@@ -14,7 +12,7 @@ type classInstanceVarsType int
 
 type RubyKubeClass struct {
 	class   *mruby.Class
-	objects []RubyKubeClassInstance
+	objects *instanceRegistry
 	rk      *RubyKube
 }
 
@@ -24,7 +22,7 @@ type RubyKubeClassInstance struct {
 }
 
 func NewRubyKubeClass(rk *RubyKube) *RubyKubeClass {
-	c := &RubyKubeClass{objects: []RubyKubeClassInstance{}, rk: rk}
+	c := &RubyKubeClass{objects: rk.newInstanceRegistry(classNameString), rk: rk}
 	c.class = DefineRubyKubeClass(rk, c)
 	return c
 }
@@ -32,11 +30,11 @@ func NewRubyKubeClass(rk *RubyKube) *RubyKubeClass {
 func DefineRubyKubeClass(rk *RubyKube, c *RubyKubeClass) *mruby.Class {
 	// common methods
 	return rk.defineClass(classNameString, map[string]methodDefintion{
-		"object_count": {
-			mruby.ArgsNone(), func(m *mruby.Mrb, self *mruby.MrbValue) (mruby.Value, mruby.Value) {
-				return m.FixnumValue(len(c.objects)), nil
+		"inspect": {
+			mruby.ArgsReq(0), func(m *mruby.Mrb, self *mruby.MrbValue) (mruby.Value, mruby.Value) {
+				return m.StringValue("#<" + classNameString + ">"), nil
 			},
-			classMethod,
+			instanceMethod,
 		},
 	})
 }
@@ -52,19 +50,18 @@ func (c *RubyKubeClass) New(args ...mruby.Value) (*RubyKubeClassInstance, error)
 		return nil, err
 	}
 
-	o := RubyKubeClassInstance{
+	o := &RubyKubeClassInstance{
 		self: s,
 		vars: v,
 	}
-	c.objects = append(c.objects, o)
-	return &o, nil
+	c.objects.add(s, o)
+	return o, nil
 }
 
 func (c *RubyKubeClass) LookupVars(this *mruby.MrbValue) (*classInstanceVarsType, error) {
-	for _, that := range c.objects {
-		if *this == *that.self {
-			return that.vars, nil
-		}
+	o, err := c.objects.lookup(this)
+	if err != nil {
+		return nil, err
 	}
-	return nil, fmt.Errorf("%s: could not find class instance", classNameString)
+	return o.(*RubyKubeClassInstance).vars, nil
 }
@@ -2,7 +2,7 @@ package resourcepodfinder
 
 import (
 	mruby "github.com/mitchellh/go-mruby"
-	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	corev1 "k8s.io/api/core/v1"
 )
 
 // template type RubyKubeClass(parentClass, classNameString, instanceVariableName, instanceVariableType)
@@ -14,29 +14,28 @@ type classNameString string
 type instanceVariableName int
 type instanceVariableType int
 
+// findPods lists pods that `pods` returns, so other methods can use them without a Ruby object
+func (c *parentClass) findPods(obj instanceVariableType) (*corev1.PodList, error) {
+	return c.rk.findPods(obj)
+}
+
 func (c *parentClass) definePodFinderMethods() {
 	c.rk.appendMethods(c.class, map[string]methodDefintion{
 		"pods": {
 			mruby.ArgsNone(), func(m *mruby.Mrb, self *mruby.MrbValue) (mruby.Value, mruby.Value) {
 				vars, err := c.LookupVars(self)
 				if err != nil {
-					return nil, createException(m, err.Error())
+					return nil, createError(m, err)
 				}
 
-				ns := vars.instanceVariableName.ObjectMeta.Namespace
-
-				listOptions := metav1.ListOptions{LabelSelector: metav1.FormatLabelSelector(vars.deployment.Spec.Selector)}
-
-				pods, err := c.rk.clientset.Core().Pods(ns).List(listOptions)
+				pods, err := c.findPods(vars.instanceVariableName)
 				if err != nil {
-					return nil, createException(m, err.Error())
+					return nil, createError(m, err)
 				}
 
-				// TODO: verify `ownerReferences`...
-
 				newPodsObj, err := c.rk.classes.Pods.New()
 				if err != nil {
-					return nil, createException(m, err.Error())
+					return nil, createError(m, err)
 				}
 				newPodsObj.vars.pods = podListTypeAlias(*pods)
 				return newPodsObj.self, nil
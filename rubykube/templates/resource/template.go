@@ -1,8 +1,6 @@
 package resource
 
 import (
-	"fmt"
-
 	"github.com/errordeveloper/kubeplay/rubykube/converter"
 	mruby "github.com/mitchellh/go-mruby"
 )
@@ -15,7 +13,7 @@ type instanceVariableType int
 
 type RubyKubeClass struct {
 	class   *mruby.Class
-	objects []RubyKubeClassInstance
+	objects *instanceRegistry
 	rk      *RubyKube
 }
 
@@ -26,10 +24,12 @@ type RubyKubeClassInstance struct {
 
 type RubyKubeClassInstanceVars struct {
 	instanceVariableName instanceVariableType
+	// query is what a list was fetched with by `get!`, it's nil for singletons and lists made in other ways
+	query *listQuery
 }
 
 func NewRubyKubeClass(rk *RubyKube) *RubyKubeClass {
-	c := &RubyKubeClass{objects: []RubyKubeClassInstance{}, rk: rk}
+	c := &RubyKubeClass{objects: rk.newInstanceRegistry(classNameString), rk: rk}
 	c.class = DefineRubyKubeClass(rk, c)
 	return c
 }
@@ -41,12 +41,12 @@ func DefineRubyKubeClass(rk *RubyKube, c *RubyKubeClass) *mruby.Class {
 			mruby.ArgsReq(0), func(m *mruby.Mrb, self *mruby.MrbValue) (mruby.Value, mruby.Value) {
 				vars, err := c.LookupVars(self)
 				if err != nil {
-					return nil, createException(m, err.Error())
+					return nil, createError(m, err)
 				}
 
 				rbconv := converter.New(m)
 				if err := rbconv.Convert(vars.instanceVariableName); err != nil {
-					return nil, createException(m, err.Error())
+					return nil, createError(m, err)
 				}
 
 				return rbconv.Value(), nil
@@ -57,17 +57,21 @@ func DefineRubyKubeClass(rk *RubyKube, c *RubyKubeClass) *mruby.Class {
 			mruby.ArgsReq(0), func(m *mruby.Mrb, self *mruby.MrbValue) (mruby.Value, mruby.Value) {
 				vars, err := c.LookupVars(self)
 				if err != nil {
-					return nil, createException(m, err.Error())
+					return nil, createError(m, err)
 				}
 				return marshalToJSON(vars.instanceVariableName, m)
 			},
 			instanceMethod,
 		},
-		"object_count": {
-			mruby.ArgsNone(), func(m *mruby.Mrb, self *mruby.MrbValue) (mruby.Value, mruby.Value) {
-				return m.FixnumValue(len(c.objects)), nil
+		"jsonpath": {
+			mruby.ArgsReq(1), func(m *mruby.Mrb, self *mruby.MrbValue) (mruby.Value, mruby.Value) {
+				vars, err := c.LookupVars(self)
+				if err != nil {
+					return nil, createError(m, err)
+				}
+				return jsonPathMethod(m, &vars.instanceVariableName)
 			},
-			classMethod,
+			instanceMethod,
 		},
 	})
 }
@@ -77,21 +81,25 @@ func (c *RubyKubeClass) New() (*RubyKubeClassInstance, error) {
 	if err != nil {
 		return nil, err
 	}
-	o := RubyKubeClassInstance{
+	o := &RubyKubeClassInstance{
 		self: s,
 		vars: &RubyKubeClassInstanceVars{
-			instanceVariableType{},
+			instanceVariableName: instanceVariableType{},
 		},
 	}
-	c.objects = append(c.objects, o)
-	return &o, nil
+	c.objects.add(s, o)
+	return o, nil
 }
 
 func (c *RubyKubeClass) LookupVars(this *mruby.MrbValue) (*RubyKubeClassInstanceVars, error) {
-	for _, that := range c.objects {
-		if *this == *that.self {
-			return that.vars, nil
-		}
+	o, err := c.objects.lookup(this)
+	if err != nil {
+		return nil, err
 	}
-	return nil, fmt.Errorf("%s: could not find class instance", classNameString)
+	return o.(*RubyKubeClassInstance).vars, nil
+}
+
+// object returns the resource object, so that it can be looked up without knowing the class
+func (o *RubyKubeClassInstance) object() interface{} {
+	return &o.vars.instanceVariableName
 }
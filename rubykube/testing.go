@@ -0,0 +1,351 @@
+package rubykube
+
+import (
+	"encoding/xml"
+	"flag"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	mruby "github.com/mitchellh/go-mruby"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+var testOutput = flag.String("test-output", "tap", "format of test results reported in script mode, tap or junit")
+
+// testingFramework defines `expect` and matchers, `describe`, `it` and `after` are verbs
+const testingFramework = `
+class RubyKube
+  class ExpectationNotMet < Error; end
+
+  class Expectation
+    def initialize(actual)
+      @actual = actual
+    end
+
+    def to(matcher)
+      raise ExpectationNotMet, matcher.failure_message(@actual) unless matcher.matches?(@actual)
+      true
+    end
+
+    def not_to(matcher)
+      raise ExpectationNotMet, matcher.failure_message(@actual, true) if matcher.matches?(@actual)
+      true
+    end
+    alias to_not not_to
+  end
+
+  class Matcher
+    def initialize(description, &block)
+      @description = description
+      @block = block
+    end
+
+    def matches?(actual)
+      @block.call(actual)
+    end
+
+    def failure_message(actual, negated = false)
+      subject = actual.inspect
+      if actual.respond_to?(:jsonpath)
+        subject = "#{actual.class} #{actual.jsonpath("{.metadata.namespace}").first}/#{actual.jsonpath("{.metadata.name}").first}"
+      end
+      "expected #{subject} #{negated ? "not " : ""}to #{@description}"
+    end
+  end
+end
+
+def expect(actual)
+  RubyKube::Expectation.new(actual)
+end
+
+def eq(expected)
+  RubyKube::Matcher.new("eq #{expected.inspect}") { |actual| actual == expected }
+end
+
+def be_ready
+  RubyKube::Matcher.new("be ready") { |actual| actual.ready? }
+end
+
+def have_replicas(n)
+  RubyKube::Matcher.new("have #{n} ready replicas") do |actual|
+    ready = actual.jsonpath("{.status.readyReplicas}").first || actual.jsonpath("{.status.numberReady}").first
+    (ready || 0) == n
+  end
+end
+
+def respond_to_http(opts = {})
+  path = opts[:path] || "/"
+  status = opts[:status] || 200
+  RubyKube::Matcher.new("respond with #{status} to GET #{path}") do |actual|
+    pod = actual.respond_to?(:http_status) ? actual : actual.pods.any
+    opts[:port] ? pod.http_status(path, port: opts[:port]) == status : pod.http_status(path) == status
+  end
+end
+
+# there is no Regexp in mruby, so the pattern is a string, which is matched by Go as an RE2 expression
+def have_log_line(pattern)
+  raise RubyKube::ArgumentError, "pattern must be a string, e.g. \"ERROR|FATAL\"" unless pattern.is_a?(String)
+  RubyKube::Matcher.new("have a log line matching #{pattern.inspect}") do |actual|
+    (actual.respond_to?(:logs) ? actual.logs : actual.pods.logs).match?(pattern)
+  end
+end
+`
+
+// temporaryNamespacePrefix is used for namespaces created by `describe "...", namespace: :temporary`
+const temporaryNamespacePrefix = "kubeplay-test-"
+
+type testResult struct {
+	name     string
+	failed   bool
+	errored  bool // an error other than a failed expectation
+	message  string
+	duration time.Duration
+}
+
+type testSuite struct {
+	name  string
+	after []*mruby.MrbValue
+}
+
+type testRunner struct {
+	results []testResult
+	suites  []*testSuite
+}
+
+func (t *testRunner) currentName(name string) string {
+	names := []string{}
+	for _, s := range t.suites {
+		names = append(names, s.name)
+	}
+	return strings.Join(append(names, name), " ")
+}
+
+// recordTestResult stores a result and prints it out as a TAP line, unless JUnit output was requested in script mode
+func (rk *RubyKube) recordTestResult(result testResult) {
+	rk.tests.results = append(rk.tests.results, result)
+
+	if rk.readline == nil && *testOutput == "junit" {
+		return
+	}
+
+	status := "ok"
+	if result.failed || result.errored {
+		status = "not ok"
+	}
+	fmt.Printf("%s %d - %s\n", status, len(rk.tests.results), result.name)
+	if result.message != "" {
+		fmt.Printf("  ---\n  message: %q\n  ...\n", result.message)
+	}
+}
+
+func isExpectationNotMet(err error) bool {
+	exc, ok := err.(*mruby.Exception)
+	if !ok || exc.MrbValue == nil {
+		return false
+	}
+	m := exc.Mrb()
+	isA, callErr := exc.Call("is_a?", m.Class("ExpectationNotMet", m.Class("RubyKube", nil)))
+	return callErr == nil && isA.Type() == mruby.TypeTrue
+}
+
+func blockArg(args []*mruby.MrbValue) *mruby.MrbValue {
+	for _, arg := range args {
+		if arg.Type() == mruby.TypeProc {
+			return arg
+		}
+	}
+	return nil
+}
+
+// describe groups tests, with `namespace: :temporary` it creates a namespace that all verbs use
+// within the block, and deletes it after `after` hooks have run
+func describe(rk *RubyKube, args []*mruby.MrbValue, m *mruby.Mrb, self *mruby.MrbValue) (mruby.Value, mruby.Value) {
+	block := blockArg(args)
+	if len(args) == 0 || args[0].Type() != mruby.TypeString || block == nil {
+		return nil, createException(m, "Usage: describe \"name\", namespace: :temporary do ... end")
+	}
+
+	namespace := ""
+	for _, arg := range args[1:] {
+		if arg.Type() != mruby.TypeHash {
+			continue
+		}
+		if err := iterateHash(arg, func(key, value *mruby.MrbValue) error {
+			if key.String() != "namespace" {
+				return newArgumentError("unknown parameter %q – only \"namespace\" is allowed", key.String())
+			}
+			namespace = value.String()
+			return nil
+		}); err != nil {
+			return nil, createError(m, err)
+		}
+	}
+
+	suite := &testSuite{name: args[0].String()}
+	rk.tests.suites = append(rk.tests.suites, suite)
+	defer func() { rk.tests.suites = rk.tests.suites[:len(rk.tests.suites)-1] }()
+
+	previousNamespace := rk.state.Namespace
+	defer rk.SetNamespace(previousNamespace)
+
+	if namespace == "temporary" {
+		if err := rk.allowChange(fmt.Sprintf("create a temporary namespace for %q", args[0].String())); err != nil {
+			return nil, createError(m, err)
+		}
+		ns, err := rk.clientset.Core().Namespaces().Create(&corev1.Namespace{
+			ObjectMeta: metav1.ObjectMeta{
+				GenerateName: temporaryNamespacePrefix,
+				Labels:       map[string]string{"kubeplay/temporary": "true"},
+			},
+		})
+		if err != nil {
+			return nil, createError(m, err)
+		}
+		namespace = ns.ObjectMeta.Name
+		defer rk.clientset.Core().Namespaces().Delete(namespace, &metav1.DeleteOptions{})
+	}
+	if namespace != "" {
+		rk.SetNamespace(namespace)
+	}
+
+	started := time.Now()
+	if _, err := block.Call("call"); err != nil {
+		// anything that fails outside of `it` is a setup error
+		rk.recordTestResult(testResult{
+			name:     rk.tests.currentName("(setup)"),
+			errored:  true,
+			message:  FormatError(err),
+			duration: time.Since(started),
+		})
+	}
+
+	for _, hook := range suite.after {
+		rk.unprotect(hook)
+		if _, err := hook.Call("call"); err != nil {
+			rk.recordTestResult(testResult{
+				name:    rk.tests.currentName("(teardown)"),
+				errored: true,
+				message: FormatError(err),
+			})
+		}
+	}
+
+	return nil, nil
+}
+
+// it runs a test right away, a test passes if the block doesn't raise
+func it(rk *RubyKube, args []*mruby.MrbValue, m *mruby.Mrb, self *mruby.MrbValue) (mruby.Value, mruby.Value) {
+	block := blockArg(args)
+	if len(args) == 0 || args[0].Type() != mruby.TypeString || block == nil {
+		return nil, createException(m, "Usage: it \"does something\" do ... end")
+	}
+
+	result := testResult{name: rk.tests.currentName(args[0].String())}
+
+	started := time.Now()
+	if _, err := block.Call("call"); err != nil {
+		result.message = FormatError(err)
+		if isExpectationNotMet(err) {
+			result.failed = true
+		} else {
+			result.errored = true
+		}
+	}
+	result.duration = time.Since(started)
+
+	rk.recordTestResult(result)
+
+	if result.failed || result.errored {
+		return m.FalseValue(), nil
+	}
+	return m.TrueValue(), nil
+}
+
+// after registers a block to run at the end of current `describe`
+func after(rk *RubyKube, args []*mruby.MrbValue, m *mruby.Mrb, self *mruby.MrbValue) (mruby.Value, mruby.Value) {
+	block := blockArg(args)
+	if block == nil {
+		return nil, createException(m, "Block must be given")
+	}
+	if len(rk.tests.suites) == 0 {
+		return nil, createException(m, "`after` must be called inside `describe`")
+	}
+
+	// the block is only referenced from Go until `describe` runs it
+	if err := rk.protect(block); err != nil {
+		return nil, createError(m, err)
+	}
+	suite := rk.tests.suites[len(rk.tests.suites)-1]
+	suite.after = append(suite.after, block)
+	return nil, nil
+}
+
+type junitTestSuite struct {
+	XMLName  xml.Name        `xml:"testsuite"`
+	Name     string          `xml:"name,attr"`
+	Tests    int             `xml:"tests,attr"`
+	Failures int             `xml:"failures,attr"`
+	Errors   int             `xml:"errors,attr"`
+	Time     string          `xml:"time,attr"`
+	Cases    []junitTestCase `xml:"testcase"`
+}
+
+type junitTestCase struct {
+	Name    string        `xml:"name,attr"`
+	Time    string        `xml:"time,attr"`
+	Failure *junitMessage `xml:"failure,omitempty"`
+	Error   *junitMessage `xml:"error,omitempty"`
+}
+
+type junitMessage struct {
+	Message string `xml:"message,attr"`
+	Text    string `xml:",chardata"`
+}
+
+// ReportTests writes a summary of tests that have run in script mode, that's a TAP plan line,
+// or a JUnit XML document; it returns the number of tests that have failed
+func (rk *RubyKube) ReportTests(w io.Writer) (int, error) {
+	results := rk.tests.results
+	if len(results) == 0 {
+		return 0, nil
+	}
+
+	failed := 0
+	suite := junitTestSuite{Name: "kubeplay", Tests: len(results)}
+	total := time.Duration(0)
+	for _, r := range results {
+		total += r.duration
+		c := junitTestCase{Name: r.name, Time: fmt.Sprintf("%.3f", r.duration.Seconds())}
+		switch {
+		case r.failed:
+			suite.Failures++
+			c.Failure = &junitMessage{Message: r.message, Text: r.message}
+		case r.errored:
+			suite.Errors++
+			c.Error = &junitMessage{Message: r.message, Text: r.message}
+		}
+		if r.failed || r.errored {
+			failed++
+		}
+		suite.Cases = append(suite.Cases, c)
+	}
+	suite.Time = fmt.Sprintf("%.3f", total.Seconds())
+
+	switch *testOutput {
+	case "tap":
+		_, err := fmt.Fprintf(w, "1..%d\n", len(results))
+		return failed, err
+	case "junit":
+		data, err := xml.MarshalIndent(suite, "", "  ")
+		if err != nil {
+			return failed, err
+		}
+		_, err = fmt.Fprintf(w, "%s%s\n", xml.Header, data)
+		return failed, err
+	default:
+		return failed, fmt.Errorf("unknown test output format %q", *testOutput)
+	}
+}
@@ -2,26 +2,25 @@ package rubykube
 
 import (
 	"encoding/json"
-	_ "errors"
+	"errors"
 	"fmt"
+	"os"
+	"os/exec"
 	"regexp"
 	"runtime/debug"
 	"strings"
 
 	mruby "github.com/mitchellh/go-mruby"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 )
 
 func init() {
 	debug.SetPanicOnFault(true)
 }
 
+// createException raises `RubyKube::ArgumentError`, use `createError` for errors returned by API calls
 func createException(m *mruby.Mrb, msg string) mruby.Value {
-	val, err := m.Class("Exception", nil).New(mruby.String(msg))
-	if err != nil {
-		panic(fmt.Sprintf("could not construct exception for return: %v", err))
-	}
-
-	return val
+	return createError(m, &argumentError{errors.New(msg)})
 }
 
 func extractStringArgs(args []*mruby.MrbValue) []string {
@@ -84,12 +83,32 @@ func iterateArray(arg *mruby.MrbValue, fn func(int, *mruby.MrbValue) error) erro
 func marshalToJSON(obj interface{}, m *mruby.Mrb) (mruby.Value, mruby.Value) {
 	data, err := json.MarshalIndent(obj, "", "  ")
 	if err != nil {
-		return nil, createException(m, err.Error())
+		return nil, createError(m, err)
 	}
 
 	return m.StringValue(string(data)), nil
 }
 
+// page shows text with `$PAGER` (or `less`), in script mode or when output is not a terminal,
+// text is printed as is
+func (rk *RubyKube) page(text string) error {
+	if info, err := os.Stdout.Stat(); rk.readline == nil || err != nil || info.Mode()&os.ModeCharDevice == 0 {
+		fmt.Print(text)
+		return nil
+	}
+
+	pager := os.Getenv("PAGER")
+	if pager == "" {
+		pager = "less -FRX"
+	}
+
+	cmd := exec.Command("sh", "-c", pager)
+	cmd.Stdin = strings.NewReader(text)
+	cmd.Stdout = os.Stdout
+	cmd.Stderr = os.Stderr
+	return cmd.Run()
+}
+
 type paramProcHandler func(*mruby.MrbValue) error
 type params struct {
 	allowed      []string
@@ -201,12 +220,12 @@ func NewParamsCollection(hash *mruby.MrbValue, spec params) (*paramsCollection,
 
 		return nil
 	}); err != nil {
-		return nil, fmt.Errorf("failed to parse given parameters – %v", err)
+		return nil, newArgumentError("failed to parse given parameters – %v", err)
 	}
 
 	for _, x := range spec.required {
 		if _, ok := params[x]; !ok {
-			return nil, fmt.Errorf("missing required parameter %q", x)
+			return nil, newArgumentError("missing required parameter %q", x)
 		}
 	}
 
@@ -251,7 +270,7 @@ func (c *paramsCollection) ToMapOfSlicesOfStrings() map[string][]string {
 
 func checkArgs(args []*mruby.MrbValue, l int) error {
 	if len(args) != l {
-		return fmt.Errorf("Expected %d arg, got %d", l, len(args))
+		return newArgumentError("Expected %d arg, got %d", l, len(args))
 	}
 
 	return nil
@@ -293,7 +312,15 @@ func call(self *mruby.MrbValue, method string, args ...*mruby.MrbValue) (mruby.V
 func callWithException(m *mruby.Mrb, self *mruby.MrbValue, method string, args ...*mruby.MrbValue) (mruby.Value, mruby.Value) {
 	v, err := call(self, method, args...)
 	if err != nil {
-		return nil, createException(m, err.Error())
+		return nil, createError(m, err)
 	}
 	return v, nil
 }
+
+// objectName returns `<namespace>/<name>`, or just the name for objects that have no namespace set yet
+func objectName(meta metav1.ObjectMeta) string {
+	if meta.Namespace == "" {
+		return meta.Name
+	}
+	return meta.Namespace + "/" + meta.Name
+}
@@ -28,12 +28,35 @@ func init() {
 		"deployments":         {deployments, mruby.ArgsReq(0) | mruby.ArgsOpt(2)},
 		"replicasets":         {replicaSets, mruby.ArgsReq(0) | mruby.ArgsOpt(2)},
 		"daemonsets":          {daemonSets, mruby.ArgsReq(0) | mruby.ArgsOpt(2)},
+		"jobs":                {jobs, mruby.ArgsReq(0) | mruby.ArgsOpt(2)},
+		"events":              {events, mruby.ArgsReq(0) | mruby.ArgsOpt(2)},
 		"make_pod":            {makePod, mruby.ArgsReq(1)},
 		"make_label_selector": {makeLabelSelector, mruby.ArgsReq(1)},
 		"make_field_selector": {makeFieldSelector, mruby.ArgsReq(1)},
 		"using":               {using, mruby.ArgsReq(0) | mruby.ArgsOpt(2)},
 		"namespace":           {namespace, mruby.ArgsReq(0) | mruby.ArgsOpt(2)},
 		"def_alias":           {defAlias, mruby.ArgsReq(2)},
+		"history":             {historyVerb, mruby.ArgsReq(0) | mruby.ArgsOpt(1)},
+		"audit":               {audit, mruby.ArgsReq(0) | mruby.ArgsOpt(2)},
+		"wait_until":          {waitUntil, mruby.ArgsReq(0) | mruby.ArgsOpt(1) | mruby.ArgsBlock()},
+		"describe":            {describe, mruby.ArgsReq(1) | mruby.ArgsOpt(1) | mruby.ArgsBlock()},
+		"it":                  {it, mruby.ArgsReq(1) | mruby.ArgsBlock()},
+		"after":               {after, mruby.ArgsBlock()},
+		"controller":          {controllerVerb, mruby.ArgsReq(1) | mruby.ArgsOpt(2) | mruby.ArgsBlock()},
+		"stats":               {stats, mruby.ArgsNone()},
+		"parallelism":         {parallelismVerb, mruby.ArgsReq(0) | mruby.ArgsOpt(1)},
+		"chaos":               {chaos, mruby.ArgsReq(0) | mruby.ArgsOpt(2) | mruby.ArgsBlock()},
+		"snapshot":            {snapshotVerb, mruby.ArgsReq(1) | mruby.ArgsRest()},
+		"compare_snapshot":    {compareSnapshot, mruby.ArgsReq(1) | mruby.ArgsRest()},
+		"load_snapshot":       {loadSnapshotVerb, mruby.ArgsReq(1)},
+		"can_i?":              {canI, mruby.ArgsReq(2) | mruby.ArgsOpt(1)},
+		"who_can":             {whoCanVerb, mruby.ArgsReq(2) | mruby.ArgsOpt(1)},
+		"as":                  {as, mruby.ArgsReq(1) | mruby.ArgsBlock()},
+		"netpol":              {netpol, mruby.ArgsReq(0) | mruby.ArgsOpt(1)},
+		"images":              {images, mruby.ArgsReq(0) | mruby.ArgsOpt(1)},
+		"security_rule":       {securityRuleVerb, mruby.ArgsReq(1) | mruby.ArgsOpt(1) | mruby.ArgsBlock()},
+		"security_rules":      {securityRulesVerb, mruby.ArgsNone()},
+		"audit_security":      {auditSecurity, mruby.ArgsReq(1) | mruby.ArgsOpt(1)},
 	}
 }
 
@@ -47,11 +70,11 @@ func pods(rk *RubyKube, args []*mruby.MrbValue, m *mruby.Mrb, self *mruby.MrbVal
 
 	newPodsObj, err := rk.classes.Pods.New()
 	if err != nil {
-		return nil, createException(m, err.Error())
+		return nil, createError(m, err)
 	}
 
 	if value, err = newPodsObj.Update(args...); err != nil {
-		return nil, createException(m, err.Error())
+		return nil, createError(m, err)
 	}
 	return value, nil
 }
@@ -64,11 +87,11 @@ func services(rk *RubyKube, args []*mruby.MrbValue, m *mruby.Mrb, self *mruby.Mr
 
 	newServicesObj, err := rk.classes.Services.New()
 	if err != nil {
-		return nil, createException(m, err.Error())
+		return nil, createError(m, err)
 	}
 
 	if value, err = newServicesObj.Update(args...); err != nil {
-		return nil, createException(m, err.Error())
+		return nil, createError(m, err)
 	}
 	return value, nil
 }
@@ -81,11 +104,11 @@ func deployments(rk *RubyKube, args []*mruby.MrbValue, m *mruby.Mrb, self *mruby
 
 	newDeploymentsObj, err := rk.classes.Deployments.New()
 	if err != nil {
-		return nil, createException(m, err.Error())
+		return nil, createError(m, err)
 	}
 
 	if value, err = newDeploymentsObj.Update(args...); err != nil {
-		return nil, createException(m, err.Error())
+		return nil, createError(m, err)
 	}
 	return value, nil
 }
@@ -98,11 +121,28 @@ func replicaSets(rk *RubyKube, args []*mruby.MrbValue, m *mruby.Mrb, self *mruby
 
 	newReplicaSetsObj, err := rk.classes.ReplicaSets.New()
 	if err != nil {
-		return nil, createException(m, err.Error())
+		return nil, createError(m, err)
 	}
 
 	if value, err = newReplicaSetsObj.Update(args...); err != nil {
-		return nil, createException(m, err.Error())
+		return nil, createError(m, err)
+	}
+	return value, nil
+}
+
+func jobs(rk *RubyKube, args []*mruby.MrbValue, m *mruby.Mrb, self *mruby.MrbValue) (mruby.Value, mruby.Value) {
+	var (
+		value mruby.Value
+		err   error
+	)
+
+	newJobsObj, err := rk.classes.Jobs.New()
+	if err != nil {
+		return nil, createError(m, err)
+	}
+
+	if value, err = newJobsObj.Update(args...); err != nil {
+		return nil, createError(m, err)
 	}
 	return value, nil
 }
@@ -115,11 +155,11 @@ func daemonSets(rk *RubyKube, args []*mruby.MrbValue, m *mruby.Mrb, self *mruby.
 
 	newReplicaSetsObj, err := rk.classes.DaemonSets.New()
 	if err != nil {
-		return nil, createException(m, err.Error())
+		return nil, createError(m, err)
 	}
 
 	if value, err = newReplicaSetsObj.Update(args...); err != nil {
-		return nil, createException(m, err.Error())
+		return nil, createError(m, err)
 	}
 	return value, nil
 }
@@ -132,12 +172,12 @@ func makePod(rk *RubyKube, args []*mruby.MrbValue, m *mruby.Mrb, self *mruby.Mrb
 	newPodMakerObj, err := rk.classes.PodMaker.New()
 
 	if err != nil {
-		return nil, createException(m, err.Error())
+		return nil, createError(m, err)
 	}
 
 	value, err := newPodMakerObj.self.Call("pod!", toValues(args)...)
 	if err != nil {
-		return nil, createException(m, err.Error())
+		return nil, createError(m, err)
 	}
 
 	return value, nil
@@ -145,12 +185,12 @@ func makePod(rk *RubyKube, args []*mruby.MrbValue, m *mruby.Mrb, self *mruby.Mrb
 
 func makeLabelSelector(rk *RubyKube, args []*mruby.MrbValue, m *mruby.Mrb, self *mruby.MrbValue) (mruby.Value, mruby.Value) {
 	if err := checkArgs(args, 1); err != nil {
-		return nil, createException(m, err.Error())
+		return nil, createError(m, err)
 	}
 
 	newLabelNameObj, err := rk.classes.LabelSelector.New(toValues(args)...)
 	if err != nil {
-		return nil, createException(m, err.Error())
+		return nil, createError(m, err)
 	}
 
 	return newLabelNameObj.self, nil
@@ -158,12 +198,12 @@ func makeLabelSelector(rk *RubyKube, args []*mruby.MrbValue, m *mruby.Mrb, self
 
 func makeFieldSelector(rk *RubyKube, args []*mruby.MrbValue, m *mruby.Mrb, self *mruby.MrbValue) (mruby.Value, mruby.Value) {
 	if err := checkArgs(args, 1); err != nil {
-		return nil, createException(m, err.Error())
+		return nil, createError(m, err)
 	}
 
 	newFieldNameObj, err := rk.classes.FieldSelector.New(toValues(args)...)
 	if err != nil {
-		return nil, createException(m, err.Error())
+		return nil, createError(m, err)
 	}
 
 	return newFieldNameObj.self, nil
@@ -189,7 +229,7 @@ func using(rk *RubyKube, args []*mruby.MrbValue, m *mruby.Mrb, self *mruby.MrbVa
 	)
 
 	if err != nil {
-		return nil, createException(m, err.Error())
+		return nil, createError(m, err)
 	}
 
 	p := pc.ToMapOfStrings()
@@ -245,12 +285,13 @@ func defAlias(rk *RubyKube, args []*mruby.MrbValue, m *mruby.Mrb, self *mruby.Mr
 	aliasFunc := func(m *mruby.Mrb, _ *mruby.MrbValue) (mruby.Value, mruby.Value) {
 		value, err := self.Call(verb, toValues(m.GetArgs())...)
 		if err != nil {
-			return nil, createException(m, err.Error())
+			return nil, createError(m, err)
 		}
 		return value, nil
 	}
 
 	rk.mrb.TopSelf().SingletonClass().DefineMethod(alias, aliasFunc, mruby.ArgsAny())
+	rk.aliases[alias] = verb
 
 	return nil, nil
 }
@@ -0,0 +1,269 @@
+package rubykube
+
+import (
+	"fmt"
+	"os"
+	"os/signal"
+	"time"
+
+	mruby "github.com/mitchellh/go-mruby"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/fields"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/watch"
+)
+
+const (
+	defaultWaitTimeout = 120 * time.Second
+	// pollInterval is used by `wait_until`, and when a watch cannot be established
+	pollInterval = 2 * time.Second
+)
+
+// timeoutError maps to `RubyKube::Timeout`
+type timeoutError struct{ error }
+
+// waitCondition is given the latest version of an object, or nil if it's been deleted
+type waitCondition func(obj runtime.Object) (bool, error)
+
+// waitTimeout parses `timeout: <seconds>` option that all wait methods accept
+func waitTimeout(args []*mruby.MrbValue) (time.Duration, error) {
+	timeout := defaultWaitTimeout
+	for _, arg := range args {
+		if arg.Type() != mruby.TypeHash {
+			continue
+		}
+		if err := iterateHash(arg, func(key, value *mruby.MrbValue) error {
+			if key.String() != "timeout" {
+				return newArgumentError("unknown parameter %q – only \"timeout\" is allowed", key.String())
+			}
+			switch value.Type() {
+			case mruby.TypeFixnum:
+				timeout = time.Duration(value.Fixnum()) * time.Second
+			case mruby.TypeFloat:
+				timeout = time.Duration(value.Float() * float64(time.Second))
+			default:
+				return newArgumentError("timeout must be a number of seconds")
+			}
+			return nil
+		}); err != nil {
+			return 0, err
+		}
+	}
+	if timeout <= 0 {
+		return 0, newArgumentError("timeout must be positive")
+	}
+	return timeout, nil
+}
+
+// progress shows a spinner with elapsed time while waiting, only in the REPL
+type progress struct {
+	enabled bool
+	what    string
+	started time.Time
+	frame   int
+}
+
+func (rk *RubyKube) newProgress(what string) *progress {
+	p := &progress{enabled: rk.readline != nil, what: what, started: time.Now()}
+	p.tick()
+	return p
+}
+
+func (p *progress) tick() {
+	if !p.enabled {
+		return
+	}
+	frames := `|/-\`
+	fmt.Printf("\r%c waiting for %s (%s)", frames[p.frame%len(frames)], p.what, time.Since(p.started).Truncate(time.Second))
+	p.frame++
+}
+
+func (p *progress) done() {
+	if p.enabled {
+		fmt.Print("\r\033[K")
+	}
+}
+
+func newTimeoutError(what string, timeout time.Duration) error {
+	return &timeoutError{fmt.Errorf("timed out after %s waiting for %s", timeout, what)}
+}
+
+// waitForObject waits until condition is met for a single object, it watches the object
+// and falls back to polling if a watch cannot be established; the last seen version of
+// the object is returned (nil if it's been deleted), waiting can be interrupted with ^C
+func (rk *RubyKube) waitForObject(what, name string, timeout time.Duration,
+	get func() (runtime.Object, error),
+	watchObjects func(metav1.ListOptions) (watch.Interface, error),
+	condition waitCondition) (runtime.Object, error) {
+
+	deadline := time.Now().Add(timeout)
+	p := rk.newProgress(what)
+	defer p.done()
+
+	interrupt := make(chan os.Signal, 1)
+	signal.Notify(interrupt, os.Interrupt)
+	defer signal.Stop(interrupt)
+
+	ticker := time.NewTicker(time.Second)
+	defer ticker.Stop()
+
+	getAndCheck := func() (runtime.Object, string, bool, error) {
+		obj, err := get()
+		if apierrors.IsNotFound(err) {
+			ok, err := condition(nil)
+			return nil, "", ok, err
+		}
+		if err != nil {
+			return nil, "", false, err
+		}
+		resourceVersion := ""
+		if accessor, err := meta.Accessor(obj); err == nil {
+			resourceVersion = accessor.GetResourceVersion()
+		}
+		ok, err := condition(obj)
+		return obj, resourceVersion, ok, err
+	}
+
+	for {
+		obj, resourceVersion, ok, err := getAndCheck()
+		if ok || err != nil {
+			return obj, err
+		}
+
+		if time.Now().After(deadline) {
+			return obj, newTimeoutError(what, timeout)
+		}
+
+		w, err := watchObjects(metav1.ListOptions{
+			FieldSelector:   fields.OneTermEqualSelector("metadata.name", name).String(),
+			ResourceVersion: resourceVersion,
+		})
+		if err != nil {
+			// polling fallback
+			wait := pollInterval
+			if remaining := time.Until(deadline); remaining < wait {
+				wait = remaining
+			}
+			select {
+			case <-time.After(wait):
+			case <-interrupt:
+				return obj, newInterruptedError()
+			}
+			p.tick()
+			continue
+		}
+
+		obj, ok, err = rk.watchUntil(w, deadline, ticker, interrupt, p, condition)
+		w.Stop()
+		if ok || err != nil {
+			return obj, err
+		}
+		// watch has expired or closed, get the object again and start over
+	}
+}
+
+func (rk *RubyKube) watchUntil(w watch.Interface, deadline time.Time, ticker *time.Ticker, interrupt <-chan os.Signal, p *progress, condition waitCondition) (runtime.Object, bool, error) {
+	timer := time.NewTimer(time.Until(deadline))
+	defer timer.Stop()
+
+	for {
+		select {
+		case event, open := <-w.ResultChan():
+			if !open {
+				return nil, false, nil
+			}
+			switch event.Type {
+			case watch.Added, watch.Modified:
+				ok, err := condition(event.Object)
+				if ok || err != nil {
+					return event.Object, ok, err
+				}
+			case watch.Deleted:
+				ok, err := condition(nil)
+				if ok || err != nil {
+					return nil, ok, err
+				}
+			case watch.Error:
+				return nil, false, nil
+			}
+		case <-ticker.C:
+			p.tick()
+		case <-timer.C:
+			return nil, false, nil
+		case <-interrupt:
+			return nil, false, newInterruptedError()
+		}
+	}
+}
+
+// objectDeleted is the condition for `wait_deleted`
+func objectDeleted(obj runtime.Object) (bool, error) {
+	return obj == nil, nil
+}
+
+// waitUntil implements `wait_until(timeout: 120) { ... }`, which calls the block until it returns
+// a truthy value, the value is returned; the block is called for the last time at the deadline,
+// and waiting can be interrupted with ^C
+func waitUntil(rk *RubyKube, args []*mruby.MrbValue, m *mruby.Mrb, self *mruby.MrbValue) (mruby.Value, mruby.Value) {
+	var block *mruby.MrbValue
+	for _, arg := range args {
+		if arg.Type() == mruby.TypeProc {
+			block = arg
+		}
+	}
+	if block == nil {
+		return nil, createException(m, "Block must be given")
+	}
+
+	timeout, err := waitTimeout(args)
+	if err != nil {
+		return nil, createError(m, err)
+	}
+
+	what := "block to return true"
+	deadline := time.Now().Add(timeout)
+	p := rk.newProgress(what)
+	defer p.done()
+
+	interrupt := make(chan os.Signal, 1)
+	signal.Notify(interrupt, os.Interrupt)
+	defer signal.Stop(interrupt)
+
+	ticker := time.NewTicker(time.Second)
+	defer ticker.Stop()
+
+	for {
+		value, err := block.Call("call")
+		if err != nil {
+			return nil, createError(m, err)
+		}
+		if t := value.Type(); t != mruby.TypeNil && t != mruby.TypeFalse {
+			return value, nil
+		}
+
+		remaining := time.Until(deadline)
+		if remaining <= 0 {
+			return nil, createError(m, newTimeoutError(what, timeout))
+		}
+		wait := pollInterval
+		if remaining < wait {
+			wait = remaining
+		}
+
+		timer := time.NewTimer(wait)
+	sleep:
+		for {
+			select {
+			case <-ticker.C:
+				p.tick()
+			case <-timer.C:
+				break sleep
+			case <-interrupt:
+				timer.Stop()
+				return nil, createError(m, newInterruptedError())
+			}
+		}
+	}
+}